@@ -10,14 +10,19 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/shindakun/attodo/internal/chat"
 	"github.com/shindakun/attodo/internal/config"
 	"github.com/shindakun/attodo/internal/database"
 	"github.com/shindakun/attodo/internal/handlers"
 	"github.com/shindakun/attodo/internal/jobs"
+	"github.com/shindakun/attodo/internal/mail"
 	"github.com/shindakun/attodo/internal/middleware"
+	"github.com/shindakun/attodo/internal/notify"
 	"github.com/shindakun/attodo/internal/push"
+	"github.com/shindakun/attodo/internal/session"
 	stripeClient "github.com/shindakun/attodo/internal/stripe"
 	"github.com/shindakun/attodo/internal/supporter"
+	"github.com/shindakun/attodo/internal/webhook"
 )
 
 func main() {
@@ -38,6 +43,7 @@ func main() {
 	// Initialize repositories
 	notificationRepo := database.NewNotificationRepo(db)
 	supporterRepo := database.NewSupporterRepo(db)
+	followRepo := database.NewFollowRepo(db)
 
 	// Initialize services
 	supporterService := supporter.NewService(supporterRepo)
@@ -49,8 +55,17 @@ func main() {
 	listHandler := handlers.NewListHandler(authHandler.Client())
 	settingsHandler := handlers.NewSettingsHandler(authHandler.Client())
 	pushHandler := handlers.NewPushHandler(notificationRepo)
+	emailHandler := handlers.NewEmailHandler(notificationRepo, cfg.BaseURL)
+	blueskyDMHandler := handlers.NewBlueskyDMHandler(notificationRepo, cfg.BaseURL)
 	calendarHandler := handlers.NewCalendarHandler(authHandler.Client())
 	icalHandler := handlers.NewICalHandler(authHandler.Client())
+	followHandler := handlers.NewFollowHandler(authHandler.Client(), listHandler)
+	profileHandler := handlers.NewProfileHandler(listHandler, supporterService)
+	viewHandler := handlers.NewViewHandler(authHandler.Client(), listHandler)
+	boardHandler := handlers.NewBoardHandler(authHandler.Client(), taskHandler, listHandler)
+	planHandler := handlers.NewPlanHandler(authHandler.Client(), taskHandler)
+	gtdHandler := handlers.NewGTDHandler(authHandler.Client(), taskHandler)
+	digestHandler := handlers.NewDigestHandler(taskHandler, settingsHandler)
 
 	// Initialize Stripe client and supporter handler (only if Stripe keys are configured)
 	var supporterHandler *handlers.SupporterHandler
@@ -65,33 +80,98 @@ func main() {
 	// Wire up cross-references between handlers
 	taskHandler.SetListHandler(listHandler)
 
+	// Notifications are delivered over every configured channel, per each
+	// user's channel settings
+	notifier := notify.NewDispatcher(settingsHandler)
+
 	// Initialize push notification sender (only if VAPID keys are configured)
 	var pushSender *push.Sender
-	var taskJobRunner *jobs.Runner
-	var calendarJobRunner *jobs.Runner
+	var webPush *notify.WebPush
 	if cfg.VAPIDPublicKey != "" && cfg.VAPIDPrivateKey != "" {
 		pushSender = push.NewSender(cfg.VAPIDPublicKey, cfg.VAPIDPrivateKey, cfg.VAPIDSubscriber)
 		pushHandler.SetSender(pushSender)
+		webPush = notify.NewWebPush(notificationRepo, pushSender)
+		notifier.Register(webPush)
 		log.Println("Push notification sender initialized")
-
-		// Initialize background job runner for task notifications (check every 5 minutes)
-		taskJobRunner = jobs.NewRunner(5 * time.Minute)
-		notificationJob := jobs.NewNotificationCheckJob(notificationRepo, authHandler.Client(), pushSender)
-		taskJobRunner.AddJob(notificationJob)
-		taskJobRunner.Start()
-		log.Println("Task notification job runner started (5 minute interval)")
-
-		// Initialize background job runner for calendar notifications (check every 30 minutes)
-		calendarJobRunner = jobs.NewRunner(30 * time.Minute)
-		calendarNotificationJob := jobs.NewCalendarNotificationJob(notificationRepo, authHandler.Client(), pushSender, settingsHandler)
-		calendarJobRunner.AddJob(calendarNotificationJob)
-		calendarJobRunner.Start()
-		log.Println("Calendar notification job runner started (30 minute interval)")
 	} else {
 		log.Println("VAPID keys not configured - push notifications disabled")
 		log.Println("Run 'go run ./cmd/vapid' to generate VAPID keys")
 	}
 
+	// Initialize email sender (only if SMTP is configured)
+	if cfg.SMTPHost != "" && cfg.SMTPFrom != "" {
+		mailSender := mail.NewSender(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom)
+		emailHandler.SetSender(mailSender)
+		notifier.Register(notify.NewEmail(notificationRepo, mailSender, cfg.BaseURL))
+		log.Printf("Email sender initialized (%s:%s)", cfg.SMTPHost, cfg.SMTPPort)
+	} else {
+		log.Println("SMTP not configured - email notifications disabled")
+	}
+
+	// Initialize Bluesky DM sender (only if a service account is configured)
+	if cfg.BlueskyDMIdentifier != "" && cfg.BlueskyDMPassword != "" {
+		chatSender := chat.NewSender(cfg.BlueskyDMService, cfg.BlueskyDMIdentifier, cfg.BlueskyDMPassword)
+		blueskyDMHandler.SetSender(chatSender)
+		notifier.Register(notify.NewBluesky(notificationRepo, chatSender, cfg.BaseURL))
+		log.Printf("Bluesky DM sender initialized (%s)", cfg.BlueskyDMIdentifier)
+	} else {
+		log.Println("Bluesky service account not configured - Bluesky DM notifications disabled")
+	}
+
+	// Webhook, ntfy and Gotify targets need no server setup
+	webhookSender := webhook.NewSender(cfg.WebhookAllowPrivate)
+	webhookHandler := handlers.NewWebhookHandler(notificationRepo, webhookSender, cfg.BaseURL)
+	notifier.Register(notify.NewWebhooks(notificationRepo, webhookSender, cfg.BaseURL))
+
+	// Background jobs share one runner; each job has its own schedule
+	jobRunRepo := database.NewJobRunRepo(db)
+	jobRunner := jobs.NewRunner(jobRunRepo)
+
+	// Only the instance holding a job's lease runs it, so several instances
+	// can share the database without sending every notification twice
+	instanceID := cfg.InstanceID
+	if instanceID == "" {
+		instanceID = jobs.DefaultInstanceID()
+	}
+	jobLeases := jobs.NewLeases(database.NewLeaseRepo(db), instanceID)
+	jobRunner.UseLeases(jobLeases, jobs.DefaultLeaseTTL)
+
+	// Task notifications (check every 5 minutes). Webhook targets need no
+	// server setup, so there is always a channel to send through.
+	jobRunner.AddJob(jobs.NewNotificationCheckJob(notificationRepo, authHandler.Client(), notifier), jobs.Every(5*time.Minute), 30*time.Second)
+	jobRunner.AddJob(jobs.NewFollowActivityJob(notificationRepo, followRepo, followHandler, notifier), jobs.Every(5*time.Minute), 30*time.Second)
+	jobRunner.AddJob(jobs.NewDailyPlanningJob(notificationRepo, planHandler, settingsHandler, notifier), jobs.Every(5*time.Minute), 30*time.Second)
+	jobRunner.AddJob(jobs.NewWaitingFollowUpJob(notificationRepo, gtdHandler, notifier), jobs.Every(5*time.Minute), 30*time.Second)
+	jobRunner.AddJob(jobs.NewDigestJob(notificationRepo, digestHandler, settingsHandler, notifier), jobs.Every(5*time.Minute), 30*time.Second)
+	reminderScheduler := jobs.NewReminderScheduler(notificationRepo, taskHandler, notifier)
+	reminderScheduler.SetLeases(jobLeases)
+	jobRunner.AddJob(reminderScheduler, jobs.Every(5*time.Minute), 0)
+	taskHandler.SetReminderScheduler(reminderScheduler)
+
+	// Calendar notifications (check every 30 minutes)
+	jobRunner.AddJob(jobs.NewCalendarNotificationJob(notificationRepo, authHandler.Client(), notifier, settingsHandler), jobs.Every(30*time.Minute), time.Minute)
+
+	// Queued push retries (every 30 seconds)
+	if webPush != nil {
+		jobRunner.AddJob(jobs.NewPushRetryJob(webPush), jobs.Every(30*time.Second), 0)
+	}
+
+	// List maintenance (every 15 minutes). It acts for the users whose sessions
+	// this instance holds in memory, so every instance runs it, not just the
+	// lease holder.
+	jobRunner.AddLocalJob(jobs.NewSmartListMaterializeJob(listHandler), jobs.Every(15*time.Minute), time.Minute)
+
+	// Database maintenance (nightly at 3:30)
+	jobRunner.AddJob(jobs.NewMaintenanceJob(db, notificationRepo, supporterRepo, jobRunRepo, jobs.Retention{
+		NotificationHistoryDays: cfg.HistoryRetentionDays,
+		PushSubscriptionDays:    cfg.PushSubscriptionRetentionDays,
+		OrphanedUserDays:        cfg.OrphanedUserRetentionDays,
+		ExpiredSupporterDays:    cfg.SupporterRetentionDays,
+		JobRunDays:              cfg.JobRunRetentionDays,
+	}), jobs.MustCron("30 3 * * *"), 10*time.Minute)
+
+	jobRunner.Start()
+
 	// Initialize templates
 	handlers.InitTemplates(cfg)
 
@@ -144,26 +224,82 @@ func main() {
 	mux.HandleFunc("/list/", listHandler.HandlePublicListView)
 	logRoute("GET /list/*")
 
+	// Public list embeds
+	mux.HandleFunc("/oembed", listHandler.HandleOEmbed)
+	logRoute("GET /oembed")
+	mux.HandleFunc("/embed/list/", listHandler.HandleEmbed)
+	logRoute("GET /embed/list/@{handle}/{rkey}")
+	mux.HandleFunc("/badge/list/", listHandler.HandleBadge)
+	logRoute("GET /badge/list/@{handle}/{rkey}.svg")
+
+	// Public user profile route
+	mux.HandleFunc("/u/", profileHandler.HandleProfile)
+	logRoute("GET /u/@{handle}")
+
 	// Public iCal feed routes
 	mux.HandleFunc("/calendar/feed/", icalHandler.GenerateCalendarFeed)
 	logRoute("GET /calendar/feed/{did}/events.ics")
 	mux.HandleFunc("/tasks/feed/", icalHandler.GenerateTasksFeed)
 	logRoute("GET /tasks/feed/{did}/tasks.ics")
 
+	// Email notification links (the token identifies the address)
+	mux.HandleFunc("/email/verify", emailHandler.HandleVerify)
+	logRoute("GET /email/verify")
+	mux.HandleFunc("/email/unsubscribe", emailHandler.HandleUnsubscribe)
+	logRoute("GET/POST /email/unsubscribe")
+
 	// Protected routes
-	mux.Handle("/app", authMiddleware.RequireAuth(http.HandlerFunc(handleDashboard)))
+	mux.Handle("/app", authMiddleware.RequireAuth(handleDashboard(settingsHandler)))
 	logRoute("GET /app [protected]")
 	mux.Handle("/app/tasks", authMiddleware.RequireAuth(http.HandlerFunc(taskHandler.HandleTasks)))
 	logRoute("GET/POST /app/tasks [protected]")
+	mux.Handle("/app/tasks/order", authMiddleware.RequireAuth(http.HandlerFunc(taskHandler.HandleTaskOrder)))
+	logRoute("POST /app/tasks/order [protected]")
+	mux.Handle("/app/today", authMiddleware.RequireAuth(http.HandlerFunc(planHandler.HandleToday)))
+	logRoute("GET/POST /app/today [protected]")
+	mux.Handle("/app/review", authMiddleware.RequireAuth(http.HandlerFunc(gtdHandler.HandleReview)))
+	logRoute("GET/POST /app/review [protected]")
+	mux.Handle("/app/digest", authMiddleware.RequireAuth(http.HandlerFunc(digestHandler.HandleDigest)))
+	logRoute("GET /app/digest [protected]")
 	mux.Handle("/app/lists", authMiddleware.RequireAuth(http.HandlerFunc(listHandler.HandleLists)))
 	logRoute("GET/POST /app/lists [protected]")
 	mux.Handle("/app/lists/view/", authMiddleware.RequireAuth(http.HandlerFunc(listHandler.HandleListDetail)))
 	logRoute("GET /app/lists/view/* [protected]")
+	mux.Handle("/app/lists/clone", authMiddleware.RequireAuth(http.HandlerFunc(listHandler.HandleCloneList)))
+	logRoute("POST /app/lists/clone [protected]")
+	mux.Handle("/app/lists/order", authMiddleware.RequireAuth(http.HandlerFunc(listHandler.HandleListOrder)))
+	logRoute("POST /app/lists/order [protected]")
+	mux.Handle("/app/lists/board/", authMiddleware.RequireAuth(http.HandlerFunc(boardHandler.HandleBoard)))
+	logRoute("GET /app/lists/board/* [protected]")
+	mux.Handle("/app/lists/board/move", authMiddleware.RequireAuth(http.HandlerFunc(boardHandler.HandleMove)))
+	logRoute("POST /app/lists/board/move [protected]")
+	mux.Handle("/app/lists/board/columns", authMiddleware.RequireAuth(http.HandlerFunc(boardHandler.HandleColumns)))
+	logRoute("POST /app/lists/board/columns [protected]")
+	mux.Handle("/app/follows", authMiddleware.RequireAuth(http.HandlerFunc(followHandler.HandleFollows)))
+	logRoute("GET/POST/DELETE /app/follows [protected]")
+	mux.Handle("/app/feed", authMiddleware.RequireAuth(http.HandlerFunc(followHandler.HandleFeed)))
+	logRoute("GET /app/feed [protected]")
+	mux.Handle("/app/views", authMiddleware.RequireAuth(http.HandlerFunc(viewHandler.HandleViews)))
+	logRoute("GET/POST/DELETE /app/views [protected]")
 	mux.Handle("/app/settings", authMiddleware.RequireAuth(http.HandlerFunc(settingsHandler.HandleSettings)))
 	logRoute("GET/PUT /app/settings [protected]")
 	mux.Handle("/app/user", authMiddleware.RequireAuth(http.HandlerFunc(authHandler.GetUserInfo)))
 	logRoute("GET /app/user [protected]")
 
+	// Email and Bluesky DM routes
+	mux.Handle("/app/email", authMiddleware.RequireAuth(http.HandlerFunc(emailHandler.HandleEmail)))
+	logRoute("GET/POST/DELETE /app/email [protected]")
+	mux.Handle("/app/bluesky-dm", authMiddleware.RequireAuth(http.HandlerFunc(blueskyDMHandler.HandleBlueskyDM)))
+	logRoute("GET/POST/DELETE /app/bluesky-dm [protected]")
+
+	// Webhook routes
+	mux.Handle("/app/webhooks", authMiddleware.RequireAuth(http.HandlerFunc(webhookHandler.HandleWebhooks)))
+	logRoute("GET/POST/DELETE /app/webhooks [protected]")
+	mux.Handle("/app/webhooks/test", authMiddleware.RequireAuth(http.HandlerFunc(webhookHandler.HandleTest)))
+	logRoute("POST /app/webhooks/test [protected]")
+	mux.Handle("/app/webhooks/enable", authMiddleware.RequireAuth(http.HandlerFunc(webhookHandler.HandleEnable)))
+	logRoute("POST /app/webhooks/enable [protected]")
+
 	// Push notification routes
 	mux.Handle("/app/push/vapid-key", authMiddleware.RequireAuth(http.HandlerFunc(pushHandler.HandleGetVAPIDKey)))
 	logRoute("GET /app/push/vapid-key [protected]")
@@ -207,6 +343,15 @@ func main() {
 		logRoute("POST /supporter/webhook [public - webhook]")
 	}
 
+	// Job status and manual triggers (only if an admin token is configured)
+	if cfg.JobsAdminToken != "" {
+		jobAdminHandler := jobs.NewAdminHandler(jobRunner, jobRunRepo, cfg.JobsAdminToken)
+		mux.HandleFunc("/admin/jobs", jobAdminHandler.HandleStatus)
+		logRoute("GET /admin/jobs [admin token]")
+		mux.HandleFunc("/admin/jobs/run", jobAdminHandler.HandleRun)
+		logRoute("POST /admin/jobs/run?job= [admin token]")
+	}
+
 	// Log all registered routes
 	log.Println("Registered routes:")
 	for _, route := range routes {
@@ -244,12 +389,10 @@ func main() {
 	<-sigChan
 	log.Println("Shutting down gracefully...")
 
-	// Stop background jobs
-	if taskJobRunner != nil {
-		taskJobRunner.Stop()
-	}
-	if calendarJobRunner != nil {
-		calendarJobRunner.Stop()
+	// Stop background jobs, waiting for running ones to finish
+	jobRunner.Stop()
+	if reminderScheduler != nil {
+		reminderScheduler.Stop()
 	}
 
 	log.Println("Shutdown complete")
@@ -274,8 +417,19 @@ func handleLanding(authHandler *handlers.AuthHandler) http.HandlerFunc {
 	}
 }
 
-func handleDashboard(w http.ResponseWriter, r *http.Request) {
-	handlers.Render(w, "dashboard.html", nil)
+// handleDashboard renders the app, recording when the user opens it for
+// smart notification timing
+func handleDashboard(settingsHandler *handlers.SettingsHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if sess, ok := session.GetSession(r); ok {
+			sessionID := ""
+			if cookie, err := r.Cookie("session_id"); err == nil {
+				sessionID = cookie.Value
+			}
+			settingsHandler.RecordAppUsage(sess, sessionID)
+		}
+		handlers.Render(w, "dashboard.html", nil)
+	}
 }
 
 func handleHealth(cfg *config.Config) http.HandlerFunc {
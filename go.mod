@@ -10,6 +10,7 @@ require (
 	github.com/mattn/go-sqlite3 v1.14.32
 	github.com/shindakun/bskyoauth v1.4.2
 	github.com/stripe/stripe-go/v84 v84.0.0
+	golang.org/x/image v0.25.0
 )
 
 require (
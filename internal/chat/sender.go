@@ -0,0 +1,404 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// chatProxy routes chat calls through the PDS to the Bluesky chat service
+const chatProxy = "did:web:api.bsky.chat#bsky_chat"
+
+const (
+	// DefaultMinInterval is the minimum spacing between any two messages
+	DefaultMinInterval = time.Second
+
+	// DefaultMaxPerHour is how many messages one recipient gets per hour
+	DefaultMaxPerHour = 10
+
+	// unavailableRetry is how long a recipient who doesn't accept messages
+	// is skipped before trying again
+	unavailableRetry = 6 * time.Hour
+
+	// maxTextLength keeps messages under the chat service's 1000 character
+	// limit, with room for the link
+	maxTextLength = 800
+)
+
+var (
+	// ErrRecipientUnavailable means the recipient doesn't accept messages
+	// from the service account
+	ErrRecipientUnavailable = errors.New("recipient does not accept direct messages")
+
+	// ErrRateLimited means the message was dropped by a rate limit
+	ErrRateLimited = errors.New("direct message rate limit reached")
+)
+
+// Sender sends Bluesky chat direct messages from a service account. It logs
+// in with an app password that is allowed to access direct messages and
+// proxies chat calls through the account's PDS.
+type Sender struct {
+	service    string
+	identifier string
+	password   string
+	client     *http.Client
+
+	minInterval time.Duration
+	maxPerHour  int
+
+	mu          sync.Mutex
+	session     *session
+	convos      map[string]string      // Recipient DID -> conversation ID
+	unavailable map[string]time.Time   // Recipient DID -> when to try again
+	sent        map[string][]time.Time // Recipient DID -> sends in the last hour
+	nextSend    time.Time
+}
+
+// session is the service account's login
+type session struct {
+	AccessJwt string `json:"accessJwt"`
+	DID       string `json:"did"`
+	Handle    string `json:"handle"`
+}
+
+// NewSender creates a new direct message sender
+func NewSender(service, identifier, password string) *Sender {
+	return &Sender{
+		service:     strings.TrimSuffix(service, "/"),
+		identifier:  identifier,
+		password:    password,
+		client:      &http.Client{Timeout: 15 * time.Second},
+		minInterval: DefaultMinInterval,
+		maxPerHour:  DefaultMaxPerHour,
+		convos:      make(map[string]string),
+		unavailable: make(map[string]time.Time),
+		sent:        make(map[string][]time.Time),
+	}
+}
+
+// Account returns the service account's handle, logging in if needed
+func (s *Sender) Account(ctx context.Context) (string, error) {
+	sess, err := s.login(ctx, false)
+	if err != nil {
+		return "", err
+	}
+	return sess.Handle, nil
+}
+
+// Message is one direct message, with an optional link appended to the text
+type Message struct {
+	Text string
+	Link string
+}
+
+// Unavailable reports whether a recipient recently turned out not to accept
+// messages, so callers can skip them without a request
+func (s *Sender) Unavailable(did string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	until, ok := s.unavailable[did]
+	return ok && time.Now().Before(until)
+}
+
+// CanMessage checks whether a recipient accepts messages from the service
+// account right now, clearing any earlier unavailable mark
+func (s *Sender) CanMessage(ctx context.Context, did string) (bool, error) {
+	s.mu.Lock()
+	delete(s.unavailable, did)
+	delete(s.convos, did)
+	s.mu.Unlock()
+
+	if _, err := s.convo(ctx, did); err != nil {
+		if errors.Is(err, ErrRecipientUnavailable) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Send delivers a message to a recipient. Recipients who don't accept
+// messages are remembered for a while and fail fast with
+// ErrRecipientUnavailable.
+func (s *Sender) Send(ctx context.Context, did string, msg *Message) error {
+	if s.Unavailable(did) {
+		return ErrRecipientUnavailable
+	}
+	if err := s.wait(ctx, did); err != nil {
+		return err
+	}
+
+	convoID, err := s.convo(ctx, did)
+	if err != nil {
+		return err
+	}
+
+	body := map[string]interface{}{
+		"convoId": convoID,
+		"message": buildMessage(msg),
+	}
+	if err := s.call(ctx, http.MethodPost, "chat.bsky.convo.sendMessage", nil, body, nil); err != nil {
+		if errors.Is(err, ErrRecipientUnavailable) {
+			s.markUnavailable(did)
+		}
+		return err
+	}
+	return nil
+}
+
+// convo returns the conversation with a recipient, starting one if needed
+func (s *Sender) convo(ctx context.Context, did string) (string, error) {
+	s.mu.Lock()
+	convoID, ok := s.convos[did]
+	s.mu.Unlock()
+	if ok {
+		return convoID, nil
+	}
+
+	var result struct {
+		Convo struct {
+			ID string `json:"id"`
+		} `json:"convo"`
+	}
+	query := url.Values{"members": {did}}
+	if err := s.call(ctx, http.MethodGet, "chat.bsky.convo.getConvoForMembers", query, nil, &result); err != nil {
+		if errors.Is(err, ErrRecipientUnavailable) {
+			s.markUnavailable(did)
+		}
+		return "", err
+	}
+	if result.Convo.ID == "" {
+		return "", fmt.Errorf("chat service returned no conversation")
+	}
+
+	s.mu.Lock()
+	s.convos[did] = result.Convo.ID
+	s.mu.Unlock()
+	return result.Convo.ID, nil
+}
+
+// wait applies the rate limits: a recipient gets at most maxPerHour messages,
+// and messages are spaced at least minInterval apart
+func (s *Sender) wait(ctx context.Context, did string) error {
+	s.mu.Lock()
+	now := time.Now()
+	recent := s.sent[did][:0]
+	for _, at := range s.sent[did] {
+		if now.Sub(at) < time.Hour {
+			recent = append(recent, at)
+		}
+	}
+	if len(recent) >= s.maxPerHour {
+		s.sent[did] = recent
+		s.mu.Unlock()
+		return ErrRateLimited
+	}
+	s.sent[did] = append(recent, now)
+
+	sendAt := now
+	if s.nextSend.After(now) {
+		sendAt = s.nextSend
+	}
+	s.nextSend = sendAt.Add(s.minInterval)
+	s.mu.Unlock()
+
+	delay := time.Until(sendAt)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// markUnavailable remembers a recipient who doesn't accept messages
+func (s *Sender) markUnavailable(did string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.unavailable[did] = time.Now().Add(unavailableRetry)
+	delete(s.convos, did)
+}
+
+// login creates a session for the service account, reusing the current one
+// unless fresh is set
+func (s *Sender) login(ctx context.Context, fresh bool) (*session, error) {
+	s.mu.Lock()
+	current := s.session
+	s.mu.Unlock()
+	if current != nil && !fresh {
+		return current, nil
+	}
+
+	payload, err := json.Marshal(map[string]string{
+		"identifier": s.identifier,
+		"password":   s.password,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.service+"/xrpc/com.atproto.server.createSession", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to log in: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("failed to log in: status %d: %s", resp.StatusCode, body)
+	}
+
+	var sess session
+	if err := json.NewDecoder(resp.Body).Decode(&sess); err != nil {
+		return nil, fmt.Errorf("failed to decode session: %w", err)
+	}
+
+	s.mu.Lock()
+	s.session = &sess
+	s.mu.Unlock()
+	return &sess, nil
+}
+
+// call makes an XRPC call to the chat service, logging in again once if the
+// session has expired
+func (s *Sender) call(ctx context.Context, method, nsid string, query url.Values, body, result interface{}) error {
+	sess, err := s.login(ctx, false)
+	if err != nil {
+		return err
+	}
+
+	err = s.do(ctx, sess, method, nsid, query, body, result)
+	if errors.Is(err, errExpiredSession) {
+		if sess, err = s.login(ctx, true); err != nil {
+			return err
+		}
+		err = s.do(ctx, sess, method, nsid, query, body, result)
+	}
+	return err
+}
+
+// errExpiredSession means the access token needs renewing
+var errExpiredSession = errors.New("session expired")
+
+// xrpcError is the error body of a failed XRPC call
+type xrpcError struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+// do makes one XRPC call with the given session
+func (s *Sender) do(ctx context.Context, sess *session, method, nsid string, query url.Values, body, result interface{}) error {
+	endpoint := s.service + "/xrpc/" + nsid
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+sess.AccessJwt)
+	req.Header.Set("Atproto-Proxy", chatProxy)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", nsid, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		if result == nil {
+			return nil
+		}
+		return json.NewDecoder(resp.Body).Decode(result)
+	}
+
+	var xerr xrpcError
+	json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&xerr)
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized, xerr.Error == "ExpiredToken":
+		return errExpiredSession
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("%s: %w", nsid, ErrRateLimited)
+	case resp.StatusCode == http.StatusBadRequest && refusesMessages(xerr.Message):
+		return fmt.Errorf("%s: %w", nsid, ErrRecipientUnavailable)
+	}
+	return fmt.Errorf("%s failed: status %d: %s %s", nsid, resp.StatusCode, xerr.Error, xerr.Message)
+}
+
+// refusesMessages reports whether a chat service error says the recipient
+// doesn't accept messages, e.g. "recipient has disabled incoming messages" or
+// "recipient requires incoming messages to come from someone they follow"
+func refusesMessages(message string) bool {
+	message = strings.ToLower(message)
+	return strings.Contains(message, "recipient") ||
+		strings.Contains(message, "blocked") ||
+		strings.Contains(message, "block between")
+}
+
+// buildMessage renders a message for sendMessage, with the link on its own
+// line and marked up as a link facet so it is clickable
+func buildMessage(msg *Message) map[string]interface{} {
+	text := truncate(msg.Text, maxTextLength)
+	if msg.Link == "" {
+		return map[string]interface{}{"text": text}
+	}
+
+	prefix := text + "\n\n"
+	return map[string]interface{}{
+		"text": prefix + msg.Link,
+		"facets": []map[string]interface{}{{
+			"index": map[string]int{
+				"byteStart": len(prefix),
+				"byteEnd":   len(prefix) + len(msg.Link),
+			},
+			"features": []map[string]string{{
+				"$type": "app.bsky.richtext.facet#link",
+				"uri":   msg.Link,
+			}},
+		}},
+	}
+}
+
+// truncate shortens text to at most max characters
+func truncate(text string, max int) string {
+	if utf8.RuneCountInString(text) <= max {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:max-1]) + "…"
+}
@@ -0,0 +1,186 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// mockChat is a stand-in for a PDS proxying the Bluesky chat service
+type mockChat struct {
+	mu       sync.Mutex
+	logins   int
+	expired  bool              // Reject the next call with ExpiredToken
+	refusing map[string]string // Recipient DID -> error message
+	messages []map[string]interface{}
+}
+
+func newMockChat(t *testing.T) (*mockChat, *httptest.Server) {
+	t.Helper()
+
+	m := &mockChat{refusing: make(map[string]string)}
+	server := httptest.NewServer(http.HandlerFunc(m.serve))
+	t.Cleanup(server.Close)
+	return m, server
+}
+
+func (m *mockChat) serve(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fail := func(status int, name, message string) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": name, "message": message})
+	}
+
+	if r.URL.Path == "/xrpc/com.atproto.server.createSession" {
+		m.logins++
+		json.NewEncoder(w).Encode(map[string]string{
+			"accessJwt": "token",
+			"did":       "did:plc:service",
+			"handle":    "attodo.example.com",
+		})
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer token" || r.Header.Get("Atproto-Proxy") != chatProxy {
+		fail(http.StatusUnauthorized, "AuthRequired", "missing auth or proxy header")
+		return
+	}
+	if m.expired {
+		m.expired = false
+		fail(http.StatusBadRequest, "ExpiredToken", "Token has expired")
+		return
+	}
+
+	switch r.URL.Path {
+	case "/xrpc/chat.bsky.convo.getConvoForMembers":
+		member := r.URL.Query().Get("members")
+		if message, ok := m.refusing[member]; ok {
+			fail(http.StatusBadRequest, "InvalidRequest", message)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"convo": map[string]string{"id": "convo-" + member},
+		})
+	case "/xrpc/chat.bsky.convo.sendMessage":
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		m.messages = append(m.messages, body)
+		json.NewEncoder(w).Encode(map[string]string{"id": "message"})
+	default:
+		fail(http.StatusNotImplemented, "MethodNotImplemented", r.URL.Path)
+	}
+}
+
+func newTestSender(url string) *Sender {
+	sender := NewSender(url, "attodo.example.com", "app-password")
+	sender.minInterval = 0
+	return sender
+}
+
+func TestSenderSend(t *testing.T) {
+	mock, server := newMockChat(t)
+	sender := newTestSender(server.URL)
+
+	err := sender.Send(context.Background(), "did:plc:alice", &Message{
+		Text: "Buy milk\nDue today",
+		Link: "https://attodo.app/app#task-abc",
+	})
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	if len(mock.messages) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(mock.messages))
+	}
+	sent := mock.messages[0]
+	if sent["convoId"] != "convo-did:plc:alice" {
+		t.Errorf("Expected conversation with alice, got %v", sent["convoId"])
+	}
+
+	message := sent["message"].(map[string]interface{})
+	text := message["text"].(string)
+	if text != "Buy milk\nDue today\n\nhttps://attodo.app/app#task-abc" {
+		t.Errorf("Unexpected text %q", text)
+	}
+
+	facet := message["facets"].([]interface{})[0].(map[string]interface{})
+	index := facet["index"].(map[string]interface{})
+	start, end := int(index["byteStart"].(float64)), int(index["byteEnd"].(float64))
+	if text[start:end] != "https://attodo.app/app#task-abc" {
+		t.Errorf("Expected link facet to cover the link, got %q", text[start:end])
+	}
+}
+
+func TestSenderRecipientUnavailable(t *testing.T) {
+	mock, server := newMockChat(t)
+	mock.refusing["did:plc:bob"] = "recipient has disabled incoming messages"
+	sender := newTestSender(server.URL)
+
+	err := sender.Send(context.Background(), "did:plc:bob", &Message{Text: "Hello"})
+	if !errors.Is(err, ErrRecipientUnavailable) {
+		t.Fatalf("Expected ErrRecipientUnavailable, got %v", err)
+	}
+	if !sender.Unavailable("did:plc:bob") {
+		t.Error("Expected bob to be remembered as unavailable")
+	}
+
+	// Once DMs are opened, a status check clears the mark
+	delete(mock.refusing, "did:plc:bob")
+	ok, err := sender.CanMessage(context.Background(), "did:plc:bob")
+	if err != nil || !ok {
+		t.Fatalf("Expected bob to be reachable, got %v, %v", ok, err)
+	}
+	if sender.Unavailable("did:plc:bob") {
+		t.Error("Expected the unavailable mark to be cleared")
+	}
+}
+
+func TestSenderRelogsInOnExpiredToken(t *testing.T) {
+	mock, server := newMockChat(t)
+	sender := newTestSender(server.URL)
+
+	if _, err := sender.Account(context.Background()); err != nil {
+		t.Fatalf("Account failed: %v", err)
+	}
+	mock.expired = true
+
+	if err := sender.Send(context.Background(), "did:plc:alice", &Message{Text: "Hello"}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if mock.logins != 2 {
+		t.Errorf("Expected a second login, got %d logins", mock.logins)
+	}
+}
+
+func TestSenderRateLimit(t *testing.T) {
+	_, server := newMockChat(t)
+	sender := newTestSender(server.URL)
+	sender.maxPerHour = 2
+
+	for i := 0; i < 2; i++ {
+		if err := sender.Send(context.Background(), "did:plc:alice", &Message{Text: "Hello"}); err != nil {
+			t.Fatalf("Send %d failed: %v", i, err)
+		}
+	}
+	if err := sender.Send(context.Background(), "did:plc:alice", &Message{Text: "Hello"}); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Expected ErrRateLimited, got %v", err)
+	}
+
+	// Other recipients have their own allowance
+	if err := sender.Send(context.Background(), "did:plc:carol", &Message{Text: "Hello"}); err != nil {
+		t.Errorf("Expected carol's message to go through, got %v", err)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := truncate(strings.Repeat("é", 10), 5); got != "éééé…" {
+		t.Errorf("Expected rune-safe truncation, got %q", got)
+	}
+}
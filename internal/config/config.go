@@ -1,7 +1,9 @@
 package config
 
 import (
+	"log"
 	"os"
+	"strconv"
 
 	"github.com/joho/godotenv"
 )
@@ -20,6 +22,24 @@ type Config struct {
 	StripePublishableKey string
 	StripeWebhookSecret  string
 	StripePriceID        string
+	SMTPHost             string
+	SMTPPort             string
+	SMTPUsername         string
+	SMTPPassword         string
+	SMTPFrom             string
+	BlueskyDMService     string
+	BlueskyDMIdentifier  string
+	BlueskyDMPassword    string
+	WebhookAllowPrivate  bool
+	JobsAdminToken       string
+	InstanceID           string
+
+	// Days to keep old data before the maintenance job deletes it; 0 keeps it
+	HistoryRetentionDays          int
+	PushSubscriptionRetentionDays int
+	OrphanedUserRetentionDays     int
+	SupporterRetentionDays        int
+	JobRunRetentionDays           int
 }
 
 func Load() (*Config, error) {
@@ -39,6 +59,23 @@ func Load() (*Config, error) {
 		StripePublishableKey: getEnv("STRIPE_PUBLISHABLE_KEY", ""),
 		StripeWebhookSecret:  getEnv("STRIPE_WEBHOOK_SECRET", ""),
 		StripePriceID:        getEnv("STRIPE_PRICE_ID", ""),
+		SMTPHost:             getEnv("SMTP_HOST", ""),
+		SMTPPort:             getEnv("SMTP_PORT", "587"),
+		SMTPUsername:         getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:         getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:             getEnv("SMTP_FROM", ""),
+		BlueskyDMService:     getEnv("BLUESKY_DM_SERVICE", "https://bsky.social"),
+		BlueskyDMIdentifier:  getEnv("BLUESKY_DM_IDENTIFIER", ""),
+		BlueskyDMPassword:    getEnv("BLUESKY_DM_APP_PASSWORD", ""),
+		WebhookAllowPrivate:  getEnv("WEBHOOK_ALLOW_PRIVATE", "") == "true",
+		JobsAdminToken:       getEnv("JOBS_ADMIN_TOKEN", ""),
+		InstanceID:           getEnv("INSTANCE_ID", ""),
+
+		HistoryRetentionDays:          getEnvInt("NOTIFICATION_HISTORY_RETENTION_DAYS", 90),
+		PushSubscriptionRetentionDays: getEnvInt("PUSH_SUBSCRIPTION_RETENTION_DAYS", 90),
+		OrphanedUserRetentionDays:     getEnvInt("ORPHANED_USER_RETENTION_DAYS", 30),
+		SupporterRetentionDays:        getEnvInt("EXPIRED_SUPPORTER_RETENTION_DAYS", 365),
+		JobRunRetentionDays:           getEnvInt("JOB_RUN_RETENTION_DAYS", 14),
 	}
 
 	return cfg, nil
@@ -55,3 +92,18 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// getEnvInt reads an integer setting, using the fallback if it's unset or
+// not a number
+func getEnvInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid %s %q, using %d", key, value, fallback)
+		return fallback
+	}
+	return n
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// FollowRepo tracks follow activity notification state.
+// The follows themselves are stored as app.attodo.follow records in each user's repository.
+type FollowRepo struct {
+	db *DB
+}
+
+// NewFollowRepo creates a new follow repository
+func NewFollowRepo(db *DB) *FollowRepo {
+	return &FollowRepo{db: db}
+}
+
+// GetLastSeen returns when the follower was last notified about the subject.
+// Returns nil if the subject has never been checked for this follower.
+func (r *FollowRepo) GetLastSeen(did, subject string) (*time.Time, error) {
+	var lastSeen time.Time
+	err := r.db.QueryRow(`
+		SELECT last_seen_at
+		FROM follow_activity_state
+		WHERE did = ? AND subject = ?
+	`, did, subject).Scan(&lastSeen)
+
+	if err == sql.ErrNoRows {
+		return nil, nil // Not found
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get follow activity state: %w", err)
+	}
+
+	return &lastSeen, nil
+}
+
+// SetLastSeen records the newest activity the follower has been notified about
+func (r *FollowRepo) SetLastSeen(did, subject string, lastSeen time.Time) error {
+	_, err := r.db.Exec(`
+		INSERT INTO follow_activity_state (did, subject, last_seen_at, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(did, subject) DO UPDATE SET
+			last_seen_at = excluded.last_seen_at,
+			updated_at = excluded.updated_at
+	`, did, subject, lastSeen, time.Now())
+
+	if err != nil {
+		return fmt.Errorf("failed to set follow activity state: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteState removes tracked state for subjects the follower no longer follows
+func (r *FollowRepo) DeleteState(did, subject string) error {
+	_, err := r.db.Exec(`
+		DELETE FROM follow_activity_state
+		WHERE did = ? AND subject = ?
+	`, did, subject)
+
+	if err != nil {
+		return fmt.Errorf("failed to delete follow activity state: %w", err)
+	}
+
+	return nil
+}
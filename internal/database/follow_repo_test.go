@@ -0,0 +1,81 @@
+package database
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/shindakun/attodo/internal/models"
+)
+
+func TestFollowRepo(t *testing.T) {
+	dbPath := "./test_follows.db"
+	defer os.Remove(dbPath)
+	defer os.Remove(dbPath + "-shm")
+	defer os.Remove(dbPath + "-wal")
+
+	db, err := New(dbPath, "../../migrations")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer db.Close()
+
+	notifications := NewNotificationRepo(db)
+	repo := NewFollowRepo(db)
+
+	testDID := "did:plc:follower"
+	subject := "at://did:plc:owner/app.attodo.list/abc123"
+
+	if err := notifications.CreateNotificationUser(&models.NotificationUser{DID: testDID, NotificationsEnabled: true}); err != nil {
+		t.Fatalf("Failed to create notification user: %v", err)
+	}
+
+	// Unknown subject
+	lastSeen, err := repo.GetLastSeen(testDID, subject)
+	if err != nil {
+		t.Fatalf("Failed to get last seen: %v", err)
+	}
+	if lastSeen != nil {
+		t.Error("Expected no state for new subject")
+	}
+
+	// Insert then update
+	first := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	if err := repo.SetLastSeen(testDID, subject, first); err != nil {
+		t.Fatalf("Failed to set last seen: %v", err)
+	}
+	second := first.Add(30 * time.Minute)
+	if err := repo.SetLastSeen(testDID, subject, second); err != nil {
+		t.Fatalf("Failed to update last seen: %v", err)
+	}
+
+	lastSeen, err = repo.GetLastSeen(testDID, subject)
+	if err != nil {
+		t.Fatalf("Failed to get last seen: %v", err)
+	}
+	if lastSeen == nil || !lastSeen.Equal(second) {
+		t.Errorf("Expected last seen %v, got %v", second, lastSeen)
+	}
+
+	// Activity notifications are no longer restricted by the type CHECK constraint
+	history := &models.NotificationHistory{
+		DID:              testDID,
+		TaskURI:          subject,
+		NotificationType: models.NotificationTypeListActivity,
+		Status:           "sent",
+	}
+	if err := notifications.CreateNotificationHistory(history); err != nil {
+		t.Fatalf("Failed to record list activity notification: %v", err)
+	}
+
+	if err := repo.DeleteState(testDID, subject); err != nil {
+		t.Fatalf("Failed to delete state: %v", err)
+	}
+	lastSeen, err = repo.GetLastSeen(testDID, subject)
+	if err != nil {
+		t.Fatalf("Failed to get last seen: %v", err)
+	}
+	if lastSeen != nil {
+		t.Error("Expected state to be deleted")
+	}
+}
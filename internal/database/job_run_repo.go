@@ -0,0 +1,165 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shindakun/attodo/internal/models"
+)
+
+// JobRunRepo records the run history of background jobs
+type JobRunRepo struct {
+	db *DB
+}
+
+// NewJobRunRepo creates a new job run repository
+func NewJobRunRepo(db *DB) *JobRunRepo {
+	return &JobRunRepo{db: db}
+}
+
+// StartJobRun records that a job run has started
+func (r *JobRunRepo) StartJobRun(run *models.JobRun) error {
+	run.Status = models.JobRunRunning
+	if run.StartedAt.IsZero() {
+		run.StartedAt = time.Now()
+	}
+
+	result, err := r.db.Exec(`
+		INSERT INTO job_runs (job_name, instance, triggered_by, status, started_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, run.JobName, run.Instance, run.TriggeredBy, run.Status, run.StartedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to record job run: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get job run ID: %w", err)
+	}
+	run.ID = id
+
+	return nil
+}
+
+// FinishJobRun records how a job run ended; runErr is nil for success
+func (r *JobRunRepo) FinishJobRun(run *models.JobRun, runErr error) error {
+	now := time.Now()
+	run.FinishedAt = &now
+	run.Status = models.JobRunSucceeded
+	run.ErrorMessage = ""
+	if runErr != nil {
+		run.Status = models.JobRunFailed
+		run.ErrorMessage = runErr.Error()
+		if len(run.ErrorMessage) > 1000 {
+			run.ErrorMessage = run.ErrorMessage[:1000]
+		}
+	}
+
+	_, err := r.db.Exec(`
+		UPDATE job_runs
+		SET status = ?, finished_at = ?, error_message = ?
+		WHERE id = ?
+	`, run.Status, run.FinishedAt, run.ErrorMessage, run.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to finish job run: %w", err)
+	}
+
+	return nil
+}
+
+// InterruptRunningJobRuns marks runs still recorded as running as
+// interrupted. Called at startup, before any job runs, for runs a restart
+// cut short.
+func (r *JobRunRepo) InterruptRunningJobRuns() (int64, error) {
+	result, err := r.db.Exec(`
+		UPDATE job_runs
+		SET status = ?, finished_at = ?
+		WHERE status = ?
+	`, models.JobRunInterrupted, time.Now(), models.JobRunRunning)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark interrupted job runs: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// InterruptOrphanedJobRuns marks runs still recorded as running as
+// interrupted when their instance no longer holds any lease, i.e. it stopped
+// without finishing them. For instances sharing the database, where another
+// instance's runs may really be running.
+func (r *JobRunRepo) InterruptOrphanedJobRuns() (int64, error) {
+	now := time.Now().UTC()
+	result, err := r.db.Exec(`
+		UPDATE job_runs
+		SET status = ?, finished_at = ?
+		WHERE status = ? AND instance NOT IN (
+			SELECT holder FROM job_leases WHERE expires_at > ?
+		)
+	`, models.JobRunInterrupted, now, models.JobRunRunning, now)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark orphaned job runs: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// CleanupOldJobRuns deletes finished runs that started more than daysToKeep
+// days ago
+func (r *JobRunRepo) CleanupOldJobRuns(daysToKeep int) (int64, error) {
+	cutoff := time.Now().AddDate(0, 0, -daysToKeep)
+
+	result, err := r.db.Exec(`
+		DELETE FROM job_runs
+		WHERE started_at < ? AND status != ?
+	`, cutoff, models.JobRunRunning)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to cleanup old job runs: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// GetRecentJobRuns retrieves the most recent runs, of one job or of all jobs
+// if jobName is empty, newest first
+func (r *JobRunRepo) GetRecentJobRuns(jobName string, limit int) ([]*models.JobRun, error) {
+	rows, err := r.db.Query(`
+		SELECT id, job_name, instance, triggered_by, status, started_at, finished_at, error_message
+		FROM job_runs
+		WHERE ? = '' OR job_name = ?
+		ORDER BY started_at DESC, id DESC
+		LIMIT ?
+	`, jobName, jobName, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query job runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []*models.JobRun
+	for rows.Next() {
+		var run models.JobRun
+		if err := rows.Scan(
+			&run.ID,
+			&run.JobName,
+			&run.Instance,
+			&run.TriggeredBy,
+			&run.Status,
+			&run.StartedAt,
+			&run.FinishedAt,
+			&run.ErrorMessage,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan job run: %w", err)
+		}
+		runs = append(runs, &run)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading job runs: %w", err)
+	}
+
+	return runs, nil
+}
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/shindakun/attodo/internal/models"
+)
+
+func TestJobRunRepo(t *testing.T) {
+	dbPath := "./test_job_runs.db"
+	defer os.Remove(dbPath)
+	defer os.Remove(dbPath + "-shm")
+	defer os.Remove(dbPath + "-wal")
+
+	db, err := New(dbPath, "../../migrations")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewJobRunRepo(db)
+
+	succeeded := &models.JobRun{JobName: "NotificationCheck", TriggeredBy: models.JobTriggerSchedule}
+	if err := repo.StartJobRun(succeeded); err != nil {
+		t.Fatalf("Failed to start job run: %v", err)
+	}
+	if err := repo.FinishJobRun(succeeded, nil); err != nil {
+		t.Fatalf("Failed to finish job run: %v", err)
+	}
+
+	failed := &models.JobRun{JobName: "DigestCheck", TriggeredBy: models.JobTriggerManual}
+	if err := repo.StartJobRun(failed); err != nil {
+		t.Fatalf("Failed to start job run: %v", err)
+	}
+	if err := repo.FinishJobRun(failed, errors.New("PDS unreachable")); err != nil {
+		t.Fatalf("Failed to finish job run: %v", err)
+	}
+
+	// Left running by a restart
+	stuck := &models.JobRun{JobName: "NotificationCheck", TriggeredBy: models.JobTriggerSchedule}
+	if err := repo.StartJobRun(stuck); err != nil {
+		t.Fatalf("Failed to start job run: %v", err)
+	}
+	interrupted, err := repo.InterruptRunningJobRuns()
+	if err != nil {
+		t.Fatalf("Failed to interrupt job runs: %v", err)
+	}
+	if interrupted != 1 {
+		t.Errorf("Expected 1 interrupted run, got %d", interrupted)
+	}
+
+	runs, err := repo.GetRecentJobRuns("", 10)
+	if err != nil {
+		t.Fatalf("Failed to get job runs: %v", err)
+	}
+	if len(runs) != 3 {
+		t.Fatalf("Expected 3 runs, got %d", len(runs))
+	}
+	if runs[0].ID != stuck.ID || runs[0].Status != models.JobRunInterrupted {
+		t.Errorf("Expected the newest run to be interrupted, got %+v", runs[0])
+	}
+	if runs[1].Status != models.JobRunFailed || runs[1].ErrorMessage != "PDS unreachable" || runs[1].FinishedAt == nil {
+		t.Errorf("Unexpected failed run %+v", runs[1])
+	}
+
+	runs, err = repo.GetRecentJobRuns("NotificationCheck", 10)
+	if err != nil {
+		t.Fatalf("Failed to get job runs: %v", err)
+	}
+	if len(runs) != 2 || runs[1].Status != models.JobRunSucceeded {
+		t.Errorf("Expected 2 NotificationCheck runs, oldest succeeded, got %+v", runs)
+	}
+}
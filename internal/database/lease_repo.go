@@ -0,0 +1,112 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/shindakun/attodo/internal/models"
+)
+
+// LeaseRepo hands out leases, so that only one server instance sharing the
+// database does something at a time. It uses plain SQL that works on SQLite
+// on a shared volume as well as a server database. Times are UTC, and lease
+// lengths should be well above the clock skew between instances.
+type LeaseRepo struct {
+	db *DB
+}
+
+// NewLeaseRepo creates a new lease repository
+func NewLeaseRepo(db *DB) *LeaseRepo {
+	return &LeaseRepo{db: db}
+}
+
+// AcquireLease takes or renews a lease for holder if it's free, expired or
+// already theirs, and returns whoever holds it afterwards
+func (r *LeaseRepo) AcquireLease(name, holder string, ttl time.Duration) (string, error) {
+	now := time.Now().UTC()
+	expiresAt := now.Add(ttl)
+
+	// Renew our own lease or take over an expired one. The CASE reads the
+	// holder before the update, so a renewal keeps its acquired time.
+	result, err := r.db.Exec(`
+		UPDATE job_leases
+		SET acquired_at = CASE WHEN holder = ? THEN acquired_at ELSE ? END,
+			holder = ?,
+			expires_at = ?
+		WHERE name = ? AND (holder = ? OR expires_at <= ?)
+	`, holder, now, holder, expiresAt, name, holder, now)
+	if err != nil {
+		return "", fmt.Errorf("failed to acquire lease: %w", err)
+	}
+	if updated, err := result.RowsAffected(); err == nil && updated > 0 {
+		return holder, nil
+	}
+
+	// Nobody has held it yet; if another instance inserts first, the primary
+	// key refuses ours
+	_, insertErr := r.db.Exec(`
+		INSERT INTO job_leases (name, holder, acquired_at, expires_at)
+		VALUES (?, ?, ?, ?)
+	`, name, holder, now, expiresAt)
+	if insertErr == nil {
+		return holder, nil
+	}
+
+	lease, err := r.GetLease(name)
+	if err != nil {
+		return "", err
+	}
+	if lease == nil {
+		return "", fmt.Errorf("failed to acquire lease: %w", insertErr)
+	}
+	return lease.Holder, nil
+}
+
+// ReleaseLease gives up holder's lease so another instance can take it at
+// once
+func (r *LeaseRepo) ReleaseLease(name, holder string) error {
+	_, err := r.db.Exec(`
+		DELETE FROM job_leases
+		WHERE name = ? AND holder = ?
+	`, name, holder)
+
+	if err != nil {
+		return fmt.Errorf("failed to release lease: %w", err)
+	}
+
+	return nil
+}
+
+// GetLease retrieves a lease by name, expired or not
+func (r *LeaseRepo) GetLease(name string) (*models.Lease, error) {
+	var lease models.Lease
+	err := r.db.QueryRow(`
+		SELECT name, holder, acquired_at, expires_at
+		FROM job_leases
+		WHERE name = ?
+	`, name).Scan(&lease.Name, &lease.Holder, &lease.AcquiredAt, &lease.ExpiresAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil // Not found
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lease: %w", err)
+	}
+
+	return &lease, nil
+}
+
+// DeleteExpiredLeases removes leases that expired before the given time
+func (r *LeaseRepo) DeleteExpiredLeases(before time.Time) (int64, error) {
+	result, err := r.db.Exec(`
+		DELETE FROM job_leases
+		WHERE expires_at < ?
+	`, before.UTC())
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired leases: %w", err)
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/shindakun/attodo/internal/models"
+)
+
+func TestLeaseRepo(t *testing.T) {
+	dbPath := "./test_leases.db"
+	defer os.Remove(dbPath)
+	defer os.Remove(dbPath + "-shm")
+	defer os.Remove(dbPath + "-wal")
+
+	db, err := New(dbPath, "../../migrations")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewLeaseRepo(db)
+	const name = "job:NotificationCheck"
+
+	holder, err := repo.AcquireLease(name, "instance-a", time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to acquire lease: %v", err)
+	}
+	if holder != "instance-a" {
+		t.Fatalf("Expected instance-a to get the free lease, got %s", holder)
+	}
+
+	// Held by someone else
+	holder, err = repo.AcquireLease(name, "instance-b", time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to try lease: %v", err)
+	}
+	if holder != "instance-a" {
+		t.Errorf("Expected instance-a to keep the lease, got %s", holder)
+	}
+
+	// Renewal keeps the acquired time
+	first, _ := repo.GetLease(name)
+	if holder, _ := repo.AcquireLease(name, "instance-a", time.Minute); holder != "instance-a" {
+		t.Errorf("Expected instance-a to renew, got %s", holder)
+	}
+	renewed, _ := repo.GetLease(name)
+	if !renewed.AcquiredAt.Equal(first.AcquiredAt) || !renewed.ExpiresAt.After(first.ExpiresAt) {
+		t.Errorf("Expected renewal to extend the lease only, got %+v then %+v", first, renewed)
+	}
+
+	// Expired leases can be taken over
+	if _, err := repo.AcquireLease(name, "instance-a", -time.Second); err != nil {
+		t.Fatalf("Failed to shorten lease: %v", err)
+	}
+	if holder, _ := repo.AcquireLease(name, "instance-b", time.Minute); holder != "instance-b" {
+		t.Errorf("Expected instance-b to take over the expired lease, got %s", holder)
+	}
+
+	// Runs of an instance holding no lease are orphaned
+	runs := NewJobRunRepo(db)
+	live := &models.JobRun{JobName: "NotificationCheck", Instance: "instance-b", TriggeredBy: models.JobTriggerSchedule}
+	dead := &models.JobRun{JobName: "NotificationCheck", Instance: "instance-a", TriggeredBy: models.JobTriggerSchedule}
+	for _, run := range []*models.JobRun{live, dead} {
+		if err := runs.StartJobRun(run); err != nil {
+			t.Fatalf("Failed to start job run: %v", err)
+		}
+	}
+	if count, err := runs.InterruptOrphanedJobRuns(); err != nil || count != 1 {
+		t.Errorf("Expected 1 orphaned run, got %d (%v)", count, err)
+	}
+
+	// Only the holder can release
+	if err := repo.ReleaseLease(name, "instance-a"); err != nil {
+		t.Fatalf("Failed to release lease: %v", err)
+	}
+	if lease, _ := repo.GetLease(name); lease == nil || lease.Holder != "instance-b" {
+		t.Errorf("Expected instance-b to still hold the lease, got %+v", lease)
+	}
+	if err := repo.ReleaseLease(name, "instance-b"); err != nil {
+		t.Fatalf("Failed to release lease: %v", err)
+	}
+	if holder, _ := repo.AcquireLease(name, "instance-a", time.Minute); holder != "instance-a" {
+		t.Errorf("Expected the released lease to be free, got %s", holder)
+	}
+
+	// Clearing out expired leases
+	if _, err := repo.AcquireLease("reminder:abc", "instance-a", -time.Hour); err != nil {
+		t.Fatalf("Failed to acquire lease: %v", err)
+	}
+	if count, err := repo.DeleteExpiredLeases(time.Now()); err != nil || count != 1 {
+		t.Errorf("Expected 1 expired lease deleted, got %d (%v)", count, err)
+	}
+}
@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// autoVacuumIncremental is PRAGMA auto_vacuum's value for incremental mode
+const autoVacuumIncremental = 2
+
+// Optimize runs PRAGMA optimize, which refreshes the query planner statistics
+// SQLite judges out of date
+func (db *DB) Optimize(ctx context.Context) error {
+	if _, err := db.ExecContext(ctx, "PRAGMA optimize"); err != nil {
+		return fmt.Errorf("failed to optimize database: %w", err)
+	}
+	return nil
+}
+
+// IncrementalVacuum returns the database's free pages to the file system and
+// reports how many were freed. A database not yet in incremental auto_vacuum
+// mode is switched to it, which takes one full VACUUM.
+func (db *DB) IncrementalVacuum(ctx context.Context) (freed int64, switched bool, err error) {
+	// The pragmas below only apply to the connection that ran them
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	var mode int
+	if err := conn.QueryRowContext(ctx, "PRAGMA auto_vacuum").Scan(&mode); err != nil {
+		return 0, false, fmt.Errorf("failed to read auto_vacuum mode: %w", err)
+	}
+
+	before, err := freelistCount(ctx, conn)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if mode != autoVacuumIncremental {
+		if _, err := conn.ExecContext(ctx, "PRAGMA auto_vacuum = INCREMENTAL"); err != nil {
+			return 0, false, fmt.Errorf("failed to set auto_vacuum mode: %w", err)
+		}
+		if _, err := conn.ExecContext(ctx, "VACUUM"); err != nil {
+			return 0, false, fmt.Errorf("failed to vacuum database: %w", err)
+		}
+		switched = true
+	} else {
+		// Each step of incremental_vacuum frees a page, so read it to the end
+		rows, err := conn.QueryContext(ctx, "PRAGMA incremental_vacuum")
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to vacuum database: %w", err)
+		}
+		for rows.Next() {
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to vacuum database: %w", err)
+		}
+	}
+
+	after, err := freelistCount(ctx, conn)
+	if err != nil {
+		return 0, switched, err
+	}
+
+	return before - after, switched, nil
+}
+
+// freelistCount returns the number of unused pages in the database file
+func freelistCount(ctx context.Context, conn *sql.Conn) (int64, error) {
+	var count int64
+	if err := conn.QueryRowContext(ctx, "PRAGMA freelist_count").Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to read freelist count: %w", err)
+	}
+	return count, nil
+}
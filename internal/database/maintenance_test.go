@@ -0,0 +1,55 @@
+package database
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+func TestIncrementalVacuum(t *testing.T) {
+	dbPath := "./test_maintenance.db"
+	defer os.Remove(dbPath)
+	defer os.Remove(dbPath + "-shm")
+	defer os.Remove(dbPath + "-wal")
+
+	db, err := New(dbPath, "../../migrations")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	// The first vacuum switches the database to incremental mode
+	if _, switched, err := db.IncrementalVacuum(ctx); err != nil || !switched {
+		t.Fatalf("Expected switch to incremental vacuum, got switched=%v err=%v", switched, err)
+	}
+
+	// Fill some pages, then free them
+	if _, err := db.Exec(`CREATE TABLE filler (data BLOB)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	for i := 0; i < 50; i++ {
+		if _, err := db.Exec(`INSERT INTO filler (data) VALUES (zeroblob(4096))`); err != nil {
+			t.Fatalf("Failed to insert: %v", err)
+		}
+	}
+	if _, err := db.Exec(`DROP TABLE filler`); err != nil {
+		t.Fatalf("Failed to drop table: %v", err)
+	}
+
+	freed, switched, err := db.IncrementalVacuum(ctx)
+	if err != nil {
+		t.Fatalf("Failed to vacuum: %v", err)
+	}
+	if switched {
+		t.Error("Expected the database to stay in incremental mode")
+	}
+	if freed < 50 {
+		t.Errorf("Expected at least 50 pages freed, got %d", freed)
+	}
+
+	if err := db.Optimize(ctx); err != nil {
+		t.Errorf("Failed to optimize: %v", err)
+	}
+}
@@ -26,12 +26,13 @@ func NewNotificationRepo(db *DB) *NotificationRepo {
 func (r *NotificationRepo) GetNotificationUser(did string) (*models.NotificationUser, error) {
 	var user models.NotificationUser
 	err := r.db.QueryRow(`
-		SELECT did, notifications_enabled, last_checked_at, created_at, updated_at
+		SELECT did, notifications_enabled, bluesky_dm_enabled, last_checked_at, created_at, updated_at
 		FROM notification_users
 		WHERE did = ?
 	`, did).Scan(
 		&user.DID,
 		&user.NotificationsEnabled,
+		&user.BlueskyDMEnabled,
 		&user.LastCheckedAt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
@@ -54,9 +55,9 @@ func (r *NotificationRepo) CreateNotificationUser(user *models.NotificationUser)
 	user.UpdatedAt = now
 
 	_, err := r.db.Exec(`
-		INSERT INTO notification_users (did, notifications_enabled, last_checked_at, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?)
-	`, user.DID, user.NotificationsEnabled, user.LastCheckedAt, user.CreatedAt, user.UpdatedAt)
+		INSERT INTO notification_users (did, notifications_enabled, bluesky_dm_enabled, last_checked_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, user.DID, user.NotificationsEnabled, user.BlueskyDMEnabled, user.LastCheckedAt, user.CreatedAt, user.UpdatedAt)
 
 	if err != nil {
 		return fmt.Errorf("failed to create notification user: %w", err)
@@ -71,9 +72,9 @@ func (r *NotificationRepo) UpdateNotificationUser(user *models.NotificationUser)
 
 	_, err := r.db.Exec(`
 		UPDATE notification_users
-		SET notifications_enabled = ?, last_checked_at = ?, updated_at = ?
+		SET notifications_enabled = ?, bluesky_dm_enabled = ?, last_checked_at = ?, updated_at = ?
 		WHERE did = ?
-	`, user.NotificationsEnabled, user.LastCheckedAt, user.UpdatedAt, user.DID)
+	`, user.NotificationsEnabled, user.BlueskyDMEnabled, user.LastCheckedAt, user.UpdatedAt, user.DID)
 
 	if err != nil {
 		return fmt.Errorf("failed to update notification user: %w", err)
@@ -85,7 +86,7 @@ func (r *NotificationRepo) UpdateNotificationUser(user *models.NotificationUser)
 // GetEnabledNotificationUsers retrieves all users with notifications enabled
 func (r *NotificationRepo) GetEnabledNotificationUsers() ([]*models.NotificationUser, error) {
 	rows, err := r.db.Query(`
-		SELECT did, notifications_enabled, last_checked_at, created_at, updated_at
+		SELECT did, notifications_enabled, bluesky_dm_enabled, last_checked_at, created_at, updated_at
 		FROM notification_users
 		WHERE notifications_enabled = 1
 		ORDER BY last_checked_at ASC
@@ -101,6 +102,7 @@ func (r *NotificationRepo) GetEnabledNotificationUsers() ([]*models.Notification
 		if err := rows.Scan(
 			&user.DID,
 			&user.NotificationsEnabled,
+			&user.BlueskyDMEnabled,
 			&user.LastCheckedAt,
 			&user.CreatedAt,
 			&user.UpdatedAt,
@@ -117,6 +119,35 @@ func (r *NotificationRepo) GetEnabledNotificationUsers() ([]*models.Notification
 	return users, nil
 }
 
+// CleanupOrphanedNotificationUsers deletes users created more than daysToKeep
+// days ago who have no way left to be notified: no push subscriptions, email
+// address, webhooks or Bluesky DMs. Users with recurring tasks are kept.
+// Enabling any channel again recreates the user.
+func (r *NotificationRepo) CleanupOrphanedNotificationUsers(daysToKeep int) (int64, error) {
+	cutoff := time.Now().AddDate(0, 0, -daysToKeep)
+
+	result, err := r.db.Exec(`
+		DELETE FROM notification_users
+		WHERE created_at < ?
+		  AND bluesky_dm_enabled = 0
+		  AND NOT EXISTS (SELECT 1 FROM push_subscriptions p WHERE p.did = notification_users.did)
+		  AND NOT EXISTS (SELECT 1 FROM email_addresses e WHERE e.did = notification_users.did)
+		  AND NOT EXISTS (SELECT 1 FROM webhooks w WHERE w.did = notification_users.did)
+		  AND NOT EXISTS (SELECT 1 FROM recurring_tasks t WHERE t.did = notification_users.did)
+	`, cutoff)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to cleanup orphaned notification users: %w", err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return count, nil
+}
+
 // ============================================================================
 // PUSH SUBSCRIPTIONS
 // ============================================================================
@@ -254,6 +285,372 @@ func (r *NotificationRepo) DeletePushSubscriptionsByDID(did string) error {
 	return nil
 }
 
+// CleanupStalePushSubscriptions deletes push subscriptions that haven't had a
+// delivery or been re-registered by their browser in daysToKeep days
+func (r *NotificationRepo) CleanupStalePushSubscriptions(daysToKeep int) (int64, error) {
+	cutoff := time.Now().AddDate(0, 0, -daysToKeep)
+
+	result, err := r.db.Exec(`
+		DELETE FROM push_subscriptions
+		WHERE last_used_at < ?
+	`, cutoff)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to cleanup stale push subscriptions: %w", err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return count, nil
+}
+
+// ============================================================================
+// PUSH DELIVERY QUEUE
+// ============================================================================
+
+// EnqueuePushDelivery queues a push delivery for retry
+func (r *NotificationRepo) EnqueuePushDelivery(delivery *models.PushDelivery) error {
+	delivery.CreatedAt = time.Now()
+
+	result, err := r.db.Exec(`
+		INSERT INTO push_delivery_queue (did, endpoint, payload, notification_type, task_uri, attempts, next_attempt_at, last_error, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, delivery.DID, delivery.Endpoint, delivery.Payload, delivery.NotificationType, delivery.TaskURI,
+		delivery.Attempts, delivery.NextAttemptAt, delivery.LastError, delivery.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to queue push delivery: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get push delivery ID: %w", err)
+	}
+	delivery.ID = id
+
+	return nil
+}
+
+// GetDuePushDeliveries retrieves queued deliveries whose next attempt is due,
+// oldest first
+func (r *NotificationRepo) GetDuePushDeliveries(now time.Time, limit int) ([]*models.PushDelivery, error) {
+	rows, err := r.db.Query(`
+		SELECT id, did, endpoint, payload, notification_type, task_uri, attempts, next_attempt_at, last_error, created_at
+		FROM push_delivery_queue
+		WHERE next_attempt_at <= ?
+		ORDER BY next_attempt_at ASC
+		LIMIT ?
+	`, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query push deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*models.PushDelivery
+	for rows.Next() {
+		var delivery models.PushDelivery
+		if err := rows.Scan(
+			&delivery.ID,
+			&delivery.DID,
+			&delivery.Endpoint,
+			&delivery.Payload,
+			&delivery.NotificationType,
+			&delivery.TaskURI,
+			&delivery.Attempts,
+			&delivery.NextAttemptAt,
+			&delivery.LastError,
+			&delivery.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan push delivery: %w", err)
+		}
+		deliveries = append(deliveries, &delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading push deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// UpdatePushDelivery saves a queued delivery's attempt count, next attempt
+// and last error
+func (r *NotificationRepo) UpdatePushDelivery(delivery *models.PushDelivery) error {
+	_, err := r.db.Exec(`
+		UPDATE push_delivery_queue
+		SET attempts = ?, next_attempt_at = ?, last_error = ?
+		WHERE id = ?
+	`, delivery.Attempts, delivery.NextAttemptAt, delivery.LastError, delivery.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to update push delivery: %w", err)
+	}
+
+	return nil
+}
+
+// DeletePushDelivery removes a delivery from the queue
+func (r *NotificationRepo) DeletePushDelivery(id int64) error {
+	_, err := r.db.Exec(`
+		DELETE FROM push_delivery_queue
+		WHERE id = ?
+	`, id)
+
+	if err != nil {
+		return fmt.Errorf("failed to delete push delivery: %w", err)
+	}
+
+	return nil
+}
+
+// ============================================================================
+// EMAIL ADDRESSES
+// ============================================================================
+
+// emailAddressColumns are the email_addresses columns, in scanEmailAddress order
+const emailAddressColumns = `did, email, verify_token, verify_sent_at, verified_at,
+		unsubscribe_token, unsubscribed_at, created_at, updated_at`
+
+// scanEmailAddress scans one email_addresses row
+func scanEmailAddress(row *sql.Row) (*models.EmailAddress, error) {
+	var addr models.EmailAddress
+	err := row.Scan(
+		&addr.DID,
+		&addr.Email,
+		&addr.VerifyToken,
+		&addr.VerifySentAt,
+		&addr.VerifiedAt,
+		&addr.UnsubscribeToken,
+		&addr.UnsubscribedAt,
+		&addr.CreatedAt,
+		&addr.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil // Not found
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email address: %w", err)
+	}
+
+	return &addr, nil
+}
+
+// GetEmailAddress retrieves a user's email address
+func (r *NotificationRepo) GetEmailAddress(did string) (*models.EmailAddress, error) {
+	return scanEmailAddress(r.db.QueryRow(`
+		SELECT `+emailAddressColumns+`
+		FROM email_addresses
+		WHERE did = ?
+	`, did))
+}
+
+// GetEmailAddressByVerifyToken retrieves the unverified address a
+// verification link is for
+func (r *NotificationRepo) GetEmailAddressByVerifyToken(token string) (*models.EmailAddress, error) {
+	if token == "" {
+		return nil, nil
+	}
+	return scanEmailAddress(r.db.QueryRow(`
+		SELECT `+emailAddressColumns+`
+		FROM email_addresses
+		WHERE verify_token = ?
+	`, token))
+}
+
+// GetEmailAddressByUnsubscribeToken retrieves the address an unsubscribe
+// link is for
+func (r *NotificationRepo) GetEmailAddressByUnsubscribeToken(token string) (*models.EmailAddress, error) {
+	if token == "" {
+		return nil, nil
+	}
+	return scanEmailAddress(r.db.QueryRow(`
+		SELECT `+emailAddressColumns+`
+		FROM email_addresses
+		WHERE unsubscribe_token = ?
+	`, token))
+}
+
+// SaveEmailAddress creates or replaces a user's email address
+func (r *NotificationRepo) SaveEmailAddress(addr *models.EmailAddress) error {
+	now := time.Now()
+	if addr.CreatedAt.IsZero() {
+		addr.CreatedAt = now
+	}
+	addr.UpdatedAt = now
+
+	_, err := r.db.Exec(`
+		INSERT INTO email_addresses (`+emailAddressColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(did) DO UPDATE SET
+			email = excluded.email,
+			verify_token = excluded.verify_token,
+			verify_sent_at = excluded.verify_sent_at,
+			verified_at = excluded.verified_at,
+			unsubscribe_token = excluded.unsubscribe_token,
+			unsubscribed_at = excluded.unsubscribed_at,
+			updated_at = excluded.updated_at
+	`, addr.DID, addr.Email, addr.VerifyToken, addr.VerifySentAt, addr.VerifiedAt,
+		addr.UnsubscribeToken, addr.UnsubscribedAt, addr.CreatedAt, addr.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to save email address: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteEmailAddress removes a user's email address
+func (r *NotificationRepo) DeleteEmailAddress(did string) error {
+	_, err := r.db.Exec(`
+		DELETE FROM email_addresses
+		WHERE did = ?
+	`, did)
+
+	if err != nil {
+		return fmt.Errorf("failed to delete email address: %w", err)
+	}
+
+	return nil
+}
+
+// ============================================================================
+// WEBHOOKS
+// ============================================================================
+
+// webhookColumns are the webhooks columns, in scanWebhook order
+const webhookColumns = `id, did, kind, name, url, secret, failure_count, last_error,
+		last_delivered_at, disabled_at, created_at, updated_at`
+
+// scanWebhook scans one webhooks row
+func scanWebhook(scanner interface{ Scan(...interface{}) error }) (*models.Webhook, error) {
+	var hook models.Webhook
+	err := scanner.Scan(
+		&hook.ID,
+		&hook.DID,
+		&hook.Kind,
+		&hook.Name,
+		&hook.URL,
+		&hook.Secret,
+		&hook.FailureCount,
+		&hook.LastError,
+		&hook.LastDeliveredAt,
+		&hook.DisabledAt,
+		&hook.CreatedAt,
+		&hook.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+// GetWebhook retrieves one of a user's webhooks
+func (r *NotificationRepo) GetWebhook(did string, id int64) (*models.Webhook, error) {
+	hook, err := scanWebhook(r.db.QueryRow(`
+		SELECT `+webhookColumns+`
+		FROM webhooks
+		WHERE did = ? AND id = ?
+	`, did, id))
+
+	if err == sql.ErrNoRows {
+		return nil, nil // Not found
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	return hook, nil
+}
+
+// GetWebhooksByDID retrieves all of a user's webhooks
+func (r *NotificationRepo) GetWebhooksByDID(did string) ([]*models.Webhook, error) {
+	rows, err := r.db.Query(`
+		SELECT `+webhookColumns+`
+		FROM webhooks
+		WHERE did = ?
+		ORDER BY created_at ASC, id ASC
+	`, did)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var hooks []*models.Webhook
+	for rows.Next() {
+		hook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		hooks = append(hooks, hook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading webhooks: %w", err)
+	}
+
+	return hooks, nil
+}
+
+// CreateWebhook creates a new webhook
+func (r *NotificationRepo) CreateWebhook(hook *models.Webhook) error {
+	now := time.Now()
+	hook.CreatedAt = now
+	hook.UpdatedAt = now
+
+	result, err := r.db.Exec(`
+		INSERT INTO webhooks (did, kind, name, url, secret, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, hook.DID, hook.Kind, hook.Name, hook.URL, hook.Secret, hook.CreatedAt, hook.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get webhook ID: %w", err)
+	}
+	hook.ID = id
+
+	return nil
+}
+
+// UpdateWebhookDelivery saves a webhook's delivery state: failure count,
+// last error and when it last delivered or was disabled
+func (r *NotificationRepo) UpdateWebhookDelivery(hook *models.Webhook) error {
+	hook.UpdatedAt = time.Now()
+
+	_, err := r.db.Exec(`
+		UPDATE webhooks
+		SET failure_count = ?, last_error = ?, last_delivered_at = ?, disabled_at = ?, updated_at = ?
+		WHERE id = ?
+	`, hook.FailureCount, hook.LastError, hook.LastDeliveredAt, hook.DisabledAt, hook.UpdatedAt, hook.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteWebhook removes one of a user's webhooks
+func (r *NotificationRepo) DeleteWebhook(did string, id int64) error {
+	_, err := r.db.Exec(`
+		DELETE FROM webhooks
+		WHERE did = ? AND id = ?
+	`, did, id)
+
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	return nil
+}
+
 // ============================================================================
 // NOTIFICATION HISTORY
 // ============================================================================
@@ -263,9 +660,9 @@ func (r *NotificationRepo) CreateNotificationHistory(history *models.Notificatio
 	history.SentAt = time.Now()
 
 	result, err := r.db.Exec(`
-		INSERT INTO notification_history (did, task_uri, notification_type, sent_at, status, error_message)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`, history.DID, history.TaskURI, history.NotificationType, history.SentAt, history.Status, history.ErrorMessage)
+		INSERT INTO notification_history (did, task_uri, notification_type, sent_at, status, error_message, endpoint)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, history.DID, history.TaskURI, history.NotificationType, history.SentAt, history.Status, history.ErrorMessage, history.Endpoint)
 
 	if err != nil {
 		return fmt.Errorf("failed to create notification history: %w", err)
@@ -281,7 +678,8 @@ func (r *NotificationRepo) CreateNotificationHistory(history *models.Notificatio
 }
 
 // GetRecentNotification checks if we recently sent a notification for this task
-// Returns the most recent notification within the cooldown period
+// Returns the most recent notification within the cooldown period; per-endpoint
+// push delivery rows don't count
 func (r *NotificationRepo) GetRecentNotification(did, taskURI string, cooldownHours int) (*models.NotificationHistory, error) {
 	cutoff := time.Now().Add(-time.Duration(cooldownHours) * time.Hour)
 
@@ -289,7 +687,7 @@ func (r *NotificationRepo) GetRecentNotification(did, taskURI string, cooldownHo
 	err := r.db.QueryRow(`
 		SELECT id, did, task_uri, notification_type, sent_at, status, error_message
 		FROM notification_history
-		WHERE did = ? AND task_uri = ? AND sent_at > ? AND status = 'sent'
+		WHERE did = ? AND task_uri = ? AND sent_at > ? AND status = 'sent' AND endpoint = ''
 		ORDER BY sent_at DESC
 		LIMIT 1
 	`, did, taskURI, cutoff).Scan(
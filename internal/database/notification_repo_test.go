@@ -54,6 +54,7 @@ func TestNotificationRepo(t *testing.T) {
 
 		// Update user
 		fetched.NotificationsEnabled = false
+		fetched.BlueskyDMEnabled = true
 		if err := repo.UpdateNotificationUser(fetched); err != nil {
 			t.Fatalf("Failed to update notification user: %v", err)
 		}
@@ -66,6 +67,9 @@ func TestNotificationRepo(t *testing.T) {
 		if updated.NotificationsEnabled {
 			t.Error("Expected notifications disabled")
 		}
+		if !updated.BlueskyDMEnabled {
+			t.Error("Expected Bluesky DMs enabled")
+		}
 	})
 
 	// Test push subscription operations
@@ -182,4 +186,257 @@ func TestNotificationRepo(t *testing.T) {
 			t.Error("Should not find notification with 0 hour cooldown")
 		}
 	})
+
+	// Test email addresses
+	t.Run("EmailAddress lifecycle", func(t *testing.T) {
+		testDID := "did:plc:testemail"
+
+		user := &models.NotificationUser{DID: testDID}
+		if err := repo.CreateNotificationUser(user); err != nil {
+			t.Fatalf("Failed to create notification user: %v", err)
+		}
+
+		sentAt := time.Now()
+		addr := &models.EmailAddress{
+			DID:              testDID,
+			Email:            "alice@example.com",
+			VerifyToken:      "verify123",
+			VerifySentAt:     &sentAt,
+			UnsubscribeToken: "unsub123",
+		}
+		if err := repo.SaveEmailAddress(addr); err != nil {
+			t.Fatalf("Failed to save email address: %v", err)
+		}
+
+		// Pending until verified
+		fetched, err := repo.GetEmailAddressByVerifyToken("verify123")
+		if err != nil {
+			t.Fatalf("Failed to get email address by verify token: %v", err)
+		}
+		if fetched == nil || fetched.Email != "alice@example.com" {
+			t.Fatalf("Expected pending address, got %+v", fetched)
+		}
+		if fetched.Active() {
+			t.Error("Unverified address should not be active")
+		}
+
+		// Verify
+		verifiedAt := time.Now()
+		fetched.VerifiedAt = &verifiedAt
+		fetched.VerifyToken = ""
+		if err := repo.SaveEmailAddress(fetched); err != nil {
+			t.Fatalf("Failed to verify email address: %v", err)
+		}
+		fetched, err = repo.GetEmailAddress(testDID)
+		if err != nil {
+			t.Fatalf("Failed to get email address: %v", err)
+		}
+		if fetched == nil || !fetched.Active() {
+			t.Fatalf("Expected active address, got %+v", fetched)
+		}
+		if missing, _ := repo.GetEmailAddressByVerifyToken(""); missing != nil {
+			t.Error("Empty verify token should not match verified addresses")
+		}
+
+		// Unsubscribe
+		fetched, err = repo.GetEmailAddressByUnsubscribeToken("unsub123")
+		if err != nil || fetched == nil {
+			t.Fatalf("Failed to get email address by unsubscribe token: %v", err)
+		}
+		unsubscribedAt := time.Now()
+		fetched.UnsubscribedAt = &unsubscribedAt
+		if err := repo.SaveEmailAddress(fetched); err != nil {
+			t.Fatalf("Failed to unsubscribe email address: %v", err)
+		}
+		fetched, _ = repo.GetEmailAddress(testDID)
+		if fetched.Active() {
+			t.Error("Unsubscribed address should not be active")
+		}
+
+		// Delete
+		if err := repo.DeleteEmailAddress(testDID); err != nil {
+			t.Fatalf("Failed to delete email address: %v", err)
+		}
+		fetched, err = repo.GetEmailAddress(testDID)
+		if err != nil {
+			t.Fatalf("Failed to get email address: %v", err)
+		}
+		if fetched != nil {
+			t.Error("Expected email address to be deleted")
+		}
+	})
+
+	// Test webhook operations
+	t.Run("Webhook CRUD", func(t *testing.T) {
+		testDID := "did:plc:testwebhook"
+
+		user := &models.NotificationUser{DID: testDID}
+		if err := repo.CreateNotificationUser(user); err != nil {
+			t.Fatalf("Failed to create notification user: %v", err)
+		}
+
+		hook := &models.Webhook{
+			DID:    testDID,
+			Kind:   models.WebhookKindNtfy,
+			Name:   "Phone",
+			URL:    "https://ntfy.sh/my-tasks",
+			Secret: "tk_abc",
+		}
+		if err := repo.CreateWebhook(hook); err != nil {
+			t.Fatalf("Failed to create webhook: %v", err)
+		}
+		if hook.ID == 0 {
+			t.Fatal("Expected webhook ID to be set")
+		}
+
+		// Record a failure that disables it
+		disabledAt := time.Now()
+		hook.FailureCount = 5
+		hook.LastError = "status 500"
+		hook.DisabledAt = &disabledAt
+		if err := repo.UpdateWebhookDelivery(hook); err != nil {
+			t.Fatalf("Failed to update webhook: %v", err)
+		}
+
+		hooks, err := repo.GetWebhooksByDID(testDID)
+		if err != nil {
+			t.Fatalf("Failed to get webhooks: %v", err)
+		}
+		if len(hooks) != 1 {
+			t.Fatalf("Expected 1 webhook, got %d", len(hooks))
+		}
+		if hooks[0].Active() || hooks[0].FailureCount != 5 || hooks[0].Secret != "tk_abc" {
+			t.Errorf("Unexpected webhook state: %+v", hooks[0])
+		}
+
+		// Another user can't see or delete it
+		if other, _ := repo.GetWebhook("did:plc:someoneelse", hook.ID); other != nil {
+			t.Error("Expected webhook to be scoped to its owner")
+		}
+
+		if err := repo.DeleteWebhook(testDID, hook.ID); err != nil {
+			t.Fatalf("Failed to delete webhook: %v", err)
+		}
+		fetched, err := repo.GetWebhook(testDID, hook.ID)
+		if err != nil {
+			t.Fatalf("Failed to get webhook: %v", err)
+		}
+		if fetched != nil {
+			t.Error("Expected webhook to be deleted")
+		}
+	})
+
+	t.Run("PushDelivery queue", func(t *testing.T) {
+		testDID := "did:plc:testqueue"
+
+		if err := repo.CreateNotificationUser(&models.NotificationUser{DID: testDID}); err != nil {
+			t.Fatalf("Failed to create notification user: %v", err)
+		}
+		sub := &models.PushSubscription{
+			DID:        testDID,
+			Endpoint:   "https://push.example.com/queue",
+			P256dhKey:  "key",
+			AuthSecret: "auth",
+		}
+		if err := repo.CreatePushSubscription(sub); err != nil {
+			t.Fatalf("Failed to create subscription: %v", err)
+		}
+
+		now := time.Now()
+		due := &models.PushDelivery{DID: testDID, Endpoint: sub.Endpoint, Payload: `{"title":"due"}`, Attempts: 1, NextAttemptAt: now.Add(-time.Minute)}
+		later := &models.PushDelivery{DID: testDID, Endpoint: sub.Endpoint, Payload: `{"title":"later"}`, Attempts: 1, NextAttemptAt: now.Add(time.Hour)}
+		for _, delivery := range []*models.PushDelivery{due, later} {
+			if err := repo.EnqueuePushDelivery(delivery); err != nil {
+				t.Fatalf("Failed to queue delivery: %v", err)
+			}
+		}
+
+		deliveries, err := repo.GetDuePushDeliveries(now, 10)
+		if err != nil {
+			t.Fatalf("Failed to get due deliveries: %v", err)
+		}
+		if len(deliveries) != 1 || deliveries[0].ID != due.ID {
+			t.Fatalf("Expected only the due delivery, got %+v", deliveries)
+		}
+
+		due.Attempts = 2
+		due.NextAttemptAt = now.Add(2 * time.Hour)
+		due.LastError = "push service returned status 429"
+		if err := repo.UpdatePushDelivery(due); err != nil {
+			t.Fatalf("Failed to update delivery: %v", err)
+		}
+		deliveries, _ = repo.GetDuePushDeliveries(now.Add(3*time.Hour), 10)
+		if len(deliveries) != 2 || deliveries[1].Attempts != 2 || deliveries[1].LastError == "" {
+			t.Errorf("Expected the rescheduled delivery last, got %+v", deliveries)
+		}
+
+		for _, delivery := range []*models.PushDelivery{due, later} {
+			if err := repo.DeletePushDelivery(delivery.ID); err != nil {
+				t.Fatalf("Failed to delete delivery: %v", err)
+			}
+		}
+		deliveries, _ = repo.GetDuePushDeliveries(now.Add(3*time.Hour), 10)
+		if len(deliveries) != 0 {
+			t.Errorf("Expected an empty queue, got %d deliveries", len(deliveries))
+		}
+
+		// Per-endpoint delivery rows don't count towards the cooldown
+		history := &models.NotificationHistory{DID: testDID, TaskURI: "at://task/queued", NotificationType: "reminder", Status: "sent", Endpoint: sub.Endpoint}
+		if err := repo.CreateNotificationHistory(history); err != nil {
+			t.Fatalf("Failed to create history: %v", err)
+		}
+		recent, err := repo.GetRecentNotification(testDID, "at://task/queued", 1)
+		if err != nil {
+			t.Fatalf("Failed to get recent notification: %v", err)
+		}
+		if recent != nil {
+			t.Error("Expected per-endpoint history to be ignored for dedupe")
+		}
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		old := time.Now().AddDate(0, 0, -60)
+		for _, did := range []string{"did:plc:orphan", "did:plc:stale", "did:plc:dm", "did:plc:fresh"} {
+			user := &models.NotificationUser{DID: did, NotificationsEnabled: true, BlueskyDMEnabled: did == "did:plc:dm"}
+			if err := repo.CreateNotificationUser(user); err != nil {
+				t.Fatalf("Failed to create notification user: %v", err)
+			}
+			if did != "did:plc:fresh" {
+				db.Exec(`UPDATE notification_users SET created_at = ? WHERE did = ?`, old, did)
+			}
+		}
+		sub := &models.PushSubscription{DID: "did:plc:stale", Endpoint: "https://push.example.com/stale", P256dhKey: "key", AuthSecret: "secret"}
+		if err := repo.CreatePushSubscription(sub); err != nil {
+			t.Fatalf("Failed to create push subscription: %v", err)
+		}
+		db.Exec(`UPDATE push_subscriptions SET last_used_at = ? WHERE endpoint = ?`, old, sub.Endpoint)
+
+		count, err := repo.CleanupOrphanedNotificationUsers(30)
+		if err != nil {
+			t.Fatalf("Failed to cleanup orphaned users: %v", err)
+		}
+		if count != 1 {
+			t.Errorf("Expected 1 orphaned user deleted, got %d", count)
+		}
+
+		count, err = repo.CleanupStalePushSubscriptions(30)
+		if err != nil {
+			t.Fatalf("Failed to cleanup stale subscriptions: %v", err)
+		}
+		if count != 1 {
+			t.Errorf("Expected 1 stale subscription deleted, got %d", count)
+		}
+
+		// Without its subscription the user is orphaned too
+		count, _ = repo.CleanupOrphanedNotificationUsers(30)
+		if count != 1 {
+			t.Errorf("Expected the user whose subscription went stale deleted, got %d", count)
+		}
+		for did, kept := range map[string]bool{"did:plc:orphan": false, "did:plc:stale": false, "did:plc:dm": true, "did:plc:fresh": true, "did:plc:test123": true} {
+			user, _ := repo.GetNotificationUser(did)
+			if (user != nil) != kept {
+				t.Errorf("User %s: expected kept=%v", did, kept)
+			}
+		}
+	})
 }
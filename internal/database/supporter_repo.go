@@ -140,6 +140,28 @@ func (r *SupporterRepo) Delete(did string) error {
 	return nil
 }
 
+// CleanupExpired deletes supporter records whose end date passed more than
+// daysToKeep days ago
+func (r *SupporterRepo) CleanupExpired(daysToKeep int) (int64, error) {
+	cutoff := time.Now().AddDate(0, 0, -daysToKeep)
+
+	result, err := r.db.Exec(`
+		DELETE FROM supporters
+		WHERE end_date IS NOT NULL AND end_date < ?
+	`, cutoff)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to cleanup expired supporters: %w", err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return count, nil
+}
+
 // IsSupporter checks if a user is an active supporter
 // Returns true if the user is active and within their subscription period
 func (r *SupporterRepo) IsSupporter(did string) (bool, error) {
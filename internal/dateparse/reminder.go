@@ -0,0 +1,130 @@
+package dateparse
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Reminder is a parsed reminder: either an absolute time or an offset before
+// the due date
+type Reminder struct {
+	At     *time.Time    // Absolute reminder time (nil for offsets)
+	Before time.Duration // Offset before the due date (0 for absolute times)
+}
+
+// ReminderResult contains the reminders found in a title and the cleaned title
+type ReminderResult struct {
+	Reminders    []Reminder
+	CleanedTitle string // Title with "remind me ..." phrases removed
+}
+
+var (
+	// "remind me" starts a reminder phrase, which runs to the next comma,
+	// semicolon or "remind me"
+	remindMePattern = regexp.MustCompile(`(?i)\bremind\s+me\b`)
+
+	// "30m before", "1 hour before due", "a day before it's due"
+	reminderOffsetPattern = regexp.MustCompile(`(?i)^(\d+|an?)\s*(m|mins?|minutes?|h|hrs?|hours?|d|days?|w|wks?|weeks?)\s+before(?:\s+(?:it'?s\s+)?due)?\b`)
+)
+
+// ParseReminders extracts "remind me ..." phrases from a title, e.g. "remind
+// me 30m before" or "remind me tomorrow at 9am". Phrases that don't parse are
+// left in the title.
+func ParseReminders(title string, referenceTime time.Time) ReminderResult {
+	result := ReminderResult{CleanedTitle: title}
+
+	starts := remindMePattern.FindAllStringIndex(title, -1)
+	if len(starts) == 0 {
+		return result
+	}
+
+	var cleaned strings.Builder
+	last := 0
+	for i, loc := range starts {
+		end := len(title)
+		if i+1 < len(starts) {
+			end = starts[i+1][0]
+		}
+		phrase := title[loc[1]:end]
+		if cut := strings.IndexAny(phrase, ",;"); cut >= 0 {
+			phrase = phrase[:cut]
+		}
+
+		reminder, consumed, ok := parseReminderPhrase(phrase, referenceTime)
+		if !ok {
+			continue
+		}
+		result.Reminders = append(result.Reminders, reminder)
+
+		// Drop "remind me" and what was parsed, plus a trailing separator
+		rest := loc[1] + consumed
+		if rest < len(title) && (title[rest] == ',' || title[rest] == ';') {
+			rest++
+		}
+		cleaned.WriteString(title[last:loc[0]])
+		cleaned.WriteString(" ")
+		last = rest
+	}
+	cleaned.WriteString(title[last:])
+
+	result.CleanedTitle = normalizeWhitespace(strings.Trim(normalizeWhitespace(cleaned.String()), ",; "))
+	return result
+}
+
+// ParseReminder parses a single reminder such as "30m before", "2 hours
+// before due" or "friday at 9am", with or without a leading "remind me"
+func ParseReminder(text string, referenceTime time.Time) (Reminder, bool) {
+	text = strings.TrimSpace(text)
+	if loc := remindMePattern.FindStringIndex(text); loc != nil && loc[0] == 0 {
+		text = text[loc[1]:]
+	}
+
+	reminder, consumed, ok := parseReminderPhrase(text, referenceTime)
+	if !ok || strings.TrimSpace(text[consumed:]) != "" {
+		return Reminder{}, false
+	}
+	return reminder, true
+}
+
+// parseReminderPhrase parses the reminder at the start of phrase and returns
+// how many bytes of phrase it used. Offsets may be followed by more text;
+// absolute times must use the whole phrase.
+func parseReminderPhrase(phrase string, referenceTime time.Time) (Reminder, int, bool) {
+	trimmed := strings.TrimLeft(phrase, " \t")
+	offset := len(phrase) - len(trimmed)
+
+	if matches := reminderOffsetPattern.FindStringSubmatch(trimmed); matches != nil {
+		num := 1
+		if n, err := strconv.Atoi(matches[1]); err == nil {
+			num = n
+		}
+		if num <= 0 {
+			return Reminder{}, 0, false
+		}
+
+		var unit time.Duration
+		switch strings.ToLower(matches[2])[0] {
+		case 'm':
+			unit = time.Minute
+		case 'h':
+			unit = time.Hour
+		case 'd':
+			unit = 24 * time.Hour
+		case 'w':
+			unit = 7 * 24 * time.Hour
+		}
+		return Reminder{Before: time.Duration(num) * unit}, offset + len(matches[0]), true
+	}
+
+	trimmed = strings.TrimSpace(trimmed)
+	if trimmed == "" {
+		return Reminder{}, 0, false
+	}
+	parsed := Parse(trimmed, referenceTime)
+	if parsed.DueDate == nil || parsed.CleanedTitle != "" {
+		return Reminder{}, 0, false
+	}
+	return Reminder{At: parsed.DueDate}, len(strings.TrimRight(phrase, " \t")), true
+}
@@ -0,0 +1,123 @@
+package dateparse
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseReminders(t *testing.T) {
+	refTime := time.Date(2024, 11, 20, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name          string
+		input         string
+		expectedTitle string
+		expectedAt    []time.Time
+		expectedOff   []time.Duration
+	}{
+		{
+			name:          "Offset in minutes",
+			input:         "Call mom tomorrow at 5pm remind me 30m before",
+			expectedTitle: "Call mom tomorrow at 5pm",
+			expectedOff:   []time.Duration{30 * time.Minute},
+		},
+		{
+			name:          "Offset in words",
+			input:         "Submit report friday, remind me an hour before it's due",
+			expectedTitle: "Submit report friday",
+			expectedOff:   []time.Duration{time.Hour},
+		},
+		{
+			name:          "Several reminders",
+			input:         "Flight 11/26 at 9am remind me 1 day before, remind me 2h before",
+			expectedTitle: "Flight 11/26 at 9am",
+			expectedOff:   []time.Duration{24 * time.Hour, 2 * time.Hour},
+		},
+		{
+			name:          "Absolute reminder",
+			input:         "Pay rent remind me tomorrow at 9am",
+			expectedTitle: "Pay rent",
+			expectedAt:    []time.Time{time.Date(2024, 11, 21, 9, 0, 0, 0, time.Local)},
+		},
+		{
+			name:          "Unparseable reminder stays in title",
+			input:         "Remind me to water the plants",
+			expectedTitle: "Remind me to water the plants",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ParseReminders(tt.input, refTime)
+
+			if result.CleanedTitle != tt.expectedTitle {
+				t.Errorf("Expected title '%s', got '%s'", tt.expectedTitle, result.CleanedTitle)
+			}
+
+			var at []time.Time
+			var off []time.Duration
+			for _, reminder := range result.Reminders {
+				if reminder.At != nil {
+					at = append(at, *reminder.At)
+				} else {
+					off = append(off, reminder.Before)
+				}
+			}
+
+			if len(at) != len(tt.expectedAt) {
+				t.Fatalf("Expected %d absolute reminders, got %d", len(tt.expectedAt), len(at))
+			}
+			for i := range at {
+				if !at[i].Equal(tt.expectedAt[i]) {
+					t.Errorf("Expected reminder at %v, got %v", tt.expectedAt[i], at[i])
+				}
+			}
+			if len(off) != len(tt.expectedOff) {
+				t.Fatalf("Expected %d offset reminders, got %d", len(tt.expectedOff), len(off))
+			}
+			for i := range off {
+				if off[i] != tt.expectedOff[i] {
+					t.Errorf("Expected reminder %v before, got %v", tt.expectedOff[i], off[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseReminder(t *testing.T) {
+	refTime := time.Date(2024, 11, 20, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		input    string
+		ok       bool
+		before   time.Duration
+		absolute bool
+	}{
+		{input: "30m before", ok: true, before: 30 * time.Minute},
+		{input: "remind me 2 hours before due", ok: true, before: 2 * time.Hour},
+		{input: "1w before", ok: true, before: 7 * 24 * time.Hour},
+		{input: "friday at 9am", ok: true, absolute: true},
+		{input: "in 2 hours", ok: true, absolute: true},
+		{input: "30m before lunch", ok: false},
+		{input: "whenever", ok: false},
+		{input: "", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			reminder, ok := ParseReminder(tt.input, refTime)
+			if ok != tt.ok {
+				t.Fatalf("Expected ok=%v, got %v", tt.ok, ok)
+			}
+			if !ok {
+				return
+			}
+			if (reminder.At != nil) != tt.absolute {
+				t.Errorf("Expected absolute=%v, got %+v", tt.absolute, reminder)
+			}
+			if reminder.Before != tt.before {
+				t.Errorf("Expected %v before, got %v", tt.before, reminder.Before)
+			}
+		})
+	}
+}
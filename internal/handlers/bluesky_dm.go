@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/shindakun/attodo/internal/chat"
+	"github.com/shindakun/attodo/internal/database"
+	"github.com/shindakun/attodo/internal/models"
+	"github.com/shindakun/attodo/internal/session"
+)
+
+// BlueskyDMHandler manages opting in to notifications as Bluesky chat DMs
+// from the service account
+type BlueskyDMHandler struct {
+	repo    *database.NotificationRepo
+	sender  *chat.Sender
+	baseURL string
+}
+
+// NewBlueskyDMHandler creates a new Bluesky DM handler
+func NewBlueskyDMHandler(repo *database.NotificationRepo, baseURL string) *BlueskyDMHandler {
+	return &BlueskyDMHandler{
+		repo:    repo,
+		sender:  nil, // Set later with SetSender()
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// SetSender sets the DM sender (called when the service account is configured)
+func (h *BlueskyDMHandler) SetSender(sender *chat.Sender) {
+	h.sender = sender
+}
+
+// blueskyDMStatus is the Bluesky DM channel state shown in settings
+type blueskyDMStatus struct {
+	Configured  bool   `json:"configured"`
+	Account     string `json:"account,omitempty"`
+	Enabled     bool   `json:"enabled"`
+	Unavailable bool   `json:"unavailable"` // The last DM was refused
+}
+
+// HandleBlueskyDM handles /app/bluesky-dm: GET returns the channel state, POST
+// opts in after checking the user accepts DMs from the service account and
+// sends a confirmation DM, DELETE opts out
+func (h *BlueskyDMHandler) HandleBlueskyDM(w http.ResponseWriter, r *http.Request) {
+	sess, ok := session.GetSession(r)
+	if !ok || sess == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.writeStatus(w, r, sess.DID)
+
+	case http.MethodPost:
+		h.handleOptIn(w, r, sess.DID)
+
+	case http.MethodDelete:
+		user, err := h.repo.GetNotificationUser(sess.DID)
+		if err != nil {
+			log.Printf("Failed to get notification user: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if user != nil && user.BlueskyDMEnabled {
+			user.BlueskyDMEnabled = false
+			if err := h.repo.UpdateNotificationUser(user); err != nil {
+				log.Printf("Failed to disable Bluesky DMs: %v", err)
+				http.Error(w, "Failed to turn off Bluesky DMs", http.StatusInternalServerError)
+				return
+			}
+			disableIfUnreachable(h.repo, sess.DID)
+			log.Printf("Bluesky DMs disabled for DID: %s", sess.DID)
+		}
+		h.writeStatus(w, r, sess.DID)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleOptIn turns on Bluesky DMs once a confirmation DM gets through
+func (h *BlueskyDMHandler) handleOptIn(w http.ResponseWriter, r *http.Request, did string) {
+	if h.sender == nil {
+		http.Error(w, "Bluesky DM notifications not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	account, err := h.sender.Account(r.Context())
+	if err != nil {
+		log.Printf("Failed to log in to the Bluesky service account: %v", err)
+		http.Error(w, "Bluesky DMs are unavailable right now. Please try again later.", http.StatusBadGateway)
+		return
+	}
+
+	canMessage, err := h.sender.CanMessage(r.Context(), did)
+	if err != nil {
+		log.Printf("Failed to check Bluesky DM availability for %s: %v", did, err)
+		http.Error(w, "Couldn't reach the Bluesky chat service. Please try again later.", http.StatusBadGateway)
+		return
+	}
+	if !canMessage {
+		http.Error(w, fmt.Sprintf("Your Bluesky chat settings don't allow messages from @%s. Allow messages from everyone, or from people you follow and follow @%s, then try again.", account, account), http.StatusConflict)
+		return
+	}
+
+	user, err := h.repo.GetNotificationUser(did)
+	if err != nil {
+		log.Printf("Failed to get notification user: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if user == nil {
+		user = &models.NotificationUser{DID: did, NotificationsEnabled: true, BlueskyDMEnabled: true}
+		err = h.repo.CreateNotificationUser(user)
+	} else {
+		user.NotificationsEnabled = true
+		user.BlueskyDMEnabled = true
+		err = h.repo.UpdateNotificationUser(user)
+	}
+	if err != nil {
+		log.Printf("Failed to enable Bluesky DMs: %v", err)
+		http.Error(w, "Failed to turn on Bluesky DMs", http.StatusInternalServerError)
+		return
+	}
+
+	err = h.sender.Send(r.Context(), did, &chat.Message{
+		Text: "You'll get your AT Todo notifications here. Turn them off any time under Delivery Channels in Settings.",
+		Link: h.baseURL + "/app",
+	})
+	if err != nil {
+		// Opted in regardless; the next notification tries again
+		log.Printf("Failed to send Bluesky DM confirmation to %s: %v", did, err)
+	}
+
+	log.Printf("Bluesky DMs enabled for DID: %s", did)
+	h.writeStatus(w, r, did)
+}
+
+// writeStatus writes the Bluesky DM channel state as JSON
+func (h *BlueskyDMHandler) writeStatus(w http.ResponseWriter, r *http.Request, did string) {
+	status := blueskyDMStatus{Configured: h.sender != nil}
+	if h.sender != nil {
+		account, err := h.sender.Account(r.Context())
+		if err != nil {
+			log.Printf("Failed to log in to the Bluesky service account: %v", err)
+		}
+		status.Account = account
+		status.Unavailable = h.sender.Unavailable(did)
+	}
+
+	user, err := h.repo.GetNotificationUser(did)
+	if err != nil {
+		log.Printf("Failed to get notification user: %v", err)
+	} else if user != nil {
+		status.Enabled = user.BlueskyDMEnabled
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shindakun/attodo/internal/models"
+	"github.com/shindakun/attodo/internal/session"
+	"github.com/shindakun/bskyoauth"
+)
+
+// BoardHandler serves the Kanban board view of a list. Task statuses live on
+// the task records; the list holds the column definitions and task order.
+type BoardHandler struct {
+	client      *bskyoauth.Client
+	taskHandler *TaskHandler
+	listHandler *ListHandler
+}
+
+func NewBoardHandler(client *bskyoauth.Client, taskHandler *TaskHandler, listHandler *ListHandler) *BoardHandler {
+	return &BoardHandler{
+		client:      client,
+		taskHandler: taskHandler,
+		listHandler: listHandler,
+	}
+}
+
+// HandleBoard shows a list as a board (e.g., /app/lists/board/abc123)
+func (h *BoardHandler) HandleBoard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sess, ok := session.GetSession(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	rkey := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/app/lists/board/"), "/")
+	if rkey == "" {
+		http.Error(w, "List ID required", http.StatusBadRequest)
+		return
+	}
+
+	list, sess, err := h.loadBoard(r.Context(), sess, rkey)
+	if err != nil {
+		log.Printf("Failed to load board for list %s: %v", rkey, err)
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
+
+	h.updateSession(r, sess)
+
+	w.Header().Set("Content-Type", "text/html")
+	Render(w, "list-board.html", list)
+}
+
+// HandleMove moves a task to a board column and position
+// Form fields: rkey (list), task (task URI), column (column ID), prev and next
+// (URIs of the tasks now directly above and below it in the column)
+func (h *BoardHandler) HandleMove(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sess, ok := session.GetSession(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	rkey := r.FormValue("rkey")
+	taskURI := r.FormValue("task")
+	columnID := r.FormValue("column")
+	if rkey == "" || taskURI == "" || columnID == "" {
+		http.Error(w, "rkey, task and column are required", http.StatusBadRequest)
+		return
+	}
+
+	list, sess, err := h.loadBoard(r.Context(), sess, rkey)
+	if err != nil {
+		log.Printf("Failed to load board for list %s: %v", rkey, err)
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
+
+	column, ok := list.FindBoardColumn(columnID)
+	if !ok {
+		http.Error(w, "Unknown column", http.StatusBadRequest)
+		return
+	}
+
+	// Only tasks resolved from the user's own repository can be moved
+	uris := taskURIs(list.Tasks)
+	if slices.Index(uris, taskURI) < 0 {
+		http.Error(w, "Task is not in this list", http.StatusBadRequest)
+		return
+	}
+
+	// Re-read the full task record so fields the list view doesn't parse are kept
+	taskRKey := extractRKey(taskURI)
+	var task *models.Task
+	sess, err = h.taskHandler.withRetry(r.Context(), sess, func(s *bskyoauth.Session) error {
+		task, err = h.taskHandler.getRecord(r.Context(), s, taskRKey)
+		return err
+	})
+	if err != nil {
+		log.Printf("Failed to get task %s for board move: %v", taskRKey, err)
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+
+	wasCompleted := task.Completed
+	terminal := column.ID == list.TerminalColumn().ID
+	if task.Status != column.ID || task.Completed != terminal {
+		task.SetStatus(column.ID, terminal, time.Now().UTC())
+
+		record := buildTaskRecord(task)
+		sess, err = h.taskHandler.withRetry(r.Context(), sess, func(s *bskyoauth.Session) error {
+			return h.taskHandler.updateRecord(r.Context(), s, taskRKey, record)
+		})
+		if err != nil {
+			log.Printf("Failed to update status of task %s: %v", taskRKey, err)
+			errMsg := getUserFriendlyError(err, "Failed to move task. Please try again.")
+			http.Error(w, errMsg, http.StatusInternalServerError)
+			return
+		}
+
+		// Moving a recurring task to the terminal column completes it
+		if task.Completed && !wasCompleted && task.IsRecurring {
+			if err := h.taskHandler.handleRecurringTaskCompletion(r.Context(), sess, task); err != nil {
+				log.Printf("Warning: Failed to create next recurring instance: %v", err)
+			}
+		}
+	}
+
+	// Keep the position the task was dropped at
+	if list.Positions == nil {
+		list.Positions = make(map[string]string)
+	}
+	if err := models.MovePosition(uris, list.Positions, taskURI, r.FormValue("prev"), r.FormValue("next")); err != nil {
+		log.Printf("Failed to move task in list %s: %v", rkey, err)
+	} else {
+		list.UpdatedAt = time.Now().UTC()
+		record := buildListRecord(list)
+		sess, err = h.listHandler.WithRetry(r.Context(), sess, func(s *bskyoauth.Session) error {
+			return h.listHandler.updateRecord(r.Context(), s, rkey, record)
+		})
+		if err != nil {
+			// The status change is already saved; only the position is lost
+			log.Printf("Failed to save order of list %s: %v", rkey, err)
+		}
+	}
+
+	h.updateSession(r, sess)
+
+	// Render the board as it is now
+	for i, t := range list.Tasks {
+		if t.URI == taskURI {
+			task.URI = taskURI
+			list.Tasks[i] = task
+		}
+	}
+	list.OrderTasks()
+
+	log.Printf("Task %s moved to column %s of list %s", taskURI, column.ID, rkey)
+	w.Header().Set("Content-Type", "text/html")
+	Render(w, "board-lanes", list)
+}
+
+// HandleColumns replaces a list's board columns
+// Form fields: rkey (list), columns (comma or newline separated names; empty
+// restores the default columns)
+func (h *BoardHandler) HandleColumns(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sess, ok := session.GetSession(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	rkey := r.FormValue("rkey")
+	if rkey == "" {
+		http.Error(w, "rkey is required", http.StatusBadRequest)
+		return
+	}
+
+	var columns []models.BoardColumn
+	if input := strings.TrimSpace(r.FormValue("columns")); input != "" {
+		columns = models.ParseBoardColumns(input)
+		if len(columns) < models.MinBoardColumns {
+			http.Error(w, fmt.Sprintf("A board needs at least %d columns", models.MinBoardColumns), http.StatusBadRequest)
+			return
+		}
+	}
+
+	list, sess, err := h.loadBoard(r.Context(), sess, rkey)
+	if err != nil {
+		log.Printf("Failed to load board for list %s: %v", rkey, err)
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
+
+	list.Columns = columns
+	list.UpdatedAt = time.Now().UTC()
+	record := buildListRecord(list)
+
+	sess, err = h.listHandler.WithRetry(r.Context(), sess, func(s *bskyoauth.Session) error {
+		return h.listHandler.updateRecord(r.Context(), s, rkey, record)
+	})
+	if err != nil {
+		log.Printf("Failed to update columns of list %s: %v", rkey, err)
+		errMsg := getUserFriendlyError(err, "Failed to update columns. Please try again.")
+		http.Error(w, errMsg, http.StatusInternalServerError)
+		return
+	}
+
+	h.updateSession(r, sess)
+
+	log.Printf("Updated board columns of list %s: %d columns", rkey, len(list.BoardColumns()))
+	w.Header().Set("Content-Type", "text/html")
+	Render(w, "board-lanes", list)
+}
+
+// loadBoard fetches a list with its tasks resolved and in manual order
+func (h *BoardHandler) loadBoard(ctx context.Context, sess *bskyoauth.Session, rkey string) (*models.TaskList, *bskyoauth.Session, error) {
+	var record map[string]interface{}
+	var err error
+	sess, err = h.listHandler.WithRetry(ctx, sess, func(s *bskyoauth.Session) error {
+		record, err = h.listHandler.getRecord(ctx, s, rkey)
+		return err
+	})
+	if err != nil {
+		return nil, sess, err
+	}
+
+	list := parseListRecord(record)
+	list.RKey = rkey
+	list.URI = fmt.Sprintf("at://%s/%s/%s", sess.DID, ListCollection, rkey)
+
+	if err := h.listHandler.loadListTasks(ctx, sess, []*models.TaskList{list}); err != nil {
+		return nil, sess, err
+	}
+
+	return list, sess, nil
+}
+
+// updateSession stores a refreshed session under the request's session cookie
+func (h *BoardHandler) updateSession(r *http.Request, sess *bskyoauth.Session) {
+	cookie, _ := r.Cookie("session_id")
+	if cookie != nil {
+		h.client.UpdateSession(cookie.Value, sess)
+	}
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/shindakun/attodo/internal/models"
+	"github.com/shindakun/attodo/internal/session"
+)
+
+type DigestHandler struct {
+	taskHandler     *TaskHandler
+	settingsHandler *SettingsHandler
+}
+
+func NewDigestHandler(taskHandler *TaskHandler, settingsHandler *SettingsHandler) *DigestHandler {
+	return &DigestHandler{
+		taskHandler:     taskHandler,
+		settingsHandler: settingsHandler,
+	}
+}
+
+// HandleDigest renders the daily or weekly digest page (?kind=daily or
+// weekly, daily by default), in the timezone from the user's settings
+func (h *DigestHandler) HandleDigest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sess, ok := session.GetSession(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	kind := r.URL.Query().Get("kind")
+	if kind == "" {
+		kind = models.DigestDaily
+	}
+	if !models.IsDigestKind(kind) {
+		http.Error(w, "Unknown digest kind", http.StatusBadRequest)
+		return
+	}
+
+	loc := time.Local
+	var viewRKey string
+	if settings, err := h.settingsHandler.FetchSettings(r.Context(), sess.DID); err == nil {
+		loc = models.UserLocation(settings.Timezone)
+		viewRKey = settings.DigestView
+	} else {
+		log.Printf("Failed to load settings for digest for %s: %v", sess.DID, err)
+	}
+
+	digest, err := loadDigest(r.Context(), sess.PDS, sess.DID, kind, viewRKey, time.Now(), loc)
+	if err != nil {
+		log.Printf("Failed to load %s digest for %s: %v", kind, sess.DID, err)
+		http.Error(w, "Failed to load your digest", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	Render(w, "digest.html", digest)
+}
+
+// FetchDigest builds a user's digest without a session, for the digest
+// notifications. viewRKey names a saved view limiting the tasks, or is empty.
+func (h *DigestHandler) FetchDigest(ctx context.Context, did, kind, viewRKey string, now time.Time, loc *time.Location) (*models.Digest, error) {
+	pds, err := h.taskHandler.resolvePDSEndpoint(ctx, did)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve PDS endpoint: %w", err)
+	}
+	return loadDigest(ctx, pds, did, kind, viewRKey, now, loc)
+}
+
+// loadDigest reads the user's tasks and calendar events and builds the digest.
+// With a saved view, only the tasks matching it are included; a view that's
+// been deleted is ignored.
+func loadDigest(ctx context.Context, pds, did, kind, viewRKey string, now time.Time, loc *time.Location) (*models.Digest, error) {
+	tasks, err := fetchPublicTasks(ctx, pds, did)
+	if err != nil {
+		return nil, err
+	}
+
+	if viewRKey != "" {
+		view, err := fetchPublicView(ctx, pds, did, viewRKey)
+		switch {
+		case errors.Is(err, errViewNotFound):
+			log.Printf("Digest view %s for %s no longer exists, using all tasks", viewRKey, did)
+		case err != nil:
+			return nil, fmt.Errorf("failed to load digest view: %w", err)
+		default:
+			tasks = view.Apply(tasks)
+		}
+	}
+
+	events, err := fetchPublicEvents(ctx, pds, did)
+	if err != nil {
+		return nil, err
+	}
+
+	if kind == models.DigestWeekly {
+		return models.BuildWeeklyDigest(tasks, events, now, loc), nil
+	}
+	return models.BuildDailyDigest(tasks, events, now, loc), nil
+}
+
+// fetchPublicEvents reads all of a user's calendar events, skipping records
+// that don't parse
+func fetchPublicEvents(ctx context.Context, pds, did string) ([]*models.CalendarEvent, error) {
+	records, err := listPublicRecords(ctx, pds, did, CalendarEventCollection)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list calendar events: %w", err)
+	}
+
+	events := make([]*models.CalendarEvent, 0, len(records))
+	for _, record := range records {
+		event, err := models.ParseCalendarEvent(record.Value, record.URI, record.CID)
+		if err != nil {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
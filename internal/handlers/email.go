@@ -0,0 +1,352 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/shindakun/attodo/internal/database"
+	attodomail "github.com/shindakun/attodo/internal/mail"
+	"github.com/shindakun/attodo/internal/models"
+	"github.com/shindakun/attodo/internal/session"
+)
+
+const (
+	// EmailVerifyTTL is how long a verification link works
+	EmailVerifyTTL = 48 * time.Hour
+
+	// emailResendInterval is how soon another verification email can be sent
+	// to the same address
+	emailResendInterval = time.Minute
+)
+
+// EmailHandler manages email notification addresses: double opt-in
+// verification and unsubscribe links
+type EmailHandler struct {
+	repo    *database.NotificationRepo
+	sender  *attodomail.Sender
+	baseURL string
+}
+
+// NewEmailHandler creates a new email handler
+func NewEmailHandler(repo *database.NotificationRepo, baseURL string) *EmailHandler {
+	return &EmailHandler{
+		repo:    repo,
+		sender:  nil, // Set later with SetSender()
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// SetSender sets the SMTP sender (called when SMTP is configured)
+func (h *EmailHandler) SetSender(sender *attodomail.Sender) {
+	h.sender = sender
+}
+
+// EmailUnsubscribeURL returns the unsubscribe link for an address
+func EmailUnsubscribeURL(baseURL, token string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/email/unsubscribe?token=" + url.QueryEscape(token)
+}
+
+// emailStatus is the email channel state shown in settings
+type emailStatus struct {
+	Configured   bool   `json:"configured"`
+	Email        string `json:"email,omitempty"`
+	Verified     bool   `json:"verified"`
+	Unsubscribed bool   `json:"unsubscribed"`
+}
+
+// HandleEmail handles /app/email: GET returns the user's address and its
+// state, POST sets an address and sends a verification email, DELETE removes
+// the address
+func (h *EmailHandler) HandleEmail(w http.ResponseWriter, r *http.Request) {
+	sess, ok := session.GetSession(r)
+	if !ok || sess == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		addr, err := h.repo.GetEmailAddress(sess.DID)
+		if err != nil {
+			log.Printf("Failed to get email address: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		h.writeStatus(w, addr)
+
+	case http.MethodPost:
+		h.handleSetEmail(w, r, sess.DID)
+
+	case http.MethodDelete:
+		if err := h.repo.DeleteEmailAddress(sess.DID); err != nil {
+			log.Printf("Failed to delete email address: %v", err)
+			http.Error(w, "Failed to remove email address", http.StatusInternalServerError)
+			return
+		}
+		disableIfUnreachable(h.repo, sess.DID)
+		log.Printf("Email address removed for DID: %s", sess.DID)
+		h.writeStatus(w, nil)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleSetEmail saves a new, unverified address and sends the verification
+// email
+func (h *EmailHandler) handleSetEmail(w http.ResponseWriter, r *http.Request, did string) {
+	if h.sender == nil {
+		http.Error(w, "Email notifications not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	email := strings.TrimSpace(req.Email)
+	if parsed, err := mail.ParseAddress(email); err != nil || parsed.Address != email || len(email) > 254 {
+		http.Error(w, "Please enter a valid email address", http.StatusBadRequest)
+		return
+	}
+
+	existing, err := h.repo.GetEmailAddress(did)
+	if err != nil {
+		log.Printf("Failed to get email address: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if existing != nil && strings.EqualFold(existing.Email, email) {
+		if existing.Active() {
+			h.writeStatus(w, existing)
+			return
+		}
+		if existing.VerifySentAt != nil && time.Since(*existing.VerifySentAt) < emailResendInterval {
+			http.Error(w, "A verification email was just sent. Please wait a minute before trying again.", http.StatusTooManyRequests)
+			return
+		}
+	}
+
+	// The address references the notification user (foreign key constraint)
+	if err := h.ensureNotificationUser(did); err != nil {
+		log.Printf("Failed to create notification user: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	verifyToken, err := newEmailToken()
+	if err != nil {
+		log.Printf("Failed to generate verification token: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	now := time.Now()
+	addr := &models.EmailAddress{
+		DID:          did,
+		Email:        email,
+		VerifyToken:  verifyToken,
+		VerifySentAt: &now,
+	}
+	if existing != nil {
+		addr.UnsubscribeToken = existing.UnsubscribeToken
+		addr.CreatedAt = existing.CreatedAt
+	} else if addr.UnsubscribeToken, err = newEmailToken(); err != nil {
+		log.Printf("Failed to generate unsubscribe token: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.repo.SaveEmailAddress(addr); err != nil {
+		log.Printf("Failed to save email address: %v", err)
+		http.Error(w, "Failed to save email address", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.sendVerification(addr); err != nil {
+		log.Printf("Failed to send verification email to %s: %v", did, err)
+		http.Error(w, "Failed to send verification email. Please check the address and try again.", http.StatusBadGateway)
+		return
+	}
+
+	log.Printf("Verification email sent for DID: %s", did)
+	h.writeStatus(w, addr)
+}
+
+// HandleVerify handles GET /email/verify?token=..., the link in the
+// verification email
+func (h *EmailHandler) HandleVerify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	addr, err := h.repo.GetEmailAddressByVerifyToken(r.URL.Query().Get("token"))
+	if err != nil {
+		log.Printf("Failed to get email address: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if addr == nil || addr.VerifySentAt == nil || time.Since(*addr.VerifySentAt) > EmailVerifyTTL {
+		h.renderStatus(w, http.StatusNotFound, "Link expired",
+			"This confirmation link is no longer valid. Enter your email address in Settings again to get a new one.", nil)
+		return
+	}
+
+	now := time.Now()
+	addr.VerifiedAt = &now
+	addr.VerifyToken = ""
+	addr.UnsubscribedAt = nil
+	if err := h.repo.SaveEmailAddress(addr); err != nil {
+		log.Printf("Failed to verify email address: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if err := enableNotifications(h.repo, addr.DID); err != nil {
+		log.Printf("Failed to enable notifications: %v", err)
+	}
+
+	log.Printf("Email address verified for DID: %s", addr.DID)
+	h.renderStatus(w, http.StatusOK, "Email confirmed",
+		fmt.Sprintf("You'll get notifications at %s for the types you've turned on, as long as Email is checked under Delivery Channels in Settings.", addr.Email), nil)
+}
+
+// HandleUnsubscribe handles /email/unsubscribe?token=...: GET asks for
+// confirmation, POST unsubscribes (also used for one-click unsubscribe from
+// the mail client)
+func (h *EmailHandler) HandleUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	addr, err := h.repo.GetEmailAddressByUnsubscribeToken(r.FormValue("token"))
+	if err != nil {
+		log.Printf("Failed to get email address: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if addr == nil {
+		h.renderStatus(w, http.StatusNotFound, "Link not valid",
+			"This unsubscribe link is no longer valid. You can manage email notifications in Settings.", nil)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		if addr.UnsubscribedAt != nil {
+			h.renderStatus(w, http.StatusOK, "Unsubscribed",
+				fmt.Sprintf("%s doesn't get AT Todo notifications.", addr.Email), nil)
+			return
+		}
+		h.renderStatus(w, http.StatusOK, "Unsubscribe",
+			fmt.Sprintf("Stop sending AT Todo notifications to %s?", addr.Email), addr)
+		return
+	}
+
+	if addr.UnsubscribedAt == nil {
+		now := time.Now()
+		addr.UnsubscribedAt = &now
+		if err := h.repo.SaveEmailAddress(addr); err != nil {
+			log.Printf("Failed to unsubscribe email address: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		disableIfUnreachable(h.repo, addr.DID)
+		log.Printf("Email address unsubscribed for DID: %s", addr.DID)
+	}
+
+	h.renderStatus(w, http.StatusOK, "Unsubscribed",
+		fmt.Sprintf("%s won't get AT Todo notifications anymore. You can turn them back on in Settings.", addr.Email), nil)
+}
+
+// sendVerification sends the double opt-in email for an address
+func (h *EmailHandler) sendVerification(addr *models.EmailAddress) error {
+	data := struct {
+		Email     string
+		VerifyURL string
+		ExpiresIn string
+	}{
+		Email:     addr.Email,
+		VerifyURL: h.baseURL + "/email/verify?token=" + url.QueryEscape(addr.VerifyToken),
+		ExpiresIn: fmt.Sprintf("%d hours", int(EmailVerifyTTL.Hours())),
+	}
+
+	html, text, err := RenderEmail("email-verify", data)
+	if err != nil {
+		return err
+	}
+
+	return h.sender.Send(&attodomail.Message{
+		To:      addr.Email,
+		Subject: "Confirm your email for AT Todo",
+		HTML:    html,
+		Text:    text,
+	})
+}
+
+// ensureNotificationUser creates the user's notification record if missing,
+// without enabling notifications (that happens once the address is verified)
+func (h *EmailHandler) ensureNotificationUser(did string) error {
+	user, err := h.repo.GetNotificationUser(did)
+	if err != nil {
+		return err
+	}
+	if user != nil {
+		return nil
+	}
+	return h.repo.CreateNotificationUser(&models.NotificationUser{DID: did})
+}
+
+// writeStatus writes the email channel state as JSON
+func (h *EmailHandler) writeStatus(w http.ResponseWriter, addr *models.EmailAddress) {
+	status := emailStatus{Configured: h.sender != nil}
+	if addr != nil {
+		status.Email = addr.Email
+		status.Verified = addr.VerifiedAt != nil
+		status.Unsubscribed = addr.UnsubscribedAt != nil
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// renderStatus renders the result of following a link from an email; with an
+// address, it asks to confirm unsubscribing it
+func (h *EmailHandler) renderStatus(w http.ResponseWriter, code int, title, message string, unsubscribe *models.EmailAddress) {
+	data := struct {
+		Title            string
+		Message          string
+		Email            string
+		UnsubscribeToken string
+	}{
+		Title:   title,
+		Message: message,
+	}
+	if unsubscribe != nil {
+		data.Email = unsubscribe.Email
+		data.UnsubscribeToken = unsubscribe.UnsubscribeToken
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+	Render(w, "email-status.html", data)
+}
+
+// newEmailToken returns a random token for verification and unsubscribe links
+func newEmailToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shindakun/attodo/internal/models"
+)
+
+const (
+	publicCacheTTL     = 5 * time.Minute
+	publicCacheMaxSize = 1000
+
+	embedDefaultWidth  = 400
+	embedMaxHeight     = 480
+	embedBaseHeight    = 110 // Header and footer
+	embedTaskRowHeight = 34
+)
+
+type cachedPublicList struct {
+	List      *models.TaskList
+	Lists     []*models.TaskList // Every list of a repository, for CachedPublicLists
+	ExpiresAt time.Time
+}
+
+// CachedPublicList returns a public list, serving repeated requests from memory
+// so embeds and badges don't hit the owner's PDS on every page view
+func (h *ListHandler) CachedPublicList(ctx context.Context, handle, rkey string) (*models.TaskList, error) {
+	key := strings.ToLower(handle) + "/" + rkey
+
+	if cached, ok := h.cachedPublic(key); ok {
+		return cached.List, nil
+	}
+
+	list, err := h.FetchPublicList(ctx, handle, rkey)
+	if err != nil {
+		return nil, err
+	}
+	// Keep the handle as typed, unless it's the owner's DID
+	if !strings.HasPrefix(handle, "did:") {
+		list.OwnerHandle = handle
+	}
+
+	h.storePublic(key, &cachedPublicList{List: list})
+	return list, nil
+}
+
+// CachedPublicLists returns every public list of a handle or DID, cached like
+// CachedPublicList
+func (h *ListHandler) CachedPublicLists(ctx context.Context, identifier string) ([]*models.TaskList, error) {
+	key := strings.ToLower(identifier) + "/"
+
+	if cached, ok := h.cachedPublic(key); ok {
+		return cached.Lists, nil
+	}
+
+	lists, err := h.FetchPublicLists(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	h.storePublic(key, &cachedPublicList{Lists: lists})
+	return lists, nil
+}
+
+// cachedPublic returns an unexpired cache entry
+func (h *ListHandler) cachedPublic(key string) (*cachedPublicList, bool) {
+	h.cacheMu.RLock()
+	defer h.cacheMu.RUnlock()
+
+	cached, ok := h.publicCache[key]
+	if !ok || !time.Now().Before(cached.ExpiresAt) {
+		return nil, false
+	}
+	return cached, true
+}
+
+// storePublic caches an entry for publicCacheTTL
+func (h *ListHandler) storePublic(key string, entry *cachedPublicList) {
+	entry.ExpiresAt = time.Now().Add(publicCacheTTL)
+
+	h.cacheMu.Lock()
+	if len(h.publicCache) >= publicCacheMaxSize {
+		h.evictExpiredLocked()
+	}
+	h.publicCache[key] = entry
+	h.cacheMu.Unlock()
+}
+
+// evictExpiredLocked drops expired entries, or everything if the cache is still full
+// Caller must hold cacheMu
+func (h *ListHandler) evictExpiredLocked() {
+	now := time.Now()
+	for key, cached := range h.publicCache {
+		if now.After(cached.ExpiresAt) {
+			delete(h.publicCache, key)
+		}
+	}
+	if len(h.publicCache) >= publicCacheMaxSize {
+		h.publicCache = make(map[string]*cachedPublicList)
+	}
+}
+
+// parsePublicListPath extracts handle and rkey from a path like /prefix/@handle/rkey
+func parsePublicListPath(path, prefix string) (string, string, bool) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(path, prefix), "/"), "/")
+	if len(parts) < 2 {
+		return "", "", false
+	}
+
+	handle := strings.TrimPrefix(parts[0], "@")
+	rkey := parts[1]
+	if handle == "" || rkey == "" {
+		return "", "", false
+	}
+
+	return handle, rkey, true
+}
+
+// writePublicListError maps list resolution errors to HTTP responses
+func writePublicListError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errInvalidIdentifier):
+		http.Error(w, "Invalid handle", http.StatusBadRequest)
+	case errors.Is(err, errIdentityNotFound):
+		http.Error(w, "Handle not found", http.StatusNotFound)
+	default:
+		http.Error(w, "List not found or not public", http.StatusNotFound)
+	}
+}
+
+// setPublicCacheHeaders lets browsers and CDNs cache embed responses
+func setPublicCacheHeaders(w http.ResponseWriter) {
+	if appConfig != nil && appConfig.IsDev() {
+		return
+	}
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(publicCacheTTL.Seconds())))
+}
+
+// embedHeight estimates the iframe height needed to show a list
+func embedHeight(list *models.TaskList) int {
+	height := embedBaseHeight + len(list.Tasks)*embedTaskRowHeight
+	if height > embedMaxHeight {
+		return embedMaxHeight
+	}
+	return height
+}
+
+// HandleEmbed renders a compact, iframe-friendly view of a public list
+// Example: /embed/list/@alice.bsky.social/abc123?theme=dark
+func (h *ListHandler) HandleEmbed(w http.ResponseWriter, r *http.Request) {
+	handle, rkey, ok := parsePublicListPath(r.URL.Path, "/embed/list/")
+	if !ok {
+		http.Error(w, "Invalid embed URL format. Expected: /embed/list/@handle/rkey", http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.CachedPublicList(r.Context(), handle, rkey)
+	if err != nil {
+		log.Printf("Failed to get list for embed %s/%s: %v", handle, rkey, err)
+		writePublicListError(w, err)
+		return
+	}
+
+	theme := r.URL.Query().Get("theme")
+	if theme != "dark" {
+		theme = "light"
+	}
+
+	data := map[string]interface{}{
+		"List":      list,
+		"Theme":     theme,
+		"PublicURL": publicListURL(list),
+	}
+
+	setPublicCacheHeaders(w)
+	w.Header().Set("Content-Type", "text/html")
+	Render(w, "embed-list.html", data)
+}
+
+// HandleBadge renders an SVG progress badge for a public list
+// Example: /badge/list/@alice.bsky.social/abc123.svg?label=launch
+func (h *ListHandler) HandleBadge(w http.ResponseWriter, r *http.Request) {
+	handle, rkey, ok := parsePublicListPath(r.URL.Path, "/badge/list/")
+	if !ok {
+		http.Error(w, "Invalid badge URL format. Expected: /badge/list/@handle/rkey.svg", http.StatusBadRequest)
+		return
+	}
+	rkey = strings.TrimSuffix(rkey, ".svg")
+
+	list, err := h.CachedPublicList(r.Context(), handle, rkey)
+	if err != nil {
+		log.Printf("Failed to get list for badge %s/%s: %v", handle, rkey, err)
+		writePublicListError(w, err)
+		return
+	}
+
+	label := r.URL.Query().Get("label")
+	if label == "" {
+		label = list.Name
+	}
+
+	setPublicCacheHeaders(w)
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Write([]byte(renderProgressBadge(label, list.CompletedCount(), len(list.Tasks))))
+}
+
+// renderProgressBadge draws a shields.io-style "label | 7/12 done" badge
+func renderProgressBadge(label string, completed, total int) string {
+	const maxLabel = 32
+	if runes := []rune(label); len(runes) > maxLabel {
+		label = string(runes[:maxLabel-1]) + "…"
+	}
+	value := fmt.Sprintf("%d/%d done", completed, total)
+
+	// Approximate text width for 11px Verdana
+	labelWidth := len([]rune(label))*7 + 12
+	valueWidth := len(value)*7 + 12
+	width := labelWidth + valueWidth
+
+	color := "#1e88e5"
+	if total > 0 && completed == total {
+		color = "#4caf50"
+	}
+
+	label = template.HTMLEscapeString(label)
+
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[2]s: %[3]s">
+<title>%[2]s: %[3]s</title>
+<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+<clipPath id="r"><rect width="%[1]d" height="20" rx="3" fill="#fff"/></clipPath>
+<g clip-path="url(#r)">
+<rect width="%[4]d" height="20" fill="#555"/>
+<rect x="%[4]d" width="%[5]d" height="20" fill="%[6]s"/>
+<rect width="%[1]d" height="20" fill="url(#s)"/>
+</g>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+<text x="%[7]d" y="15" fill="#010101" fill-opacity=".3">%[2]s</text>
+<text x="%[7]d" y="14">%[2]s</text>
+<text x="%[8]d" y="15" fill="#010101" fill-opacity=".3">%[3]s</text>
+<text x="%[8]d" y="14">%[3]s</text>
+</g>
+</svg>`, width, label, value, labelWidth, valueWidth, color, labelWidth/2, labelWidth+valueWidth/2)
+}
+
+// oEmbedResponse is an oEmbed 1.0 "rich" response
+type oEmbedResponse struct {
+	Type         string `json:"type"`
+	Version      string `json:"version"`
+	Title        string `json:"title"`
+	AuthorName   string `json:"author_name"`
+	AuthorURL    string `json:"author_url"`
+	ProviderName string `json:"provider_name"`
+	ProviderURL  string `json:"provider_url"`
+	CacheAge     int    `json:"cache_age"`
+	HTML         string `json:"html"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+}
+
+// HandleOEmbed is the oEmbed provider endpoint for public lists
+// Example: /oembed?url=https://attodo.app/list/@alice.bsky.social/abc123
+func (h *ListHandler) HandleOEmbed(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	if format := query.Get("format"); format != "" && format != "json" {
+		http.Error(w, "Only JSON format is supported", http.StatusNotImplemented)
+		return
+	}
+
+	target, err := url.Parse(query.Get("url"))
+	if err != nil || !strings.HasPrefix(target.Path, "/list/") {
+		http.Error(w, "url must be a public list URL (/list/@handle/rkey)", http.StatusNotFound)
+		return
+	}
+
+	handle, rkey, ok := parsePublicListPath(target.Path, "/list/")
+	if !ok {
+		http.Error(w, "url must be a public list URL (/list/@handle/rkey)", http.StatusNotFound)
+		return
+	}
+	rkey, _ = listFormatFromPath(rkey)
+
+	list, err := h.CachedPublicList(r.Context(), handle, rkey)
+	if err != nil {
+		log.Printf("Failed to get list for oEmbed %s/%s: %v", handle, rkey, err)
+		writePublicListError(w, err)
+		return
+	}
+
+	width := embedDefaultWidth
+	if maxWidth, err := strconv.Atoi(query.Get("maxwidth")); err == nil && maxWidth > 0 && maxWidth < width {
+		width = maxWidth
+	}
+	height := embedHeight(list)
+	if maxHeight, err := strconv.Atoi(query.Get("maxheight")); err == nil && maxHeight > 0 && maxHeight < height {
+		height = maxHeight
+	}
+
+	base := ""
+	if appConfig != nil {
+		base = strings.TrimSuffix(appConfig.BaseURL, "/")
+	}
+	embedURL := fmt.Sprintf("%s/embed/list/@%s/%s", base, url.PathEscape(list.OwnerHandle), url.PathEscape(list.RKey))
+
+	response := oEmbedResponse{
+		Type:         "rich",
+		Version:      "1.0",
+		Title:        list.Name,
+		AuthorName:   "@" + list.OwnerHandle,
+		AuthorURL:    "https://bsky.app/profile/" + list.OwnerHandle,
+		ProviderName: "AT Todo",
+		ProviderURL:  base + "/",
+		CacheAge:     int(publicCacheTTL.Seconds()),
+		HTML: fmt.Sprintf(`<iframe src="%s" width="%d" height="%d" style="border:0;max-width:100%%;" title="%s" loading="lazy"></iframe>`,
+			template.HTMLEscapeString(embedURL), width, height, template.HTMLEscapeString(list.Name)),
+		Width:  width,
+		Height: height,
+	}
+
+	setPublicCacheHeaders(w)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,413 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bluesky-social/indigo/api/atproto"
+	"github.com/shindakun/attodo/internal/models"
+	"github.com/shindakun/attodo/internal/session"
+	"github.com/shindakun/bskyoauth"
+)
+
+const FollowCollection = "app.attodo.follow"
+
+const (
+	feedWindow  = 14 * 24 * time.Hour // How far back the activity feed looks
+	feedLimit   = 50                  // Maximum number of feed entries rendered
+	feedWorkers = 4                   // Followed repositories fetched at once
+)
+
+type FollowHandler struct {
+	client      *bskyoauth.Client
+	listHandler *ListHandler
+}
+
+func NewFollowHandler(client *bskyoauth.Client, listHandler *ListHandler) *FollowHandler {
+	return &FollowHandler{
+		client:      client,
+		listHandler: listHandler,
+	}
+}
+
+// HandleFollows handles follow CRUD operations
+func (h *FollowHandler) HandleFollows(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.handleListFollows(w, r)
+	case http.MethodPost:
+		h.handleCreateFollow(w, r)
+	case http.MethodDelete:
+		h.handleDeleteFollow(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleFeed renders recent task additions and completions on followed lists
+func (h *FollowHandler) HandleFeed(w http.ResponseWriter, r *http.Request) {
+	sess, ok := session.GetSession(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	follows, err := h.fetchFollows(r.Context(), sess.PDS, sess.DID)
+	if err != nil {
+		log.Printf("Failed to list follows: %v", err)
+		http.Error(w, "Failed to load activity feed", http.StatusInternalServerError)
+		return
+	}
+
+	followed := make([][]*models.TaskList, len(follows))
+	forEachFollow(follows, func(i int, follow *models.Follow) {
+		var err error
+		followed[i], err = h.cachedFollowedLists(r.Context(), follow)
+		if err != nil {
+			log.Printf("Failed to fetch followed lists for %s: %v", follow.Subject, err)
+		}
+	})
+
+	lists := make([]*models.TaskList, 0)
+	for _, l := range followed {
+		lists = append(lists, l...)
+	}
+
+	items := models.BuildActivity(lists, time.Now().Add(-feedWindow), feedLimit)
+
+	w.Header().Set("Content-Type", "text/html")
+	if len(items) == 0 {
+		Render(w, "feed-empty.html", nil)
+		return
+	}
+	for _, item := range items {
+		Render(w, "feed-item.html", item)
+	}
+}
+
+// handleListFollows renders the lists and users the current user follows
+func (h *FollowHandler) handleListFollows(w http.ResponseWriter, r *http.Request) {
+	sess, ok := session.GetSession(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	follows, err := h.fetchFollows(r.Context(), sess.PDS, sess.DID)
+	if err != nil {
+		log.Printf("Failed to list follows: %v", err)
+		http.Error(w, "Failed to list follows", http.StatusInternalServerError)
+		return
+	}
+
+	forEachFollow(follows, func(_ int, follow *models.Follow) {
+		h.resolveDisplayName(r.Context(), follow)
+	})
+
+	w.Header().Set("Content-Type", "text/html")
+	for _, follow := range follows {
+		Render(w, "follow-item.html", follow)
+	}
+}
+
+// handleCreateFollow follows a public list or a user
+// Accepts a list URL (/list/@handle/rkey), a list AT URI, or a handle/DID
+func (h *FollowHandler) handleCreateFollow(w http.ResponseWriter, r *http.Request) {
+	sess, ok := session.GetSession(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	subject := strings.TrimSpace(r.FormValue("subject"))
+	if subject == "" {
+		http.Error(w, "Subject is required", http.StatusBadRequest)
+		return
+	}
+
+	follow, err := h.resolveSubject(r.Context(), subject)
+	if err != nil {
+		log.Printf("Failed to resolve follow subject %q: %v", subject, err)
+		http.Error(w, "List or user not found", http.StatusNotFound)
+		return
+	}
+	follow.Notify = r.FormValue("notify") == "true" || r.FormValue("notify") == "on"
+	follow.CreatedAt = time.Now().UTC()
+
+	if follow.Kind == models.FollowKindUser && follow.Subject == sess.DID {
+		http.Error(w, "You can't follow yourself", http.StatusBadRequest)
+		return
+	}
+
+	// Don't create duplicate follow records for the same subject
+	existing, err := h.fetchFollows(r.Context(), sess.PDS, sess.DID)
+	if err != nil {
+		log.Printf("Failed to list follows: %v", err)
+		http.Error(w, "Failed to create follow", http.StatusInternalServerError)
+		return
+	}
+	for _, f := range existing {
+		if f.Subject == follow.Subject {
+			h.respondFollowCreated(w, r, f)
+			return
+		}
+	}
+
+	record := buildFollowRecord(follow)
+
+	var output *atproto.RepoCreateRecord_Output
+	sess, err = h.listHandler.WithRetry(r.Context(), sess, func(s *bskyoauth.Session) error {
+		output, err = h.client.CreateRecord(r.Context(), s, FollowCollection, record)
+		return err
+	})
+
+	if err != nil {
+		log.Printf("Failed to create follow after retries: %v", err)
+		http.Error(w, "Failed to create follow", http.StatusInternalServerError)
+		return
+	}
+
+	// Update session
+	cookie, _ := r.Cookie("session_id")
+	if cookie != nil {
+		h.client.UpdateSession(cookie.Value, sess)
+	}
+
+	follow.URI = output.Uri
+	follow.RKey = extractRKey(output.Uri)
+
+	log.Printf("Follow created: %s (%s)", follow.Subject, follow.RKey)
+
+	h.respondFollowCreated(w, r, follow)
+}
+
+// respondFollowCreated returns the follow partial for HTMX, or redirects back for plain form posts
+func (h *FollowHandler) respondFollowCreated(w http.ResponseWriter, r *http.Request, follow *models.Follow) {
+	if r.Header.Get("HX-Request") == "" {
+		redirect := "/app"
+		if ref := r.Referer(); ref != "" {
+			if u, err := url.Parse(ref); err == nil && strings.HasPrefix(u.Path, "/list/") {
+				redirect = u.Path
+			}
+		}
+		http.Redirect(w, r, redirect, http.StatusSeeOther)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	Render(w, "follow-item.html", follow)
+}
+
+// handleDeleteFollow removes a follow record
+func (h *FollowHandler) handleDeleteFollow(w http.ResponseWriter, r *http.Request) {
+	sess, ok := session.GetSession(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	rkey := r.URL.Query().Get("rkey")
+	if rkey == "" {
+		http.Error(w, "rkey is required", http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	sess, err = h.listHandler.WithRetry(r.Context(), sess, func(s *bskyoauth.Session) error {
+		return h.client.DeleteRecord(r.Context(), s, FollowCollection, rkey)
+	})
+
+	if err != nil {
+		log.Printf("Failed to delete follow after retries: %v", err)
+		http.Error(w, "Failed to delete follow", http.StatusInternalServerError)
+		return
+	}
+
+	// Update session
+	cookie, _ := r.Cookie("session_id")
+	if cookie != nil {
+		h.client.UpdateSession(cookie.Value, sess)
+	}
+
+	log.Printf("Follow deleted: %s", rkey)
+	w.WriteHeader(http.StatusOK)
+}
+
+// ListFollows fetches the follow records of any user (no authentication, used by background jobs)
+func (h *FollowHandler) ListFollows(ctx context.Context, did string) ([]*models.Follow, error) {
+	ident, err := h.listHandler.lookupIdentity(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.fetchFollows(ctx, ident.PDSEndpoint(), ident.DID.String())
+}
+
+// FollowedLists returns the lists covered by a follow, with tasks resolved
+func (h *FollowHandler) FollowedLists(ctx context.Context, follow *models.Follow) ([]*models.TaskList, error) {
+	if follow.Kind == models.FollowKindUser {
+		return h.listHandler.FetchPublicLists(ctx, follow.Subject)
+	}
+
+	list, err := h.listHandler.FetchPublicListByURI(ctx, follow.Subject)
+	if err != nil {
+		return nil, err
+	}
+	return []*models.TaskList{list}, nil
+}
+
+// cachedFollowedLists is FollowedLists served from the public list cache, for
+// pages that are reloaded often. Background jobs use FollowedLists so they see
+// changes as soon as they happen.
+func (h *FollowHandler) cachedFollowedLists(ctx context.Context, follow *models.Follow) ([]*models.TaskList, error) {
+	if follow.Kind == models.FollowKindUser {
+		return h.listHandler.CachedPublicLists(ctx, follow.Subject)
+	}
+
+	list, err := h.cachedListByURI(ctx, follow.Subject)
+	if err != nil {
+		return nil, err
+	}
+	return []*models.TaskList{list}, nil
+}
+
+// cachedListByURI returns a public list from its AT URI via the public list cache
+func (h *FollowHandler) cachedListByURI(ctx context.Context, uri string) (*models.TaskList, error) {
+	parts := strings.Split(strings.TrimPrefix(uri, "at://"), "/")
+	if len(parts) != 3 || parts[1] != ListCollection {
+		return nil, fmt.Errorf("not a list URI: %s", uri)
+	}
+	return h.listHandler.CachedPublicList(ctx, parts[0], parts[2])
+}
+
+// forEachFollow calls fn for every follow, with at most feedWorkers calls
+// running at once, and returns when all are done
+func forEachFollow(follows []*models.Follow, fn func(int, *models.Follow)) {
+	slots := make(chan struct{}, feedWorkers)
+	var wg sync.WaitGroup
+	for i, follow := range follows {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, follow *models.Follow) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			fn(i, follow)
+		}(i, follow)
+	}
+	wg.Wait()
+}
+
+// fetchFollows reads follow records from a repository (follow records are public)
+func (h *FollowHandler) fetchFollows(ctx context.Context, pds, did string) ([]*models.Follow, error) {
+	records, err := listPublicRecords(ctx, pds, did, FollowCollection)
+	if err != nil {
+		return nil, err
+	}
+
+	follows := make([]*models.Follow, 0, len(records))
+	for _, record := range records {
+		follow := parseFollowRecord(record.Value)
+		if follow.Subject == "" {
+			continue
+		}
+		follow.URI = record.URI
+		follow.RKey = extractRKey(record.URI)
+		follows = append(follows, follow)
+	}
+
+	return follows, nil
+}
+
+// resolveSubject turns user input into a follow with a canonical subject
+func (h *FollowHandler) resolveSubject(ctx context.Context, subject string) (*models.Follow, error) {
+	// Public list URL, e.g. https://attodo.app/list/@alice.bsky.social/abc123
+	if idx := strings.Index(subject, "/list/"); idx >= 0 {
+		parts := strings.Split(strings.Trim(subject[idx+len("/list/"):], "/"), "/")
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("invalid list URL: %s", subject)
+		}
+		list, err := h.listHandler.FetchPublicList(ctx, strings.TrimPrefix(parts[0], "@"), parts[1])
+		if err != nil {
+			return nil, err
+		}
+		return &models.Follow{Subject: list.URI, Kind: models.FollowKindList, DisplayName: list.Name}, nil
+	}
+
+	// List AT URI
+	if strings.HasPrefix(subject, "at://") {
+		list, err := h.listHandler.FetchPublicListByURI(ctx, subject)
+		if err != nil {
+			return nil, err
+		}
+		return &models.Follow{Subject: list.URI, Kind: models.FollowKindList, DisplayName: list.Name}, nil
+	}
+
+	// Handle or DID
+	ident, err := h.listHandler.lookupIdentity(ctx, strings.TrimPrefix(subject, "@"))
+	if err != nil {
+		return nil, err
+	}
+	return &models.Follow{
+		Subject:     ident.DID.String(),
+		Kind:        models.FollowKindUser,
+		DisplayName: "@" + ident.Handle.String(),
+	}, nil
+}
+
+// resolveDisplayName fills in a human readable name for a follow, falling back to the subject
+func (h *FollowHandler) resolveDisplayName(ctx context.Context, follow *models.Follow) {
+	follow.DisplayName = follow.Subject
+
+	if follow.Kind == models.FollowKindUser {
+		if ident, err := h.listHandler.lookupIdentity(ctx, follow.Subject); err == nil {
+			follow.DisplayName = "@" + ident.Handle.String()
+		}
+		return
+	}
+
+	if list, err := h.cachedListByURI(ctx, follow.Subject); err == nil {
+		follow.DisplayName = list.Name + " by @" + list.OwnerHandle
+	}
+}
+
+func buildFollowRecord(follow *models.Follow) map[string]interface{} {
+	return map[string]interface{}{
+		"$type":     FollowCollection,
+		"subject":   follow.Subject,
+		"kind":      follow.Kind,
+		"notify":    follow.Notify,
+		"createdAt": follow.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func parseFollowRecord(value map[string]interface{}) *models.Follow {
+	follow := &models.Follow{Kind: models.FollowKindList}
+
+	if subject, ok := value["subject"].(string); ok {
+		follow.Subject = subject
+	}
+	if kind, ok := value["kind"].(string); ok && kind == models.FollowKindUser {
+		follow.Kind = models.FollowKindUser
+	}
+	if notify, ok := value["notify"].(bool); ok {
+		follow.Notify = notify
+	}
+	if createdAt, ok := value["createdAt"].(string); ok {
+		if t, err := time.Parse(time.RFC3339, createdAt); err == nil {
+			follow.CreatedAt = t
+		}
+	}
+
+	return follow
+}
@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/shindakun/attodo/internal/models"
+	"github.com/shindakun/attodo/internal/session"
+	"github.com/shindakun/bskyoauth"
+)
+
+const (
+	MaxContextsPerTask  = 5
+	MaxWaitingForLength = 100
+)
+
+// followUpHour is the local hour a follow-up date is due at
+const followUpHour = 9
+
+type GTDHandler struct {
+	client      *bskyoauth.Client
+	taskHandler *TaskHandler
+}
+
+func NewGTDHandler(client *bskyoauth.Client, taskHandler *TaskHandler) *GTDHandler {
+	return &GTDHandler{
+		client:      client,
+		taskHandler: taskHandler,
+	}
+}
+
+// HandleReview runs the guided weekly review
+// GET renders the review page at a step (?step=inbox, waiting, next or
+// someday); POST acts on one task with form fields rkey, step, action (next,
+// waiting, someday, keep or done) and, for waiting, waitingFor and followUp,
+// then returns the updated step
+func (h *GTDHandler) HandleReview(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.handleGetReview(w, r)
+	case http.MethodPost:
+		h.handleReviewAction(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleGetReview renders the weekly review page
+func (h *GTDHandler) handleGetReview(w http.ResponseWriter, r *http.Request) {
+	sess, ok := session.GetSession(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	tasks, err := fetchPublicTasks(r.Context(), sess.PDS, sess.DID)
+	if err != nil {
+		log.Printf("Failed to load tasks for review for %s: %v", sess.DID, err)
+		http.Error(w, "Failed to load your tasks", http.StatusInternalServerError)
+		return
+	}
+
+	data, err := reviewData(tasks, r.URL.Query().Get("step"), time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	Render(w, "review.html", data)
+}
+
+// handleReviewAction applies a review decision to a task
+func (h *GTDHandler) handleReviewAction(w http.ResponseWriter, r *http.Request) {
+	sess, ok := session.GetSession(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	rkey := r.FormValue("rkey")
+	if rkey == "" {
+		http.Error(w, "rkey is required", http.StatusBadRequest)
+		return
+	}
+
+	tasks, err := fetchPublicTasks(r.Context(), sess.PDS, sess.DID)
+	if err != nil {
+		log.Printf("Failed to load tasks for review for %s: %v", sess.DID, err)
+		http.Error(w, "Failed to load your tasks", http.StatusInternalServerError)
+		return
+	}
+
+	var task *models.Task
+	for _, t := range tasks {
+		if t.RKey == rkey {
+			task = t
+			break
+		}
+	}
+	if task == nil {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+
+	now := time.Now().UTC()
+	action := r.FormValue("action")
+	switch action {
+	case models.GTDNext, models.GTDSomeday:
+		task.SetState(action, now)
+	case models.GTDWaiting:
+		followUp, err := parseFollowUp(r.FormValue("followUp"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		task.SetWaiting(truncate(r.FormValue("waitingFor"), MaxWaitingForLength), followUp, now)
+	case "keep":
+		// Keeping a waiting-for task past its follow-up date means it was chased up
+		if task.IsFollowUpDue(now) {
+			followUp := now.AddDate(0, 0, models.DefaultFollowUpDays)
+			task.FollowUpAt = &followUp
+		}
+		task.MarkReviewed(now)
+	case "done":
+		task.SetCompleted(true, now)
+	default:
+		http.Error(w, "Unknown action", http.StatusBadRequest)
+		return
+	}
+
+	record := buildTaskRecord(task)
+	sess, err = h.taskHandler.withRetry(r.Context(), sess, func(s *bskyoauth.Session) error {
+		return h.taskHandler.updateRecord(r.Context(), s, rkey, record)
+	})
+	if err != nil {
+		log.Printf("Failed to save review of task %s: %v", rkey, err)
+		errMsg := getUserFriendlyError(err, "Failed to update task. Please try again.")
+		http.Error(w, errMsg, http.StatusInternalServerError)
+		return
+	}
+
+	// Update session
+	cookie, _ := r.Cookie("session_id")
+	if cookie != nil {
+		h.client.UpdateSession(cookie.Value, sess)
+	}
+
+	log.Printf("Task reviewed: %s (%s) for DID: %s", rkey, action, sess.DID)
+
+	if action == "done" && task.IsRecurring {
+		if err := h.taskHandler.handleRecurringTaskCompletion(r.Context(), sess, task); err != nil {
+			log.Printf("Warning: Failed to create next recurring instance: %v", err)
+		}
+	}
+
+	data, err := reviewData(tasks, r.FormValue("step"), time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	Render(w, "review-step", data)
+}
+
+// FetchFollowUps loads a user's open waiting-for tasks whose follow-up date
+// has passed, without a session, for the follow-up notifications
+func (h *GTDHandler) FetchFollowUps(ctx context.Context, did string, now time.Time) ([]*models.Task, error) {
+	pds, err := h.taskHandler.resolvePDSEndpoint(ctx, did)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve PDS endpoint: %w", err)
+	}
+
+	tasks, err := fetchPublicTasks(ctx, pds, did)
+	if err != nil {
+		return nil, err
+	}
+
+	due := make([]*models.Task, 0)
+	for _, task := range tasks {
+		if task.IsFollowUpDue(now) {
+			due = append(due, task)
+		}
+	}
+	return due, nil
+}
+
+// reviewData builds the review page data for a step (the first step if empty)
+func reviewData(tasks []*models.Task, stepID string, now time.Time) (map[string]interface{}, error) {
+	review := models.BuildWeeklyReview(tasks, now)
+	if stepID == "" {
+		stepID = models.ReviewStepIDs[0]
+	}
+	step := review.Step(stepID)
+	if step == nil {
+		return nil, fmt.Errorf("Unknown review step %q", stepID)
+	}
+
+	return map[string]interface{}{
+		"Review": review,
+		"Step":   step,
+		"Next":   review.NextStep(stepID),
+		"Now":    now,
+	}, nil
+}
+
+// fetchPublicTasks reads all of a user's tasks
+func fetchPublicTasks(ctx context.Context, pds, did string) ([]*models.Task, error) {
+	records, err := listPublicRecords(ctx, pds, did, TaskCollection)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	tasks := make([]*models.Task, 0, len(records))
+	for _, record := range records {
+		task := ParseTaskFields(record.Value)
+		task.URI = record.URI
+		task.RKey = extractRKey(record.URI)
+		tasks = append(tasks, &task)
+	}
+	return tasks, nil
+}
+
+// applyGTDForm sets a task's GTD fields from form fields state, contexts,
+// waitingFor and followUp (YYYY-MM-DD)
+func applyGTDForm(r *http.Request, task *models.Task, now time.Time) error {
+	state := r.FormValue("state")
+	if state == "" {
+		state = models.GTDInbox
+	}
+	if !models.IsGTDState(state) {
+		return fmt.Errorf("Unknown state %q", state)
+	}
+
+	task.Contexts = parseContexts(r.FormValue("contexts"))
+
+	switch {
+	case state == models.GTDWaiting:
+		followUp, err := parseFollowUp(r.FormValue("followUp"))
+		if err != nil {
+			return err
+		}
+		task.SetWaiting(truncate(r.FormValue("waitingFor"), MaxWaitingForLength), followUp, now)
+	case state != task.GTDState():
+		task.SetState(state, now)
+	}
+	return nil
+}
+
+// parseContexts parses "@home, phone" into unique "@name" contexts
+func parseContexts(input string) []string {
+	fields := strings.FieldsFunc(input, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+
+	contexts := make([]string, 0, len(fields))
+	seen := make(map[string]bool)
+	for _, field := range fields {
+		context := models.NormalizeContext(field)
+		if len(context) > MaxTagLength {
+			context = context[:MaxTagLength]
+		}
+		if context == "" || seen[context] {
+			continue
+		}
+		seen[context] = true
+		contexts = append(contexts, context)
+		if len(contexts) == MaxContextsPerTask {
+			break
+		}
+	}
+	return contexts
+}
+
+// parseFollowUp parses a YYYY-MM-DD follow-up date, due in the morning local
+// time; empty means the default follow-up
+func parseFollowUp(input string) (*time.Time, error) {
+	if input == "" {
+		return nil, nil
+	}
+	t, err := time.Parse("2006-01-02", input)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid follow-up date")
+	}
+	followUp := time.Date(t.Year(), t.Month(), t.Day(), followUpHour, 0, 0, 0, time.Local).UTC()
+	return &followUp, nil
+}
+
+// truncate trims s and cuts it to at most max bytes
+func truncate(s string, max int) string {
+	s = strings.TrimSpace(s)
+	if len(s) > max {
+		s = s[:max]
+	}
+	return s
+}
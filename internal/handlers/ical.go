@@ -87,8 +87,28 @@ func (h *ICalHandler) GenerateTasksFeed(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Optionally limit the feed to one of the user's saved views: ?view={rkey}
+	calendarName := fmt.Sprintf("AT Protocol Tasks - %s", sanitizeDID(did))
+	if rkey := r.URL.Query().Get("view"); rkey != "" {
+		pds, err := h.resolvePDSEndpoint(ctx, did)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to resolve PDS endpoint: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		view, err := fetchPublicView(ctx, pds, did, rkey)
+		if err != nil {
+			log.Printf("Failed to load view %s for tasks feed: %v", rkey, err)
+			writeViewError(w, err)
+			return
+		}
+
+		tasks = view.Apply(tasks)
+		calendarName = fmt.Sprintf("AT Todo - %s", view.Name)
+	}
+
 	// Generate iCal feed
-	ical := h.generateTasksICalendar(did, tasks)
+	ical := h.generateTasksICalendar(calendarName, tasks)
 
 	// Set headers for iCal feed
 	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
@@ -530,14 +550,14 @@ func (h *ICalHandler) fetchTasksForDID(ctx context.Context, did string) ([]*mode
 }
 
 // generateTasksICalendar generates an iCal format string from tasks
-func (h *ICalHandler) generateTasksICalendar(did string, tasks []*models.Task) string {
+func (h *ICalHandler) generateTasksICalendar(calendarName string, tasks []*models.Task) string {
 	var ical strings.Builder
 
 	// iCal header
 	ical.WriteString("BEGIN:VCALENDAR\r\n")
 	ical.WriteString("VERSION:2.0\r\n")
 	ical.WriteString("PRODID:-//AT Todo//Tasks Feed//EN\r\n")
-	ical.WriteString(fmt.Sprintf("X-WR-CALNAME:AT Protocol Tasks - %s\r\n", sanitizeDID(did)))
+	ical.WriteString(fmt.Sprintf("X-WR-CALNAME:%s\r\n", escapeICalText(calendarName)))
 	ical.WriteString("X-WR-TIMEZONE:UTC\r\n")
 	ical.WriteString("CALSCALE:GREGORIAN\r\n")
 	ical.WriteString("METHOD:PUBLISH\r\n")
@@ -582,6 +602,8 @@ func (h *ICalHandler) addTaskToICalendar(ical *strings.Builder, task *models.Tas
 		if task.CompletedAt != nil {
 			ical.WriteString(fmt.Sprintf("COMPLETED:%s\r\n", formatICalTime(*task.CompletedAt)))
 		}
+	} else if task.Status == models.StatusInProgress || task.Status == models.StatusReview {
+		ical.WriteString("STATUS:IN-PROCESS\r\n")
 	} else {
 		ical.WriteString("STATUS:NEEDS-ACTION\r\n")
 	}
@@ -677,6 +699,10 @@ func parseTaskFieldsForICal(record map[string]interface{}) models.Task {
 			}
 		}
 	}
+	// Parse workflow status if present
+	if status, ok := record["status"].(string); ok {
+		task.Status = status
+	}
 
 	return task
 }
@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/shindakun/attodo/internal/models"
+)
+
+// Public list representations served from /list/@handle/rkey
+const (
+	listFormatHTML     = "html"
+	listFormatJSON     = "json"
+	listFormatAtom     = "atom"
+	listFormatRSS      = "rss"
+	listFormatMarkdown = "md"
+	listFormatPNG      = "png" // OpenGraph preview image
+)
+
+const feedEntryLimit = 50 // Maximum number of entries in Atom/RSS feeds
+
+// listFormatFromPath splits an optional format extension off the rkey
+// e.g. "abc123.json" -> ("abc123", "json")
+func listFormatFromPath(rkey string) (string, string) {
+	for _, format := range []string{listFormatJSON, listFormatAtom, listFormatRSS, listFormatMarkdown, listFormatPNG} {
+		if strings.HasSuffix(rkey, "."+format) {
+			return strings.TrimSuffix(rkey, "."+format), format
+		}
+	}
+	return rkey, ""
+}
+
+// listFormatFromAccept picks a representation from the Accept header, defaulting to HTML
+func listFormatFromAccept(accept string) string {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		switch mediaType {
+		case "text/html", "application/xhtml+xml":
+			return listFormatHTML
+		case "application/json":
+			return listFormatJSON
+		case "application/atom+xml":
+			return listFormatAtom
+		case "application/rss+xml":
+			return listFormatRSS
+		case "text/markdown":
+			return listFormatMarkdown
+		}
+	}
+	return listFormatHTML
+}
+
+// publicListURL returns the absolute URL of a public list
+func publicListURL(list *models.TaskList) string {
+	base := ""
+	if appConfig != nil {
+		base = strings.TrimSuffix(appConfig.BaseURL, "/")
+	}
+	return fmt.Sprintf("%s/list/@%s/%s", base, list.OwnerHandle, list.RKey)
+}
+
+// writePublicList writes a public list in the requested format
+func writePublicList(w http.ResponseWriter, list *models.TaskList, format string) {
+	switch format {
+	case listFormatJSON:
+		writeListJSON(w, list)
+	case listFormatAtom:
+		writeListAtom(w, list)
+	case listFormatRSS:
+		writeListRSS(w, list)
+	case listFormatMarkdown:
+		writeListMarkdown(w, list)
+	default:
+		w.Header().Set("Content-Type", "text/html")
+		Render(w, "public-list.html", list)
+	}
+}
+
+// ===== JSON =====
+
+type publicListOwner struct {
+	DID    string `json:"did"`
+	Handle string `json:"handle"`
+}
+
+type publicListTask struct {
+	URI         string     `json:"uri"`
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	Completed   bool       `json:"completed"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	DueDate     *time.Time `json:"dueDate,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+}
+
+type publicListJSON struct {
+	URI         string           `json:"uri"`
+	CID         string           `json:"cid"`
+	URL         string           `json:"url"`
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Owner       publicListOwner  `json:"owner"`
+	Parent      string           `json:"parent,omitempty"`
+	CreatedAt   time.Time        `json:"createdAt"`
+	UpdatedAt   time.Time        `json:"updatedAt"`
+	Total       int              `json:"total"`
+	Completed   int              `json:"completed"`
+	Tasks       []publicListTask `json:"tasks"`
+}
+
+func writeListJSON(w http.ResponseWriter, list *models.TaskList) {
+	out := publicListJSON{
+		URI:         list.URI,
+		CID:         list.CID,
+		URL:         publicListURL(list),
+		Name:        list.Name,
+		Description: list.Description,
+		Owner:       publicListOwner{DID: list.OwnerDID, Handle: list.OwnerHandle},
+		Parent:      list.Parent,
+		CreatedAt:   list.CreatedAt,
+		UpdatedAt:   list.UpdatedAt,
+		Total:       len(list.Tasks),
+		Completed:   list.CompletedCount(),
+		Tasks:       make([]publicListTask, 0, len(list.Tasks)),
+	}
+
+	for _, task := range list.Tasks {
+		out.Tasks = append(out.Tasks, publicListTask{
+			URI:         task.URI,
+			Title:       task.Title,
+			Description: task.Description,
+			Completed:   task.Completed,
+			CreatedAt:   task.CreatedAt,
+			CompletedAt: task.CompletedAt,
+			DueDate:     task.DueDate,
+			Tags:        task.Tags,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+// ===== ATOM =====
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type atomEntry struct {
+	ID      string   `xml:"id"`
+	Title   string   `xml:"title"`
+	Updated string   `xml:"updated"`
+	Link    atomLink `xml:"link"`
+	Summary string   `xml:"summary,omitempty"`
+}
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Author   atomAuthor  `xml:"author"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+func writeListAtom(w http.ResponseWriter, list *models.TaskList) {
+	url := publicListURL(list)
+	items := models.BuildActivity([]*models.TaskList{list}, time.Time{}, feedEntryLimit)
+
+	updated := list.UpdatedAt
+	if len(items) > 0 && items[0].At.After(updated) {
+		updated = items[0].At
+	}
+
+	feed := atomFeed{
+		ID:       list.URI,
+		Title:    list.Name,
+		Subtitle: list.Description,
+		Updated:  updated.UTC().Format(time.RFC3339),
+		Author:   atomAuthor{Name: "@" + list.OwnerHandle, URI: "https://bsky.app/profile/" + list.OwnerHandle},
+		Links: []atomLink{
+			{Href: url, Rel: "alternate", Type: "text/html"},
+			{Href: url + ".atom", Rel: "self", Type: "application/atom+xml"},
+		},
+	}
+
+	for _, item := range items {
+		feed.Entries = append(feed.Entries, atomEntry{
+			ID:      item.Task.URI + "#" + item.Kind,
+			Title:   activityTitle(item),
+			Updated: item.At.UTC().Format(time.RFC3339),
+			Link:    atomLink{Href: url, Rel: "alternate", Type: "text/html"},
+			Summary: item.Task.Description,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	enc.Encode(feed)
+}
+
+// ===== RSS =====
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description,omitempty"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+func writeListRSS(w http.ResponseWriter, list *models.TaskList) {
+	url := publicListURL(list)
+	items := models.BuildActivity([]*models.TaskList{list}, time.Time{}, feedEntryLimit)
+
+	description := list.Description
+	if description == "" {
+		description = fmt.Sprintf("Task list by @%s on AT Todo", list.OwnerHandle)
+	}
+
+	feed := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         list.Name,
+			Link:          url,
+			Description:   description,
+			LastBuildDate: list.UpdatedAt.UTC().Format(time.RFC1123Z),
+		},
+	}
+
+	for _, item := range items {
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       activityTitle(item),
+			Link:        url,
+			Description: item.Task.Description,
+			GUID:        rssGUID{Value: item.Task.URI + "#" + item.Kind},
+			PubDate:     item.At.UTC().Format(time.RFC1123Z),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	enc.Encode(feed)
+}
+
+// activityTitle formats an activity item as a feed entry title
+func activityTitle(item *models.ActivityItem) string {
+	if item.Kind == models.ActivityTaskCompleted {
+		return "Completed: " + item.Task.Title
+	}
+	return "Added: " + item.Task.Title
+}
+
+// ===== MARKDOWN =====
+
+func writeListMarkdown(w http.ResponseWriter, list *models.TaskList) {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", list.Name)
+	if list.Description != "" {
+		fmt.Fprintf(&b, "%s\n\n", list.Description)
+	}
+	fmt.Fprintf(&b, "_%d/%d done · by @%s · %s_\n\n", list.CompletedCount(), len(list.Tasks), list.OwnerHandle, publicListURL(list))
+
+	for _, task := range list.Tasks {
+		check := " "
+		if task.Completed {
+			check = "x"
+		}
+		fmt.Fprintf(&b, "- [%s] %s", check, task.Title)
+		if task.DueDate != nil {
+			fmt.Fprintf(&b, " (due %s)", task.DueDate.UTC().Format("2006-01-02"))
+		}
+		for _, tag := range task.Tags {
+			fmt.Fprintf(&b, " #%s", tag)
+		}
+		b.WriteString("\n")
+	}
+
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Write([]byte(b.String()))
+}
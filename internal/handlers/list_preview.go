@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/shindakun/attodo/internal/preview"
+)
+
+const previewCacheMaxSize = 500
+
+// writePreviewImage serves the OpenGraph preview PNG for a public list
+// Rendered images are cached by list record CID and progress, so a card is only
+// redrawn when the list record or its completion count changes
+func (h *ListHandler) writePreviewImage(w http.ResponseWriter, r *http.Request, handle, rkey string) {
+	list, err := h.CachedPublicList(r.Context(), handle, rkey)
+	if err != nil {
+		log.Printf("Failed to get list for preview %s/%s: %v", handle, rkey, err)
+		writePublicListError(w, err)
+		return
+	}
+
+	completed := list.CompletedCount()
+	total := len(list.Tasks)
+	key := fmt.Sprintf("%s|%s|%d|%d", list.CID, list.OwnerHandle, completed, total)
+	etag := fmt.Sprintf("%q", key)
+
+	setPublicCacheHeaders(w)
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	h.cacheMu.RLock()
+	img, ok := h.previewCache[key]
+	h.cacheMu.RUnlock()
+
+	if !ok {
+		img, err = preview.RenderList(list.Name, list.OwnerHandle, completed, total)
+		if err != nil {
+			log.Printf("Failed to render preview for %s/%s: %v", handle, rkey, err)
+			http.Error(w, "Failed to render preview", http.StatusInternalServerError)
+			return
+		}
+
+		h.cacheMu.Lock()
+		if len(h.previewCache) >= previewCacheMaxSize {
+			h.previewCache = make(map[string][]byte)
+		}
+		h.previewCache[key] = img
+		h.cacheMu.Unlock()
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(img)
+}
@@ -3,11 +3,14 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/bluesky-social/indigo/api/atproto"
@@ -20,12 +23,38 @@ import (
 
 const ListCollection = "app.attodo.list"
 
+var (
+	errInvalidIdentifier = errors.New("invalid handle or DID")
+	errIdentityNotFound  = errors.New("handle or DID not found")
+	errInvalidParent     = errors.New("parent must be one of your lists")
+	errSmartListTasks    = errors.New("smart lists collect tasks from their rules and can't be edited by hand")
+	errRecordNotFound    = errors.New("record not found")
+	errSwapConflict      = errors.New("record was changed by another write")
+)
+
 type ListHandler struct {
 	client *bskyoauth.Client
+
+	// Cache of public lists for embeds and badges, and rendered preview images
+	publicCache  map[string]*cachedPublicList
+	previewCache map[string][]byte
+	cacheMu      sync.RWMutex
+
+	// Session IDs of recently active users (DID -> session ID), used by the
+	// background job that keeps materialized smart lists up to date. Only users
+	// who came through this instance are here, and since sessions themselves
+	// aren't persisted the map starts empty after a restart.
+	activeSessions map[string]string
+	sessionsMu     sync.Mutex
 }
 
 func NewListHandler(client *bskyoauth.Client) *ListHandler {
-	return &ListHandler{client: client}
+	return &ListHandler{
+		client:         client,
+		publicCache:    make(map[string]*cachedPublicList),
+		previewCache:   make(map[string][]byte),
+		activeSessions: make(map[string]string),
+	}
 }
 
 // HandleLists handles list CRUD operations
@@ -103,7 +132,28 @@ func (h *ListHandler) HandleListDetail(w http.ResponseWriter, r *http.Request) {
 			// Continue anyway, just with empty tasks
 		} else {
 			list.Tasks = tasks
+			list.OrderTasks()
+		}
+	}
+
+	// Place the list in the tree for breadcrumbs and sublist progress
+	var lists []*models.TaskList
+	sess, err = h.WithRetry(r.Context(), sess, func(s *bskyoauth.Session) error {
+		lists, err = h.ListRecords(r.Context(), s)
+		return err
+	})
+	if err != nil {
+		log.Printf("Failed to list lists for tree of %s: %v", rkey, err)
+	} else {
+		for i, l := range lists {
+			if l.URI == list.URI {
+				lists[i] = list
+			}
+		}
+		if err := h.loadListTasks(r.Context(), sess, lists); err != nil {
+			log.Printf("Failed to load tasks for sublists of %s: %v", rkey, err)
 		}
+		models.BuildListTree(lists)
 	}
 
 	// Update session
@@ -112,12 +162,19 @@ func (h *ListHandler) HandleListDetail(w http.ResponseWriter, r *http.Request) {
 		h.client.UpdateSession(cookie.Value, sess)
 	}
 
+	// Saved matches are refreshed by the background job, not on view
+	if list.IsSmart() && list.Materialize {
+		h.rememberSession(r, sess.DID)
+	}
+
 	// Render list detail view
 	w.Header().Set("Content-Type", "text/html")
 	Render(w, "list-detail.html", list)
 }
 
 // HandlePublicListView shows a public read-only view of a list
+// The list is also available as JSON, Atom, RSS or Markdown via an extension
+// (e.g., /list/@handle/abc123.atom) or the Accept header
 func (h *ListHandler) HandlePublicListView(w http.ResponseWriter, r *http.Request) {
 	// Extract handle and rkey from URL path (e.g., /list/@handle.bsky.social/abc123)
 	path := strings.TrimPrefix(r.URL.Path, "/list/")
@@ -129,62 +186,180 @@ func (h *ListHandler) HandlePublicListView(w http.ResponseWriter, r *http.Reques
 	}
 
 	handle := strings.TrimPrefix(parts[0], "@")
-	rkey := parts[1]
+	rkey, format := listFormatFromPath(parts[1])
 
 	if handle == "" || rkey == "" {
 		http.Error(w, "Handle and list ID required", http.StatusBadRequest)
 		return
 	}
 
-	// Resolve the handle to a DID
-	dir := identity.DefaultDirectory()
-	atid, err := syntax.ParseAtIdentifier(handle)
-	if err != nil {
-		log.Printf("Failed to parse handle %s: %v", handle, err)
-		http.Error(w, "Invalid handle", http.StatusBadRequest)
+	if format == "" {
+		format = listFormatFromAccept(r.Header.Get("Accept"))
+		w.Header().Set("Vary", "Accept")
+	}
+
+	// Social preview image (/list/@handle/rkey.png)
+	if format == listFormatPNG {
+		h.writePreviewImage(w, r, handle, rkey)
 		return
 	}
 
-	ident, err := dir.Lookup(r.Context(), *atid)
+	log.Printf("Fetching public list: handle=%s, rkey=%s", handle, rkey)
+
+	list, err := h.FetchPublicList(r.Context(), handle, rkey)
 	if err != nil {
-		log.Printf("Failed to resolve handle %s: %v", handle, err)
-		http.Error(w, "Handle not found", http.StatusNotFound)
+		log.Printf("Failed to get public list %s/%s: %v", handle, rkey, err)
+		writePublicListError(w, err)
 		return
 	}
 
-	did := ident.DID.String()
-	pds := ident.PDSEndpoint()
+	// Keep the handle as typed in the URL for display
+	list.OwnerHandle = handle
+
+	writePublicList(w, list, format)
+}
+
+// deleteClonedTasks removes the tasks a failed clone already created, so they
+// aren't left behind without a list
+func (h *ListHandler) deleteClonedTasks(ctx context.Context, sess *bskyoauth.Session, taskURIs []string) {
+	for _, uri := range taskURIs {
+		rkey := extractRKey(uri)
+		var err error
+		sess, err = h.WithRetry(ctx, sess, func(s *bskyoauth.Session) error {
+			return h.client.DeleteRecord(ctx, s, TaskCollection, rkey)
+		})
+		if err != nil {
+			log.Printf("Failed to delete cloned task %s after failed clone: %v", uri, err)
+		}
+	}
+}
+
+// HandleCloneList copies a public list and its tasks into the current user's repository
+func (h *ListHandler) HandleCloneList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sess, ok := session.GetSession(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
 
-	log.Printf("Fetching public list: handle=%s, did=%s, rkey=%s", handle, did, rkey)
+	sourceURI := r.FormValue("uri")
+	if sourceURI == "" {
+		http.Error(w, "uri is required", http.StatusBadRequest)
+		return
+	}
 
-	// Fetch the list record publicly
-	record, err := h.getPublicRecord(r.Context(), pds, did, rkey)
+	source, err := h.FetchPublicListByURI(r.Context(), sourceURI)
 	if err != nil {
-		log.Printf("Failed to get public list: %v", err)
+		log.Printf("Failed to fetch list to clone %s: %v", sourceURI, err)
 		http.Error(w, "List not found or not public", http.StatusNotFound)
 		return
 	}
 
-	// Parse list
-	list := parseListRecord(record)
-	list.RKey = rkey
-	list.URI = fmt.Sprintf("at://%s/%s/%s", did, ListCollection, rkey)
-	list.OwnerHandle = handle
+	// Tasks are copied fresh: not completed, created now
+	now := time.Now().UTC()
+	tasks := make([]*models.Task, 0, len(source.Tasks))
+	for _, t := range source.Tasks {
+		tasks = append(tasks, &models.Task{
+			Title:         t.Title,
+			Description:   t.Description,
+			CreatedAt:     now,
+			DueDate:       t.DueDate,
+			Tags:          t.Tags,
+			IsRecurring:   t.IsRecurring,
+			RecFrequency:  t.RecFrequency,
+			RecInterval:   t.RecInterval,
+			RecDaysOfWeek: t.RecDaysOfWeek,
+		})
+	}
+
+	// Optionally move due dates so the earliest one is today
+	if r.FormValue("shiftDates") == "true" || r.FormValue("shiftDates") == "on" {
+		loc := time.UTC
+		if tz := r.FormValue("timezone"); tz != "" {
+			if l, err := time.LoadLocation(tz); err == nil {
+				loc = l
+			}
+		}
+		days := models.ShiftDueDates(tasks, time.Now().In(loc))
+		log.Printf("Clone: shifted due dates by %d day(s)", days)
+	}
 
-	// Resolve tasks from URIs (public fetch)
-	if len(list.TaskURIs) > 0 {
-		tasks, err := h.resolvePublicTasksFromURIs(r.Context(), pds, did, list.TaskURIs)
+	taskURIs := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		record := buildTaskRecord(task)
+
+		var output *atproto.RepoCreateRecord_Output
+		sess, err = h.WithRetry(r.Context(), sess, func(s *bskyoauth.Session) error {
+			output, err = h.client.CreateRecord(r.Context(), s, TaskCollection, record)
+			return err
+		})
 		if err != nil {
-			log.Printf("Failed to resolve public tasks for list %s: %v", rkey, err)
-			// Continue anyway, just with empty tasks
-		} else {
-			list.Tasks = tasks
+			log.Printf("Failed to create cloned task %q after retries: %v", task.Title, err)
+			h.deleteClonedTasks(r.Context(), sess, taskURIs)
+			http.Error(w, "Failed to copy tasks", http.StatusInternalServerError)
+			return
 		}
+
+		taskURIs = append(taskURIs, output.Uri)
+	}
+
+	list := &models.TaskList{
+		Name:        source.Name,
+		Description: source.Description,
+		TaskURIs:    taskURIs,
+		Columns:     source.Columns,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Source: &models.StrongRef{
+			URI: source.URI,
+			CID: source.CID,
+		},
+	}
+
+	record := buildListRecord(list)
+
+	var output *atproto.RepoCreateRecord_Output
+	sess, err = h.WithRetry(r.Context(), sess, func(s *bskyoauth.Session) error {
+		output, err = h.client.CreateRecord(r.Context(), s, ListCollection, record)
+		return err
+	})
+
+	if err != nil {
+		log.Printf("Failed to create cloned list after retries: %v", err)
+		h.deleteClonedTasks(r.Context(), sess, taskURIs)
+		http.Error(w, "Failed to create list", http.StatusInternalServerError)
+		return
+	}
+
+	// Update session
+	cookie, _ := r.Cookie("session_id")
+	if cookie != nil {
+		h.client.UpdateSession(cookie.Value, sess)
+	}
+
+	list.RKey = extractRKey(output.Uri)
+	list.URI = output.Uri
+
+	log.Printf("List cloned: %s -> %s (%d tasks)", source.URI, list.URI, len(taskURIs))
+
+	// Plain form posts (from the public list page) go straight to the new list
+	if r.Header.Get("HX-Request") == "" {
+		http.Redirect(w, r, "/app/lists/view/"+list.RKey, http.StatusSeeOther)
+		return
 	}
 
-	// Render public list view
 	w.Header().Set("Content-Type", "text/html")
-	Render(w, "public-list.html", list)
+	Render(w, "list-item.html", list)
 }
 
 // handleCreateList creates a new list
@@ -213,16 +388,38 @@ func (h *ListHandler) handleCreateList(w http.ResponseWriter, r *http.Request) {
 		Name:        name,
 		Description: r.FormValue("description"),
 		TaskURIs:    []string{}, // Empty initially
+		Parent:      r.FormValue("parent"),
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
+	applySmartListForm(r, list)
+
+	// Nest under an existing list if requested
+	var err error
+	if list.Parent != "" {
+		sess, err = h.validateParent(r.Context(), sess, "", list.Parent)
+		if err != nil {
+			writeParentError(w, err)
+			return
+		}
+	}
+
+	// Resolve smart lists straight away for their count, and save the
+	// matches of materialized ones
+	if list.IsSmart() {
+		if err := h.loadListTasks(r.Context(), sess, []*models.TaskList{list}); err != nil {
+			log.Printf("Failed to load tasks for new smart list: %v", err)
+		}
+		if list.Materialize {
+			list.TaskURIs = taskURIs(list.Tasks)
+		}
+	}
 
 	// Build record
 	record := buildListRecord(list)
 
 	// Create the record with retry logic
 	var output *atproto.RepoCreateRecord_Output
-	var err error
 	sess, err = h.WithRetry(r.Context(), sess, func(s *bskyoauth.Session) error {
 		output, err = h.client.CreateRecord(r.Context(), s, ListCollection, record)
 		return err
@@ -246,6 +443,11 @@ func (h *ListHandler) handleCreateList(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("List created: %s (%s)", list.Name, list.RKey)
 
+	// Sublists need the whole tree re-rendered to land under their parent
+	if list.Parent != "" {
+		w.Header().Set("HX-Trigger", "reload-lists")
+	}
+
 	// Return the list partial for HTMX
 	w.Header().Set("Content-Type", "text/html")
 	Render(w, "list-item.html", list)
@@ -279,9 +481,16 @@ func (h *ListHandler) handleListLists(w http.ResponseWriter, r *http.Request) {
 		h.client.UpdateSession(cookie.Value, sess)
 	}
 
-	// Return HTML partials for HTMX
+	h.rememberSession(r, sess.DID)
+
+	// Resolve tasks so lists with sublists and smart lists can show progress
+	if err := h.loadListTasks(r.Context(), sess, lists); err != nil {
+		log.Printf("Failed to load tasks for list progress: %v", err)
+	}
+
+	// Return HTML partials for HTMX, nested lists directly below their parent
 	w.Header().Set("Content-Type", "text/html")
-	for _, list := range lists {
+	for _, list := range models.FlattenListTree(models.BuildListTree(lists)) {
 		Render(w, "list-item.html", list)
 	}
 }
@@ -341,6 +550,29 @@ func (h *ListHandler) handleUpdateList(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Update smart list rules if the form carried them
+	if _, ok := r.Form["smartRules"]; ok {
+		applySmartListForm(r, list)
+		if list.IsSmart() {
+			if err := h.loadListTasks(r.Context(), sess, []*models.TaskList{list}); err != nil {
+				log.Printf("Failed to load tasks for smart list %s: %v", rkey, err)
+			} else if list.Materialize {
+				list.TaskURIs = taskURIs(list.Tasks)
+			}
+		}
+	}
+
+	// Move the list if a parent was submitted (empty moves it to the top level)
+	if _, ok := r.Form["parent"]; ok && r.FormValue("parent") != list.Parent {
+		parent := r.FormValue("parent")
+		sess, err = h.validateParent(r.Context(), sess, list.URI, parent)
+		if err != nil {
+			writeParentError(w, err)
+			return
+		}
+		list.Parent = parent
+	}
+
 	// Build record and update
 	updatedRecord := buildListRecord(list)
 	sess, err = h.WithRetry(r.Context(), sess, func(s *bskyoauth.Session) error {
@@ -361,6 +593,9 @@ func (h *ListHandler) handleUpdateList(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("List updated: %s", rkey)
 
+	// Re-render the tree so nesting and rolled up progress stay accurate
+	w.Header().Set("HX-Trigger", "reload-lists")
+
 	// Return updated list partial
 	w.Header().Set("Content-Type", "text/html")
 	Render(w, "list-item.html", list)
@@ -408,6 +643,11 @@ func (h *ListHandler) handleManageTasks(w http.ResponseWriter, r *http.Request)
 	list.RKey = rkey
 	list.URI = fmt.Sprintf("at://%s/%s/%s", sess.DID, ListCollection, rkey)
 
+	if list.IsSmart() {
+		http.Error(w, errSmartListTasks.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Modify task URIs based on action
 	switch action {
 	case "add":
@@ -528,8 +768,17 @@ func (h *ListHandler) handleDeleteList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Delete with retry logic
+	// Move sublists up a level first so they aren't left pointing at a deleted list
 	var err error
+	var moved int
+	sess, moved, err = h.reparentChildren(r.Context(), sess, fmt.Sprintf("at://%s/%s/%s", sess.DID, ListCollection, rkey))
+	if err != nil {
+		log.Printf("Failed to move sublists of %s: %v", rkey, err)
+		http.Error(w, "Failed to move sublists, list not deleted", http.StatusInternalServerError)
+		return
+	}
+
+	// Delete with retry logic
 	sess, err = h.WithRetry(r.Context(), sess, func(s *bskyoauth.Session) error {
 		return h.client.DeleteRecord(r.Context(), s, ListCollection, rkey)
 	})
@@ -546,10 +795,88 @@ func (h *ListHandler) handleDeleteList(w http.ResponseWriter, r *http.Request) {
 		h.client.UpdateSession(cookie.Value, sess)
 	}
 
-	log.Printf("List deleted: %s", rkey)
+	log.Printf("List deleted: %s (%d sublists moved up)", rkey, moved)
+
+	if moved > 0 {
+		w.Header().Set("HX-Trigger", "reload-lists")
+	}
 	w.WriteHeader(http.StatusOK)
 }
 
+// validateParent checks that parentURI is one of the user's lists and that
+// nesting childURI under it keeps the tree valid (childURI is empty for new lists)
+func (h *ListHandler) validateParent(ctx context.Context, sess *bskyoauth.Session, childURI, parentURI string) (*bskyoauth.Session, error) {
+	if parentURI == "" {
+		return sess, nil
+	}
+	if !strings.HasPrefix(parentURI, fmt.Sprintf("at://%s/%s/", sess.DID, ListCollection)) {
+		return sess, errInvalidParent
+	}
+
+	var lists []*models.TaskList
+	var err error
+	sess, err = h.WithRetry(ctx, sess, func(s *bskyoauth.Session) error {
+		lists, err = h.ListRecords(ctx, s)
+		return err
+	})
+	if err != nil {
+		return sess, fmt.Errorf("failed to list lists: %w", err)
+	}
+
+	found := false
+	for _, list := range lists {
+		if list.URI == parentURI {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return sess, errInvalidParent
+	}
+
+	return sess, models.ValidateParent(lists, childURI, parentURI)
+}
+
+// reparentChildren moves the direct sublists of a list to that list's own parent
+// Returns the number of sublists moved
+func (h *ListHandler) reparentChildren(ctx context.Context, sess *bskyoauth.Session, uri string) (*bskyoauth.Session, int, error) {
+	var lists []*models.TaskList
+	var err error
+	sess, err = h.WithRetry(ctx, sess, func(s *bskyoauth.Session) error {
+		lists, err = h.ListRecords(ctx, s)
+		return err
+	})
+	if err != nil {
+		return sess, 0, fmt.Errorf("failed to list lists: %w", err)
+	}
+
+	moved := 0
+	for _, list := range models.ReparentChildren(lists, uri) {
+		list.UpdatedAt = time.Now().UTC()
+		record := buildListRecord(list)
+
+		sess, err = h.WithRetry(ctx, sess, func(s *bskyoauth.Session) error {
+			return h.updateRecord(ctx, s, list.RKey, record)
+		})
+		if err != nil {
+			return sess, moved, fmt.Errorf("failed to move list %s: %w", list.RKey, err)
+		}
+		moved++
+	}
+
+	return sess, moved, nil
+}
+
+// writeParentError maps list nesting errors to HTTP responses
+func writeParentError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errInvalidParent) || errors.Is(err, models.ErrListCycle) || errors.Is(err, models.ErrListTooDeep) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Printf("Failed to validate list parent: %v", err)
+	http.Error(w, "Failed to update list", http.StatusInternalServerError)
+}
+
 // listRecords fetches all lists from the repository
 // ListRecords fetches all list records for the given session (public for cross-handler access)
 func (h *ListHandler) ListRecords(ctx context.Context, sess *bskyoauth.Session) ([]*models.TaskList, error) {
@@ -656,6 +983,12 @@ func (h *ListHandler) getRecord(ctx context.Context, sess *bskyoauth.Session, rk
 
 // updateRecord updates a record using com.atproto.repo.putRecord
 func (h *ListHandler) updateRecord(ctx context.Context, sess *bskyoauth.Session, rkey string, record map[string]interface{}) error {
+	return h.swapRecord(ctx, sess, rkey, record, "")
+}
+
+// swapRecord updates a record only if it still has the CID it was read at,
+// see putRepoRecord
+func (h *ListHandler) swapRecord(ctx context.Context, sess *bskyoauth.Session, rkey string, record map[string]interface{}, swapCID string) error {
 	log.Printf("updateRecord: DID=%s, Collection=%s, RKey=%s", sess.DID, ListCollection, rkey)
 
 	// Resolve the actual PDS endpoint for this user
@@ -670,24 +1003,39 @@ func (h *ListHandler) updateRecord(ctx context.Context, sess *bskyoauth.Session,
 		record["$type"] = ListCollection
 	}
 
-	// Build the request body
+	output, err := putRepoRecord(ctx, sess, pdsHost, ListCollection, rkey, record, swapCID)
+	if err != nil {
+		log.Printf("updateRecord: %v", err)
+		return err
+	}
+
+	log.Printf("updateRecord: Success! URI=%s", output.Uri)
+	return nil
+}
+
+// putRepoRecord writes a record using com.atproto.repo.putRecord. A non-empty
+// swapCID makes the write conditional: if the record no longer has that CID
+// because another device wrote it in between, errSwapConflict is returned and
+// the caller should re-read and re-apply its change.
+func putRepoRecord(ctx context.Context, sess *bskyoauth.Session, pdsHost, collection, rkey string, record map[string]interface{}, swapCID string) (*atproto.RepoPutRecord_Output, error) {
 	body := map[string]interface{}{
 		"repo":       sess.DID,
-		"collection": ListCollection,
+		"collection": collection,
 		"rkey":       rkey,
 		"record":     record,
 	}
+	if swapCID != "" {
+		body["swapRecord"] = swapCID
+	}
 
 	bodyJSON, err := json.Marshal(body)
 	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Create the request to the resolved PDS endpoint
-	url := fmt.Sprintf("%s/xrpc/com.atproto.repo.putRecord", pdsHost)
-	req, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(bodyJSON)))
+	req, err := http.NewRequestWithContext(ctx, "POST", pdsHost+"/xrpc/com.atproto.repo.putRecord", strings.NewReader(string(bodyJSON)))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -707,23 +1055,33 @@ func (h *ListHandler) updateRecord(ctx context.Context, sess *bskyoauth.Session,
 
 	resp, err := httpClient.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		log.Printf("updateRecord: HTTP %d: %s", resp.StatusCode, string(bodyBytes))
-		return fmt.Errorf("XRPC ERROR %d: %s", resp.StatusCode, string(bodyBytes))
+		// Not wrapped with the status: WithRetry would take the 400 for an
+		// expired token and repeat the stale swap
+		if resp.StatusCode == http.StatusBadRequest && isInvalidSwap(bodyBytes) {
+			return nil, errSwapConflict
+		}
+		return nil, fmt.Errorf("XRPC ERROR %d: %s", resp.StatusCode, string(bodyBytes))
 	}
 
 	var output atproto.RepoPutRecord_Output
 	if err := json.NewDecoder(resp.Body).Decode(&output); err != nil {
-		return fmt.Errorf("failed to decode response: %w", err)
+		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
+	return &output, nil
+}
 
-	log.Printf("updateRecord: Success! URI=%s", output.Uri)
-	return nil
+// isInvalidSwap reports whether an XRPC error body is an InvalidSwap error
+func isInvalidSwap(body []byte) bool {
+	var xrpcErr struct {
+		Error string `json:"error"`
+	}
+	return json.Unmarshal(body, &xrpcErr) == nil && xrpcErr.Error == "InvalidSwap"
 }
 
 // resolvePDSEndpoint resolves the PDS endpoint for a given DID
@@ -871,6 +1229,35 @@ func parseTaskRecord(record map[string]interface{}) *models.Task {
 			}
 		}
 	}
+	if status, ok := record["status"].(string); ok {
+		task.Status = status
+	}
+	// Parse GTD fields if present
+	if state, ok := record["state"].(string); ok {
+		task.State = state
+	}
+	if contexts, ok := record["contexts"].([]interface{}); ok {
+		task.Contexts = make([]string, 0, len(contexts))
+		for _, context := range contexts {
+			if contextStr, ok := context.(string); ok {
+				task.Contexts = append(task.Contexts, contextStr)
+			}
+		}
+	}
+	if waitingFor, ok := record["waitingFor"].(string); ok {
+		task.WaitingFor = waitingFor
+	}
+	if followUpAt, ok := record["followUpAt"].(string); ok {
+		if t, err := time.Parse(time.RFC3339, followUpAt); err == nil {
+			task.FollowUpAt = &t
+		}
+	}
+	if reviewedAt, ok := record["reviewedAt"].(string); ok {
+		if t, err := time.Parse(time.RFC3339, reviewedAt); err == nil {
+			task.ReviewedAt = &t
+		}
+	}
+	task.Reminders = parseReminderRecords(record)
 
 	return task
 }
@@ -911,7 +1298,7 @@ func (h *ListHandler) WithRetry(ctx context.Context, sess *bskyoauth.Session, fn
 // Helper functions for record building/parsing
 
 func buildListRecord(list *models.TaskList) map[string]interface{} {
-	return map[string]interface{}{
+	record := map[string]interface{}{
 		"$type":       ListCollection,
 		"name":        list.Name,
 		"description": list.Description,
@@ -919,6 +1306,63 @@ func buildListRecord(list *models.TaskList) map[string]interface{} {
 		"createdAt":   list.CreatedAt.Format(time.RFC3339),
 		"updatedAt":   list.UpdatedAt.Format(time.RFC3339),
 	}
+
+	// Nested lists point at their parent
+	if list.Parent != "" {
+		record["parent"] = list.Parent
+	}
+
+	// Smart list rules
+	if list.Filter != nil {
+		filter := map[string]interface{}{}
+		if list.Filter.Status != "" {
+			filter["status"] = list.Filter.Status
+		}
+		if len(list.Filter.Tags) > 0 {
+			filter["tags"] = list.Filter.Tags
+		}
+		if list.Filter.Due != "" {
+			filter["due"] = list.Filter.Due
+		}
+		if list.Filter.Text != "" {
+			filter["text"] = list.Filter.Text
+		}
+		if list.Filter.State != "" {
+			filter["state"] = list.Filter.State
+		}
+		if list.Filter.Context != "" {
+			filter["context"] = list.Filter.Context
+		}
+		record["filter"] = filter
+		record["materialize"] = list.Materialize
+	}
+
+	// Manual task order
+	if len(list.Positions) > 0 {
+		record["order"] = buildPositionItems(list.Positions)
+	}
+
+	// Custom board columns
+	if len(list.Columns) > 0 {
+		columns := make([]interface{}, 0, len(list.Columns))
+		for _, column := range list.Columns {
+			columns = append(columns, map[string]interface{}{
+				"id":   column.ID,
+				"name": column.Name,
+			})
+		}
+		record["columns"] = columns
+	}
+
+	// Keep provenance for cloned lists
+	if list.Source != nil {
+		record["source"] = map[string]interface{}{
+			"uri": list.Source.URI,
+			"cid": list.Source.CID,
+		}
+	}
+
+	return record
 }
 
 func parseListRecord(value map[string]interface{}) *models.TaskList {
@@ -948,12 +1392,59 @@ func parseListRecord(value map[string]interface{}) *models.TaskList {
 			list.UpdatedAt = t
 		}
 	}
+	if parent, ok := value["parent"].(string); ok {
+		list.Parent = parent
+	}
+	if filter, ok := value["filter"].(map[string]interface{}); ok {
+		list.Filter = &models.TaskFilter{}
+		list.Filter.Status, _ = filter["status"].(string)
+		list.Filter.Due, _ = filter["due"].(string)
+		list.Filter.Text, _ = filter["text"].(string)
+		list.Filter.State, _ = filter["state"].(string)
+		list.Filter.Context, _ = filter["context"].(string)
+		if tags, ok := filter["tags"].([]interface{}); ok {
+			for _, tag := range tags {
+				if tagStr, ok := tag.(string); ok {
+					list.Filter.Tags = append(list.Filter.Tags, tagStr)
+				}
+			}
+		}
+	}
+	if materialize, ok := value["materialize"].(bool); ok {
+		list.Materialize = materialize
+	}
+	if order, ok := value["order"].([]interface{}); ok {
+		list.Positions = parsePositionItems(order)
+	}
+	if columns, ok := value["columns"].([]interface{}); ok {
+		for _, item := range columns {
+			entry, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			column := models.BoardColumn{}
+			column.ID, _ = entry["id"].(string)
+			column.Name, _ = entry["name"].(string)
+			if column.ID != "" {
+				list.Columns = append(list.Columns, column)
+			}
+		}
+	}
+	if source, ok := value["source"].(map[string]interface{}); ok {
+		ref := &models.StrongRef{}
+		ref.URI, _ = source["uri"].(string)
+		ref.CID, _ = source["cid"].(string)
+		if ref.URI != "" {
+			list.Source = ref
+		}
+	}
 
 	return list
 }
 
 // getPublicRecord retrieves a list record publicly (no authentication)
-func (h *ListHandler) getPublicRecord(ctx context.Context, pds, did, rkey string) (map[string]interface{}, error) {
+// Returns the record value and its CID
+func (h *ListHandler) getPublicRecord(ctx context.Context, pds, did, rkey string) (map[string]interface{}, string, error) {
 	// Build the XRPC URL
 	url := fmt.Sprintf("%s/xrpc/com.atproto.repo.getRecord?repo=%s&collection=%s&rkey=%s",
 		pds, did, ListCollection, rkey)
@@ -961,19 +1452,19 @@ func (h *ListHandler) getPublicRecord(ctx context.Context, pds, did, rkey string
 	// Create request (no authentication for public access)
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	client := &http.Client{Timeout: 10 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("XRPC ERROR %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, "", fmt.Errorf("XRPC ERROR %d: %s", resp.StatusCode, string(bodyBytes))
 	}
 
 	// Parse response
@@ -984,10 +1475,10 @@ func (h *ListHandler) getPublicRecord(ctx context.Context, pds, did, rkey string
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return nil, "", fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return result.Value, nil
+	return result.Value, result.CID, nil
 }
 
 // resolvePublicTasksFromURIs fetches task records publicly (no authentication)
@@ -1067,3 +1558,258 @@ func (h *ListHandler) getPublicTaskRecord(ctx context.Context, pds, did, rkey st
 
 	return result.Value, nil
 }
+
+// lookupIdentity resolves a handle or DID to its identity (DID, handle, PDS)
+func (h *ListHandler) lookupIdentity(ctx context.Context, identifier string) (*identity.Identity, error) {
+	atid, err := syntax.ParseAtIdentifier(identifier)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidIdentifier, err)
+	}
+
+	ident, err := identity.DefaultDirectory().Lookup(ctx, *atid)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errIdentityNotFound, err)
+	}
+
+	return ident, nil
+}
+
+// FetchPublicList resolves a handle or DID and fetches one of its lists with tasks (no authentication)
+func (h *ListHandler) FetchPublicList(ctx context.Context, identifier, rkey string) (*models.TaskList, error) {
+	ident, err := h.lookupIdentity(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	did := ident.DID.String()
+	pds := ident.PDSEndpoint()
+
+	record, cid, err := h.getPublicRecord(ctx, pds, did, rkey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get public list: %w", err)
+	}
+
+	list := parseListRecord(record)
+	list.RKey = rkey
+	list.URI = fmt.Sprintf("at://%s/%s/%s", did, ListCollection, rkey)
+	list.CID = cid
+	list.OwnerDID = did
+	list.OwnerHandle = ident.Handle.String()
+
+	// Smart lists are worked out from their rules, whether or not their
+	// matches are saved to the record
+	if list.IsSmart() {
+		taskRecords, err := listPublicRecords(ctx, pds, did, TaskCollection)
+		if err != nil {
+			log.Printf("Failed to list public tasks for smart list %s: %v", rkey, err)
+		} else {
+			attachTasks([]*models.TaskList{list}, taskRecords)
+		}
+		return list, nil
+	}
+
+	// Resolve tasks from URIs (public fetch)
+	if len(list.TaskURIs) > 0 {
+		tasks, err := h.resolvePublicTasksFromURIs(ctx, pds, did, list.TaskURIs)
+		if err != nil {
+			log.Printf("Failed to resolve public tasks for list %s: %v", rkey, err)
+			// Continue anyway, just with empty tasks
+		} else {
+			list.Tasks = tasks
+			list.OrderTasks()
+		}
+	}
+
+	return list, nil
+}
+
+// FetchPublicListByURI fetches a public list from its AT URI
+// Example: at://did:plc:xxx/app.attodo.list/abc123
+func (h *ListHandler) FetchPublicListByURI(ctx context.Context, uri string) (*models.TaskList, error) {
+	parts := strings.Split(strings.TrimPrefix(uri, "at://"), "/")
+	if len(parts) != 3 || parts[1] != ListCollection {
+		return nil, fmt.Errorf("not a list URI: %s", uri)
+	}
+
+	return h.FetchPublicList(ctx, parts[0], parts[2])
+}
+
+// FetchPublicLists fetches every list owned by a handle or DID, with tasks resolved (no authentication)
+func (h *ListHandler) FetchPublicLists(ctx context.Context, identifier string) ([]*models.TaskList, error) {
+	ident, err := h.lookupIdentity(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	did := ident.DID.String()
+	pds := ident.PDSEndpoint()
+
+	listRecords, err := listPublicRecords(ctx, pds, did, ListCollection)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list public lists: %w", err)
+	}
+
+	// Fetch all tasks once rather than one getRecord per URI
+	taskRecords, err := listPublicRecords(ctx, pds, did, TaskCollection)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list public tasks: %w", err)
+	}
+
+	lists := make([]*models.TaskList, 0, len(listRecords))
+	for _, record := range listRecords {
+		list := parseListRecord(record.Value)
+		list.URI = record.URI
+		list.RKey = extractRKey(record.URI)
+		list.CID = record.CID
+		list.OwnerDID = did
+		list.OwnerHandle = ident.Handle.String()
+		lists = append(lists, list)
+	}
+
+	attachTasks(lists, taskRecords)
+
+	return lists, nil
+}
+
+// attachTasks resolves each list's task URIs against a set of task records
+func attachTasks(lists []*models.TaskList, taskRecords []publicRecord) {
+	tasksByURI := make(map[string]*models.Task, len(taskRecords))
+	for _, record := range taskRecords {
+		task := parseTaskRecord(record.Value)
+		task.URI = record.URI
+		task.RKey = extractRKey(record.URI)
+		tasksByURI[record.URI] = task
+	}
+
+	for _, list := range lists {
+		list.Tasks = nil
+
+		// Smart lists select from every task, in repository order
+		if list.IsSmart() {
+			for _, record := range taskRecords {
+				if task := tasksByURI[record.URI]; list.Filter.Matches(task) {
+					list.Tasks = append(list.Tasks, task)
+				}
+			}
+			continue
+		}
+
+		for _, uri := range list.TaskURIs {
+			if task, ok := tasksByURI[uri]; ok {
+				list.Tasks = append(list.Tasks, task)
+			}
+		}
+	}
+
+	for _, list := range lists {
+		list.OrderTasks()
+	}
+}
+
+// loadListTasks resolves the tasks of every list with a single listRecords pass
+// over the user's tasks, rather than one getRecord per task URI
+func (h *ListHandler) loadListTasks(ctx context.Context, sess *bskyoauth.Session, lists []*models.TaskList) error {
+	taskRecords, err := listPublicRecords(ctx, sess.PDS, sess.DID, TaskCollection)
+	if err != nil {
+		return fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	attachTasks(lists, taskRecords)
+	return nil
+}
+
+// publicRecord is a single entry from com.atproto.repo.listRecords
+type publicRecord struct {
+	URI   string                 `json:"uri"`
+	CID   string                 `json:"cid"`
+	Value map[string]interface{} `json:"value"`
+}
+
+// listPublicRecords fetches every record in a collection publicly, following cursors
+func listPublicRecords(ctx context.Context, pds, did, collection string) ([]publicRecord, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	records := make([]publicRecord, 0)
+	cursor := ""
+
+	for {
+		query := url.Values{
+			"repo":       {did},
+			"collection": {collection},
+			"limit":      {"100"},
+		}
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "GET", pds+"/xrpc/com.atproto.repo.listRecords?"+query.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return nil, fmt.Errorf("XRPC ERROR %d: %s", resp.StatusCode, string(bodyBytes))
+		}
+
+		var result struct {
+			Records []publicRecord `json:"records"`
+			Cursor  string         `json:"cursor"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		records = append(records, result.Records...)
+
+		if result.Cursor == "" || len(result.Records) == 0 {
+			break
+		}
+		cursor = result.Cursor
+	}
+
+	return records, nil
+}
+
+// getPublicRecord fetches a single record publicly; a missing record is errRecordNotFound
+func getPublicRecord(ctx context.Context, pds, did, collection, rkey string) (*publicRecord, error) {
+	query := url.Values{
+		"repo":       {did},
+		"collection": {collection},
+		"rkey":       {rkey},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", pds+"/xrpc/com.atproto.repo.getRecord?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// PDSes report a missing record as 400 RecordNotFound
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusBadRequest {
+		return nil, errRecordNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("XRPC ERROR %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var record publicRecord
+	if err := json.NewDecoder(resp.Body).Decode(&record); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &record, nil
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"log"
+
+	"github.com/shindakun/attodo/internal/database"
+	"github.com/shindakun/attodo/internal/models"
+)
+
+// enableNotifications turns on background notifications for the user,
+// creating their notification record if needed
+func enableNotifications(repo *database.NotificationRepo, did string) error {
+	user, err := repo.GetNotificationUser(did)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return repo.CreateNotificationUser(&models.NotificationUser{DID: did, NotificationsEnabled: true})
+	}
+	if !user.NotificationsEnabled {
+		user.NotificationsEnabled = true
+		return repo.UpdateNotificationUser(user)
+	}
+	return nil
+}
+
+// disableIfUnreachable turns off background notifications for a user left
+// with no channel that can reach them. Every channel calls it after the user
+// removes or turns off one of its targets.
+func disableIfUnreachable(repo *database.NotificationRepo, did string) {
+	user, err := repo.GetNotificationUser(did)
+	if err != nil {
+		log.Printf("Failed to get notification user: %v", err)
+		return
+	}
+	if user == nil || !user.NotificationsEnabled || isReachable(repo, user) {
+		return
+	}
+
+	user.NotificationsEnabled = false
+	if err := repo.UpdateNotificationUser(user); err != nil {
+		log.Printf("Failed to disable notifications: %v", err)
+	}
+}
+
+// isReachable reports whether any channel can still reach the user: a push
+// subscription, an active email address, an active webhook or Bluesky DMs.
+// When that can't be checked the user counts as reachable, so a database
+// error never turns notifications off.
+func isReachable(repo *database.NotificationRepo, user *models.NotificationUser) bool {
+	if user.BlueskyDMEnabled {
+		return true
+	}
+
+	subs, err := repo.GetPushSubscriptionsByDID(user.DID)
+	if err != nil {
+		log.Printf("Failed to check remaining subscriptions: %v", err)
+		return true
+	}
+	if len(subs) > 0 {
+		return true
+	}
+
+	addr, err := repo.GetEmailAddress(user.DID)
+	if err != nil {
+		log.Printf("Failed to get email address: %v", err)
+		return true
+	}
+	if addr != nil && addr.Active() {
+		return true
+	}
+
+	hooks, err := repo.GetWebhooksByDID(user.DID)
+	if err != nil {
+		log.Printf("Failed to get webhooks: %v", err)
+		return true
+	}
+	for _, hook := range hooks {
+		if hook.Active() {
+			return true
+		}
+	}
+	return false
+}
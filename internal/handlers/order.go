@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/shindakun/attodo/internal/models"
+	"github.com/shindakun/attodo/internal/session"
+	"github.com/shindakun/bskyoauth"
+)
+
+const (
+	OrderCollection = "app.attodo.order"
+	InboxOrderRKey  = "inbox" // Single record holding the main inbox order
+
+	// maxSwapAttempts bounds how often a move is re-applied when another
+	// device keeps changing the same record
+	maxSwapAttempts = 3
+)
+
+// HandleListOrder moves a task within a list's manual order
+// Form fields: rkey (list), task (moved task URI), prev and next (URIs of the
+// tasks now directly above and below it, empty at either end)
+func (h *ListHandler) HandleListOrder(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sess, ok := session.GetSession(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	rkey := r.FormValue("rkey")
+	taskURI := r.FormValue("task")
+	if rkey == "" || taskURI == "" {
+		http.Error(w, "rkey and task are required", http.StatusBadRequest)
+		return
+	}
+
+	// Always start from the latest record and write it back only if it is
+	// unchanged; if another device moved a task in between, re-apply this
+	// move on top of theirs
+	for attempt := 1; ; attempt++ {
+		current, err := getPublicRecord(r.Context(), sess.PDS, sess.DID, ListCollection, rkey)
+		if err != nil {
+			log.Printf("Failed to get list %s for reorder: %v", rkey, err)
+			http.Error(w, "List not found", http.StatusNotFound)
+			return
+		}
+
+		list := parseListRecord(current.Value)
+		list.RKey = rkey
+		list.URI = fmt.Sprintf("at://%s/%s/%s", sess.DID, ListCollection, rkey)
+
+		// Smart lists order their current matches
+		uris := list.TaskURIs
+		if list.IsSmart() {
+			if err := h.loadListTasks(r.Context(), sess, []*models.TaskList{list}); err != nil {
+				log.Printf("Failed to load smart list %s for reorder: %v", rkey, err)
+				http.Error(w, "Failed to reorder tasks", http.StatusInternalServerError)
+				return
+			}
+			uris = taskURIs(list.Tasks)
+		}
+
+		if slices.Index(uris, taskURI) < 0 {
+			http.Error(w, "Task is not in this list", http.StatusBadRequest)
+			return
+		}
+
+		if list.Positions == nil {
+			list.Positions = make(map[string]string)
+		}
+		if err := models.MovePosition(uris, list.Positions, taskURI, r.FormValue("prev"), r.FormValue("next")); err != nil {
+			log.Printf("Failed to move task in list %s: %v", rkey, err)
+			http.Error(w, "Failed to reorder tasks", http.StatusInternalServerError)
+			return
+		}
+
+		list.UpdatedAt = time.Now().UTC()
+		record := buildListRecord(list)
+
+		sess, err = h.WithRetry(r.Context(), sess, func(s *bskyoauth.Session) error {
+			return h.swapRecord(r.Context(), s, rkey, record, current.CID)
+		})
+		if errors.Is(err, errSwapConflict) && attempt < maxSwapAttempts {
+			log.Printf("List %s changed during reorder, retrying", rkey)
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to save order of list %s: %v", rkey, err)
+			http.Error(w, "Failed to reorder tasks", http.StatusInternalServerError)
+			return
+		}
+		break
+	}
+
+	// Update session
+	cookie, _ := r.Cookie("session_id")
+	if cookie != nil {
+		h.client.UpdateSession(cookie.Value, sess)
+	}
+
+	log.Printf("Task %s moved in list %s", taskURI, rkey)
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandleTaskOrder moves a task within the manual order of the main inbox
+// (incomplete tasks). Form fields: task, prev and next, as for HandleListOrder
+func (h *TaskHandler) HandleTaskOrder(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sess, ok := session.GetSession(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	taskURI := r.FormValue("task")
+	if taskURI == "" {
+		http.Error(w, "task is required", http.StatusBadRequest)
+		return
+	}
+
+	var tasks []models.Task
+	var err error
+	sess, err = h.withRetry(r.Context(), sess, func(s *bskyoauth.Session) error {
+		tasks, err = h.listRecords(r.Context(), s)
+		return err
+	})
+	if err != nil {
+		log.Printf("Failed to list tasks for reorder: %v", err)
+		http.Error(w, "Failed to reorder tasks", http.StatusInternalServerError)
+		return
+	}
+
+	// The inbox holds incomplete tasks, newest first until placed
+	inbox := make([]models.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if !task.Completed {
+			inbox = append(inbox, task)
+		}
+	}
+	sortTasks(inbox, "")
+
+	uris := make([]string, 0, len(inbox))
+	for _, task := range inbox {
+		uris = append(uris, task.URI)
+	}
+	if slices.Index(uris, taskURI) < 0 {
+		http.Error(w, "Task not found", http.StatusBadRequest)
+		return
+	}
+
+	pdsHost, err := h.resolvePDSEndpoint(r.Context(), sess.DID)
+	if err != nil {
+		log.Printf("Failed to resolve PDS endpoint: %v", err)
+		http.Error(w, "Failed to reorder tasks", http.StatusInternalServerError)
+		return
+	}
+
+	// As for lists, write only over the order that was read and re-apply the
+	// move if another device changed it in between
+	for attempt := 1; ; attempt++ {
+		positions, cid, err := fetchInboxOrder(r.Context(), sess.PDS, sess.DID)
+		if err != nil {
+			log.Printf("Failed to get inbox order: %v", err)
+			http.Error(w, "Failed to reorder tasks", http.StatusInternalServerError)
+			return
+		}
+
+		if err := models.MovePosition(uris, positions, taskURI, r.FormValue("prev"), r.FormValue("next")); err != nil {
+			log.Printf("Failed to move task in inbox: %v", err)
+			http.Error(w, "Failed to reorder tasks", http.StatusInternalServerError)
+			return
+		}
+
+		record := map[string]interface{}{
+			"$type":     OrderCollection,
+			"items":     buildPositionItems(positions),
+			"updatedAt": time.Now().UTC().Format(time.RFC3339),
+		}
+
+		sess, err = h.withRetry(r.Context(), sess, func(s *bskyoauth.Session) error {
+			_, err := putRepoRecord(r.Context(), s, pdsHost, OrderCollection, InboxOrderRKey, record, cid)
+			return err
+		})
+		if errors.Is(err, errSwapConflict) && attempt < maxSwapAttempts {
+			log.Printf("Inbox order changed during reorder, retrying")
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to save inbox order: %v", err)
+			http.Error(w, "Failed to reorder tasks", http.StatusInternalServerError)
+			return
+		}
+		break
+	}
+
+	// Update session
+	cookie, _ := r.Cookie("session_id")
+	if cookie != nil {
+		h.client.UpdateSession(cookie.Value, sess)
+	}
+
+	log.Printf("Task %s moved in inbox for DID: %s", taskURI, sess.DID)
+	w.WriteHeader(http.StatusOK)
+}
+
+// orderTasksByPosition sorts tasks into a manual order, see models.OrderByPosition
+func orderTasksByPosition(tasks []models.Task, positions map[string]string) {
+	if len(positions) == 0 {
+		return
+	}
+
+	byURI := make(map[string]models.Task, len(tasks))
+	uris := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		byURI[task.URI] = task
+		uris = append(uris, task.URI)
+	}
+
+	for i, uri := range models.OrderByPosition(uris, positions) {
+		tasks[i] = byURI[uri]
+	}
+}
+
+// fetchInboxOrder reads the inbox order record and its CID; a missing record
+// is an empty order with no CID
+func fetchInboxOrder(ctx context.Context, pds, did string) (map[string]string, string, error) {
+	record, err := getPublicRecord(ctx, pds, did, OrderCollection, InboxOrderRKey)
+	if errors.Is(err, errRecordNotFound) {
+		return make(map[string]string), "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	items, _ := record.Value["items"].([]interface{})
+	return parsePositionItems(items), record.CID, nil
+}
+
+// buildPositionItems converts positions to record items, sorted by position
+func buildPositionItems(positions map[string]string) []interface{} {
+	uris := make([]string, 0, len(positions))
+	for uri := range positions {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	uris = models.OrderByPosition(uris, positions)
+
+	items := make([]interface{}, 0, len(uris))
+	for _, uri := range uris {
+		items = append(items, map[string]interface{}{
+			"uri":      uri,
+			"position": positions[uri],
+		})
+	}
+	return items
+}
+
+// parsePositionItems reads record items into a task URI -> position map
+func parsePositionItems(items []interface{}) map[string]string {
+	positions := make(map[string]string, len(items))
+	for _, item := range items {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		uri, _ := entry["uri"].(string)
+		position, _ := entry["position"].(string)
+		if uri != "" && position != "" {
+			positions[uri] = position
+		}
+	}
+	return positions
+}
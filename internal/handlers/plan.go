@@ -0,0 +1,353 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/shindakun/attodo/internal/models"
+	"github.com/shindakun/attodo/internal/session"
+	"github.com/shindakun/bskyoauth"
+)
+
+const PlanCollection = "app.attodo.plan"
+
+// recentPlansLimit is how many of the latest plans are checked for one to carry over from
+const recentPlansLimit = 5
+
+type PlanHandler struct {
+	client      *bskyoauth.Client
+	taskHandler *TaskHandler
+}
+
+func NewPlanHandler(client *bskyoauth.Client, taskHandler *TaskHandler) *PlanHandler {
+	return &PlanHandler{
+		client:      client,
+		taskHandler: taskHandler,
+	}
+}
+
+// HandleToday shows and edits the day plan ("Today")
+// GET renders the planning panel; POST changes the plan with form fields
+// date (YYYY-MM-DD, the user's local date), action (add, remove, carry or
+// skip) and task (task URI, for add and remove)
+func (h *PlanHandler) HandleToday(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.handleGetToday(w, r)
+	case http.MethodPost:
+		h.handleUpdateToday(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleGetToday renders the plan for a day with the tasks to pick from
+func (h *PlanHandler) handleGetToday(w http.ResponseWriter, r *http.Request) {
+	sess, ok := session.GetSession(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	date, err := planDateParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tasks, plan, previous, err := loadDayPlan(r.Context(), sess.PDS, sess.DID, date)
+	if err != nil {
+		log.Printf("Failed to load plan %s for %s: %v", date, sess.DID, err)
+		http.Error(w, "Failed to load today's plan", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	Render(w, "today.html", models.BuildDayPlanning(tasks, plan, previous))
+}
+
+// handleUpdateToday adds or removes a planned task, or answers the carry-over prompt
+func (h *PlanHandler) handleUpdateToday(w http.ResponseWriter, r *http.Request) {
+	sess, ok := session.GetSession(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	date, err := planDateParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tasks, plan, previous, err := loadDayPlan(r.Context(), sess.PDS, sess.DID, date)
+	if err != nil {
+		log.Printf("Failed to load plan %s for %s: %v", date, sess.DID, err)
+		http.Error(w, "Failed to update today's plan", http.StatusInternalServerError)
+		return
+	}
+
+	action := r.FormValue("action")
+	taskURI := r.FormValue("task")
+	ownTask := strings.HasPrefix(taskURI, fmt.Sprintf("at://%s/%s/", sess.DID, TaskCollection))
+
+	switch action {
+	case "add":
+		if !ownTask {
+			http.Error(w, "Unknown task", http.StatusBadRequest)
+			return
+		}
+		plan.Add(taskURI)
+	case "remove":
+		plan.Remove(taskURI)
+	case "carry":
+		planning := models.BuildDayPlanning(tasks, plan, previous)
+		for _, task := range planning.CarryOver {
+			plan.Add(task.URI)
+		}
+		plan.CarriedOver = true
+	case "skip":
+		plan.CarriedOver = true
+	default:
+		http.Error(w, "Unknown action", http.StatusBadRequest)
+		return
+	}
+
+	plan.UpdatedAt = time.Now().UTC()
+	record := buildPlanRecord(plan)
+
+	sess, err = h.taskHandler.withRetry(r.Context(), sess, func(s *bskyoauth.Session) error {
+		return h.putRecord(r.Context(), s, date, record)
+	})
+	if err != nil {
+		log.Printf("Failed to save plan %s: %v", date, err)
+		errMsg := getUserFriendlyError(err, "Failed to update today's plan. Please try again.")
+		http.Error(w, errMsg, http.StatusInternalServerError)
+		return
+	}
+
+	// Update session
+	cookie, _ := r.Cookie("session_id")
+	if cookie != nil {
+		h.client.UpdateSession(cookie.Value, sess)
+	}
+
+	log.Printf("Plan %s updated (%s) for DID: %s", date, action, sess.DID)
+
+	resolvePlan(plan, tasks)
+	w.Header().Set("Content-Type", "text/html")
+	Render(w, "today.html", models.BuildDayPlanning(tasks, plan, previous))
+}
+
+// FetchDayPlanning loads a user's plan for a date without a session, for the
+// planning nudges
+func (h *PlanHandler) FetchDayPlanning(ctx context.Context, did, date string) (*models.DayPlanning, error) {
+	pds, err := h.taskHandler.resolvePDSEndpoint(ctx, did)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve PDS endpoint: %w", err)
+	}
+
+	tasks, plan, previous, err := loadDayPlan(ctx, pds, did, date)
+	if err != nil {
+		return nil, err
+	}
+	return models.BuildDayPlanning(tasks, plan, previous), nil
+}
+
+// planDateParam reads the date parameter, defaulting to the server's today
+func planDateParam(r *http.Request) (string, error) {
+	date := r.FormValue("date")
+	if date == "" {
+		return models.PlanDate(time.Now(), time.Local), nil
+	}
+	if _, err := models.ParsePlanDate(date); err != nil {
+		return "", err
+	}
+	return date, nil
+}
+
+// loadDayPlan reads the user's tasks, the plan for date (a new empty plan if
+// there is none yet) and the latest earlier plan, with their tasks resolved
+func loadDayPlan(ctx context.Context, pds, did, date string) ([]*models.Task, *models.DayPlan, *models.DayPlan, error) {
+	tasks, err := fetchPublicTasks(ctx, pds, did)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	plans, err := fetchRecentPlans(ctx, pds, did)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to list plans: %w", err)
+	}
+
+	var plan, previous *models.DayPlan
+	for _, p := range plans {
+		switch {
+		case p.Date == date:
+			plan = p
+		case p.Date < date && (previous == nil || p.Date > previous.Date):
+			previous = p
+		}
+	}
+
+	// Plans for other days than the latest few (e.g. reopening an old day)
+	if plan == nil {
+		plan, err = fetchPlan(ctx, pds, did, date)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if plan == nil {
+		plan = models.NewDayPlan(date, time.Now().UTC())
+		plan.URI = fmt.Sprintf("at://%s/%s/%s", did, PlanCollection, date)
+	}
+
+	resolvePlan(plan, tasks)
+	if previous != nil {
+		resolvePlan(previous, tasks)
+	}
+
+	return tasks, plan, previous, nil
+}
+
+// resolvePlan fills a plan's tasks from the user's tasks
+func resolvePlan(plan *models.DayPlan, tasks []*models.Task) {
+	byURI := make(map[string]*models.Task, len(tasks))
+	for _, task := range tasks {
+		byURI[task.URI] = task
+	}
+	plan.Resolve(byURI)
+}
+
+// fetchRecentPlans reads the latest plans; listRecords returns the highest
+// record keys (the latest dates) first
+func fetchRecentPlans(ctx context.Context, pds, did string) ([]*models.DayPlan, error) {
+	url := fmt.Sprintf("%s/xrpc/com.atproto.repo.listRecords?repo=%s&collection=%s&limit=%d",
+		pds, did, PlanCollection, recentPlansLimit)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("XRPC ERROR %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var result struct {
+		Records []publicRecord `json:"records"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	plans := make([]*models.DayPlan, 0, len(result.Records))
+	for _, record := range result.Records {
+		plan := parsePlanRecord(record.Value)
+		plan.URI = record.URI
+		plan.RKey = extractRKey(record.URI)
+		if plan.Date == "" {
+			plan.Date = plan.RKey
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+// fetchPlan reads the plan for a date; a missing plan is nil
+func fetchPlan(ctx context.Context, pds, did, date string) (*models.DayPlan, error) {
+	record, err := getPublicRecord(ctx, pds, did, PlanCollection, date)
+	if errors.Is(err, errRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	plan := parsePlanRecord(record.Value)
+	plan.URI = record.URI
+	plan.RKey = date
+	plan.Date = date
+	return plan, nil
+}
+
+// putRecord writes a plan record using com.atproto.repo.putRecord
+func (h *PlanHandler) putRecord(ctx context.Context, sess *bskyoauth.Session, rkey string, record map[string]interface{}) error {
+	pdsHost, err := h.taskHandler.resolvePDSEndpoint(ctx, sess.DID)
+	if err != nil {
+		return fmt.Errorf("failed to resolve PDS endpoint: %w", err)
+	}
+
+	_, err = putRepoRecord(ctx, sess, pdsHost, PlanCollection, rkey, record, "")
+	return err
+}
+
+// buildPlanRecord creates a plan record map from a DayPlan
+func buildPlanRecord(plan *models.DayPlan) map[string]interface{} {
+	taskURIs := plan.TaskURIs
+	if taskURIs == nil {
+		taskURIs = []string{}
+	}
+
+	record := map[string]interface{}{
+		"$type":     PlanCollection,
+		"date":      plan.Date,
+		"taskUris":  taskURIs,
+		"createdAt": plan.CreatedAt.Format(time.RFC3339),
+		"updatedAt": plan.UpdatedAt.Format(time.RFC3339),
+	}
+	if plan.CarriedOver {
+		record["carriedOver"] = true
+	}
+	return record
+}
+
+// parsePlanRecord parses a plan record from AT Protocol
+func parsePlanRecord(value map[string]interface{}) *models.DayPlan {
+	plan := &models.DayPlan{TaskURIs: make([]string, 0)}
+
+	if date, ok := value["date"].(string); ok {
+		plan.Date = date
+	}
+	if uris, ok := value["taskUris"].([]interface{}); ok {
+		for _, uri := range uris {
+			if s, ok := uri.(string); ok {
+				plan.TaskURIs = append(plan.TaskURIs, s)
+			}
+		}
+	}
+	if carried, ok := value["carriedOver"].(bool); ok {
+		plan.CarriedOver = carried
+	}
+	if createdAt, ok := value["createdAt"].(string); ok {
+		if t, err := time.Parse(time.RFC3339, createdAt); err == nil {
+			plan.CreatedAt = t
+		}
+	}
+	if updatedAt, ok := value["updatedAt"].(string); ok {
+		if t, err := time.Parse(time.RFC3339, updatedAt); err == nil {
+			plan.UpdatedAt = t
+		}
+	}
+
+	return plan
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shindakun/attodo/internal/models"
+	"github.com/shindakun/attodo/internal/supporter"
+)
+
+var errSettingsNotFound = errors.New("settings record not found")
+
+// ProfileHandler serves public user profile pages
+type ProfileHandler struct {
+	listHandler      *ListHandler
+	supporterService *supporter.Service
+}
+
+func NewProfileHandler(listHandler *ListHandler, supporterService *supporter.Service) *ProfileHandler {
+	return &ProfileHandler{
+		listHandler:      listHandler,
+		supporterService: supporterService,
+	}
+}
+
+// PublicProfile is the data rendered on a user's profile page
+type PublicProfile struct {
+	DID       string
+	Handle    string
+	Supporter bool
+	Lists     []*models.TaskList
+}
+
+// CompletedCount returns the number of completed tasks across all lists
+func (p *PublicProfile) CompletedCount() int {
+	count := 0
+	for _, list := range p.Lists {
+		count += list.CompletedCount()
+	}
+	return count
+}
+
+// TaskCount returns the number of tasks across all lists
+func (p *PublicProfile) TaskCount() int {
+	count := 0
+	for _, list := range p.Lists {
+		count += len(list.Tasks)
+	}
+	return count
+}
+
+// HandleProfile shows a user's published lists with progress
+// Also available as JSON via /u/@handle.json or the Accept header
+// Example: /u/@alice.bsky.social
+func (h *ProfileHandler) HandleProfile(w http.ResponseWriter, r *http.Request) {
+	handle := strings.Trim(strings.TrimPrefix(r.URL.Path, "/u/"), "/")
+	handle = strings.TrimPrefix(handle, "@")
+
+	format := ""
+	if strings.HasSuffix(handle, "."+listFormatJSON) {
+		handle = strings.TrimSuffix(handle, "."+listFormatJSON)
+		format = listFormatJSON
+	}
+
+	if handle == "" || strings.Contains(handle, "/") {
+		http.Error(w, "Invalid profile URL format. Expected: /u/@handle", http.StatusBadRequest)
+		return
+	}
+
+	if format == "" {
+		format = listFormatFromAccept(r.Header.Get("Accept"))
+		w.Header().Set("Vary", "Accept")
+	}
+
+	profile, err := h.fetchProfile(r.Context(), handle)
+	if err != nil {
+		log.Printf("Failed to get profile for %s: %v", handle, err)
+		switch {
+		case errors.Is(err, errInvalidIdentifier):
+			http.Error(w, "Invalid handle", http.StatusBadRequest)
+		case errors.Is(err, errIdentityNotFound):
+			http.Error(w, "Handle not found", http.StatusNotFound)
+		default:
+			http.Error(w, "Failed to load profile", http.StatusBadGateway)
+		}
+		return
+	}
+	if profile == nil {
+		http.Error(w, "Profile not found", http.StatusNotFound)
+		return
+	}
+
+	setPublicCacheHeaders(w)
+
+	if format == listFormatJSON {
+		writeProfileJSON(w, profile)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	Render(w, "profile.html", profile)
+}
+
+// fetchProfile resolves a handle and collects its lists
+// Returns nil if the user has hidden their profile
+func (h *ProfileHandler) fetchProfile(ctx context.Context, handle string) (*PublicProfile, error) {
+	ident, err := h.listHandler.lookupIdentity(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	did := ident.DID.String()
+
+	// Respect the owner's opt-out before reading any lists
+	settings, err := fetchPublicSettings(ctx, ident.PDSEndpoint(), did)
+	if err != nil && !errors.Is(err, errSettingsNotFound) {
+		return nil, fmt.Errorf("failed to get settings: %w", err)
+	}
+	if settings != nil && settings.HideProfile {
+		return nil, nil
+	}
+
+	lists, err := h.listHandler.FetchPublicLists(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+
+	// Most recently updated lists first
+	sort.SliceStable(lists, func(i, j int) bool {
+		return lists[i].UpdatedAt.After(lists[j].UpdatedAt)
+	})
+
+	// Keep the handle as typed in the URL for display
+	for _, list := range lists {
+		list.OwnerHandle = handle
+	}
+
+	profile := &PublicProfile{
+		DID:    did,
+		Handle: handle,
+		Lists:  lists,
+	}
+
+	if h.supporterService != nil {
+		isSupporter, err := h.supporterService.IsSupporter(did)
+		if err != nil {
+			log.Printf("Failed to check supporter status for %s: %v", did, err)
+		}
+		profile.Supporter = isSupporter
+	}
+
+	return profile, nil
+}
+
+// fetchPublicSettings reads a user's settings record without authentication
+func fetchPublicSettings(ctx context.Context, pds, did string) (*models.NotificationSettings, error) {
+	record, err := getPublicRecord(ctx, pds, did, SettingsCollection, SettingsRKey)
+	if errors.Is(err, errRecordNotFound) {
+		return nil, errSettingsNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseSettingsRecord(record.Value), nil
+}
+
+// ===== JSON =====
+
+type publicProfileList struct {
+	URI         string    `json:"uri"`
+	URL         string    `json:"url"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+	Total       int       `json:"total"`
+	Completed   int       `json:"completed"`
+}
+
+type publicProfileJSON struct {
+	DID       string              `json:"did"`
+	Handle    string              `json:"handle"`
+	URL       string              `json:"url"`
+	Supporter bool                `json:"supporter"`
+	Lists     []publicProfileList `json:"lists"`
+}
+
+func writeProfileJSON(w http.ResponseWriter, profile *PublicProfile) {
+	base := ""
+	if appConfig != nil {
+		base = strings.TrimSuffix(appConfig.BaseURL, "/")
+	}
+
+	out := publicProfileJSON{
+		DID:       profile.DID,
+		Handle:    profile.Handle,
+		URL:       fmt.Sprintf("%s/u/@%s", base, profile.Handle),
+		Supporter: profile.Supporter,
+		Lists:     make([]publicProfileList, 0, len(profile.Lists)),
+	}
+
+	for _, list := range profile.Lists {
+		out.Lists = append(out.Lists, publicProfileList{
+			URI:         list.URI,
+			URL:         publicListURL(list),
+			Name:        list.Name,
+			Description: list.Description,
+			UpdatedAt:   list.UpdatedAt,
+			Total:       len(list.Tasks),
+			Completed:   list.CompletedCount(),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
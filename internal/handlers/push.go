@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 
@@ -228,22 +229,8 @@ func (h *PushHandler) HandleUnsubscribe(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Check if user has any remaining subscriptions
-	subs, err := h.repo.GetPushSubscriptionsByDID(sess.DID)
-	if err != nil {
-		log.Printf("Failed to check remaining subscriptions: %v", err)
-	} else if len(subs) == 0 {
-		// No more subscriptions, disable notifications
-		user, err := h.repo.GetNotificationUser(sess.DID)
-		if err != nil {
-			log.Printf("Failed to get notification user: %v", err)
-		} else if user != nil {
-			user.NotificationsEnabled = false
-			if err := h.repo.UpdateNotificationUser(user); err != nil {
-				log.Printf("Failed to disable notifications: %v", err)
-			}
-		}
-	}
+	// Without any channel left, stop checking for notifications
+	disableIfUnreachable(h.repo, sess.DID)
 
 	log.Printf("Push subscription deleted for DID: %s", sess.DID)
 
@@ -342,8 +329,24 @@ func (h *PushHandler) HandleTestNotification(w http.ResponseWriter, r *http.Requ
 		},
 	}
 
-	// Send to all subscriptions
-	successCount, errors := h.sender.SendToAll(subs, notification)
+	// Send to all subscriptions, removing any the push service says are gone
+	var errors []error
+	successCount := 0
+	for _, sub := range subs {
+		err := h.sender.Send(r.Context(), sub, notification)
+		switch {
+		case err == nil:
+			successCount++
+		case push.IsGone(err):
+			log.Printf("Removing expired subscription %s for DID: %s", sub.Endpoint, sess.DID)
+			if err := h.repo.DeletePushSubscription(sub.Endpoint); err != nil {
+				log.Printf("Failed to remove expired subscription: %v", err)
+			}
+			errors = append(errors, fmt.Errorf("subscription expired and was removed: %s", sub.Endpoint))
+		default:
+			errors = append(errors, fmt.Errorf("failed to send to %s: %w", sub.Endpoint, err))
+		}
+	}
 
 	log.Printf("Test notification sent to %d/%d subscriptions for DID: %s", successCount, len(subs), sess.DID)
 
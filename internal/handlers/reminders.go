@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shindakun/attodo/internal/dateparse"
+	"github.com/shindakun/attodo/internal/models"
+)
+
+const MaxRemindersPerTask = 5
+
+// ReminderScheduler is told when a user's reminders may have changed, so new
+// reminders are armed without waiting for its next run
+type ReminderScheduler interface {
+	Reschedule(did string)
+}
+
+// FetchTasks reads all of a user's tasks without a session, for the reminder
+// scheduler
+func (h *TaskHandler) FetchTasks(ctx context.Context, did string) ([]*models.Task, error) {
+	pds, err := h.resolvePDSEndpoint(ctx, did)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve PDS endpoint: %w", err)
+	}
+	return fetchPublicTasks(ctx, pds, did)
+}
+
+// rescheduleReminders tells the reminder scheduler about a task with reminders
+func (h *TaskHandler) rescheduleReminders(did string, task *models.Task) {
+	if h.reminders != nil && len(task.Reminders) > 0 {
+		h.reminders.Reschedule(did)
+	}
+}
+
+// reminderModels converts parsed reminders, rounding offsets to minutes
+func reminderModels(parsed []dateparse.Reminder) []models.Reminder {
+	reminders := make([]models.Reminder, 0, len(parsed))
+	for _, p := range parsed {
+		if p.At != nil {
+			at := p.At.UTC()
+			reminders = append(reminders, models.Reminder{At: &at})
+		} else {
+			reminders = append(reminders, models.Reminder{MinutesBefore: int(p.Before / time.Minute)})
+		}
+	}
+	return reminders
+}
+
+// parseRemindersInput parses a comma-separated reminders field, e.g. "30m
+// before, friday at 9am"
+func parseRemindersInput(input string, now time.Time) ([]models.Reminder, error) {
+	parsed := make([]dateparse.Reminder, 0)
+	for _, part := range strings.Split(input, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		reminder, ok := dateparse.ParseReminder(part, now)
+		if !ok {
+			return nil, fmt.Errorf("Couldn't understand the reminder %q. Try \"30m before\" or \"friday at 9am\".", part)
+		}
+		parsed = append(parsed, reminder)
+	}
+	return reminderModels(parsed), nil
+}
+
+// validateReminders checks a task's reminders against its due date
+func validateReminders(task *models.Task) error {
+	if len(task.Reminders) > MaxRemindersPerTask {
+		return fmt.Errorf("A task can have at most %d reminders", MaxRemindersPerTask)
+	}
+	for _, reminder := range task.Reminders {
+		if reminder.At == nil && task.DueDate == nil {
+			return fmt.Errorf("Reminders before the due date need a due date. Please set a due date.")
+		}
+	}
+	return nil
+}
+
+// parseReminderRecords reads the reminders array of a task record
+func parseReminderRecords(record map[string]interface{}) []models.Reminder {
+	values, ok := record["reminders"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	reminders := make([]models.Reminder, 0, len(values))
+	for _, value := range values {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if at, ok := fields["at"].(string); ok {
+			if t, err := time.Parse(time.RFC3339, at); err == nil {
+				reminders = append(reminders, models.Reminder{At: &t})
+			}
+		} else if minutes, ok := fields["minutesBefore"].(float64); ok && minutes > 0 {
+			reminders = append(reminders, models.Reminder{MinutesBefore: int(minutes)})
+		}
+	}
+	return reminders
+}
+
+// buildReminderRecords builds the reminders array of a task record
+func buildReminderRecords(reminders []models.Reminder) []map[string]interface{} {
+	records := make([]map[string]interface{}, 0, len(reminders))
+	for _, reminder := range reminders {
+		if reminder.At != nil {
+			records = append(records, map[string]interface{}{"at": reminder.At.Format(time.RFC3339)})
+		} else {
+			records = append(records, map[string]interface{}{"minutesBefore": reminder.MinutesBefore})
+		}
+	}
+	return records
+}
@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"bytes"
+	"fmt"
 	"html/template"
 	"log"
 	"net/http"
 	"strings"
+	texttemplate "text/template"
 	"time"
 
 	"github.com/shindakun/attodo/internal/config"
@@ -12,6 +15,7 @@ import (
 )
 
 var templates *template.Template
+var emailTexts *texttemplate.Template
 var appConfig *config.Config
 
 func InitTemplates(cfg *config.Config) error {
@@ -96,6 +100,12 @@ func InitTemplates(cfg *config.Config) error {
 		template.New("").Funcs(funcMap).ParseGlob("templates/*.html"),
 	)
 	templates = template.Must(templates.ParseGlob("templates/partials/*.html"))
+	templates = template.Must(templates.ParseGlob("templates/email/*.html"))
+
+	// Plain-text email bodies must not be HTML-escaped
+	emailTexts = texttemplate.Must(
+		texttemplate.New("").Funcs(texttemplate.FuncMap(funcMap)).ParseGlob("templates/email/*.txt"),
+	)
 
 	log.Printf("Templates loaded successfully")
 	return err
@@ -109,3 +119,16 @@ func Render(w http.ResponseWriter, name string, data interface{}) error {
 	}
 	return err
 }
+
+// RenderEmail renders an email's HTML and plain-text bodies from the
+// templates name.html and name.txt
+func RenderEmail(name string, data interface{}) (string, string, error) {
+	var html, text bytes.Buffer
+	if err := templates.ExecuteTemplate(&html, name+".html", data); err != nil {
+		return "", "", fmt.Errorf("failed to render %s.html: %w", name, err)
+	}
+	if err := emailTexts.ExecuteTemplate(&text, name+".txt", data); err != nil {
+		return "", "", fmt.Errorf("failed to render %s.txt: %w", name, err)
+	}
+	return html.String(), text.String(), nil
+}
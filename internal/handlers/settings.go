@@ -3,11 +3,13 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/bluesky-social/indigo/atproto/identity"
@@ -22,10 +24,19 @@ const SettingsRKey = "settings" // Single record per user
 
 type SettingsHandler struct {
 	client *bskyoauth.Client
+
+	// DIDs whose app usage was recorded in the current UTC hour
+	// ("2006-01-02T15"); the set is cleared when the hour changes
+	usageMu       sync.Mutex
+	usageSlot     string
+	usageRecorded map[string]bool
 }
 
 func NewSettingsHandler(client *bskyoauth.Client) *SettingsHandler {
-	return &SettingsHandler{client: client}
+	return &SettingsHandler{
+		client:        client,
+		usageRecorded: make(map[string]bool),
+	}
 }
 
 // HandleSettings handles settings CRUD operations
@@ -95,6 +106,27 @@ func (h *SettingsHandler) handleUpdateSettings(w http.ResponseWriter, r *http.Re
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	// Planning and digest times come from <input type="time"> as HH:MM
+	for _, clock := range []string{settings.PlanningTime, settings.ReviewTime, settings.DigestTime} {
+		if _, ok := models.AtClock(time.Now(), clock, time.UTC); clock != "" && !ok {
+			http.Error(w, "Times must be HH:MM", http.StatusBadRequest)
+			return
+		}
+	}
+	if settings.NotificationChannels != nil {
+		channels := make([]string, 0, len(settings.NotificationChannels))
+		for _, channel := range settings.NotificationChannels {
+			if models.IsNotificationChannel(channel) {
+				channels = append(channels, channel)
+			}
+		}
+		settings.NotificationChannels = channels
+	}
+	if settings.Timezone != "" {
+		if _, err := time.LoadLocation(settings.Timezone); err != nil {
+			settings.Timezone = ""
+		}
+	}
 
 	// Set metadata
 	settings.UpdatedAt = time.Now().UTC()
@@ -112,14 +144,32 @@ func (h *SettingsHandler) handleUpdateSettings(w http.ResponseWriter, r *http.Re
 		"quietEnd":                     settings.QuietEnd,
 		"pushEnabled":                  settings.PushEnabled,
 		"taskInputCollapsed":           settings.TaskInputCollapsed,
+		"hideProfile":                  settings.HideProfile,
 		"calendarNotificationsEnabled": settings.CalendarNotificationsEnabled,
 		"calendarNotificationLeadTime": settings.CalendarNotificationLeadTime,
+		"digestDaily":                  settings.DigestDaily,
+		"digestWeekly":                 settings.DigestWeekly,
 		"updatedAt":                    settings.UpdatedAt.Format(time.RFC3339),
 	}
 
-	// Include appUsageHours if present
-	if settings.AppUsageHours != nil {
-		record["appUsageHours"] = settings.AppUsageHours
+	// Include daily planning times if set
+	if settings.PlanningTime != "" {
+		record["planningTime"] = settings.PlanningTime
+	}
+	if settings.ReviewTime != "" {
+		record["reviewTime"] = settings.ReviewTime
+	}
+	if settings.Timezone != "" {
+		record["timezone"] = settings.Timezone
+	}
+	if settings.DigestTime != "" {
+		record["digestTime"] = settings.DigestTime
+	}
+	if settings.DigestView != "" {
+		record["digestView"] = settings.DigestView
+	}
+	if settings.NotificationChannels != nil {
+		record["notificationChannels"] = settings.NotificationChannels
 	}
 
 	// Include notificationSentHistory if present
@@ -128,12 +178,21 @@ func (h *SettingsHandler) handleUpdateSettings(w http.ResponseWriter, r *http.Re
 	}
 
 	// Check if settings record already exists
+	var existing map[string]interface{}
 	var err error
 	sess, err = h.WithRetry(r.Context(), sess, func(s *bskyoauth.Session) error {
-		_, fetchErr := h.GetRecord(r.Context(), s, SettingsRKey)
+		var fetchErr error
+		existing, fetchErr = h.GetRecord(r.Context(), s, SettingsRKey)
 		return fetchErr
 	})
 
+	// App usage is recorded by the server (RecordAppUsage), never the client
+	settings.AppUsageHours = nil
+	if usage, ok := existing["appUsageHours"]; ok {
+		record["appUsageHours"] = usage
+		settings.AppUsageHours = ParseSettingsRecord(existing).AppUsageHours
+	}
+
 	if err != nil {
 		// Create new settings record
 		log.Printf("Creating new settings record")
@@ -202,6 +261,9 @@ func ParseSettingsRecord(record map[string]interface{}) *models.NotificationSett
 	if v, ok := record["taskInputCollapsed"].(bool); ok {
 		settings.TaskInputCollapsed = v
 	}
+	if v, ok := record["hideProfile"].(bool); ok {
+		settings.HideProfile = v
+	}
 	if v, ok := record["calendarNotificationsEnabled"].(bool); ok {
 		settings.CalendarNotificationsEnabled = v
 	}
@@ -209,6 +271,36 @@ func ParseSettingsRecord(record map[string]interface{}) *models.NotificationSett
 		settings.CalendarNotificationLeadTime = v
 	}
 
+	if v, ok := record["planningTime"].(string); ok {
+		settings.PlanningTime = v
+	}
+	if v, ok := record["reviewTime"].(string); ok {
+		settings.ReviewTime = v
+	}
+	if v, ok := record["timezone"].(string); ok {
+		settings.Timezone = v
+	}
+	if v, ok := record["digestDaily"].(bool); ok {
+		settings.DigestDaily = v
+	}
+	if v, ok := record["digestWeekly"].(bool); ok {
+		settings.DigestWeekly = v
+	}
+	if v, ok := record["digestTime"].(string); ok {
+		settings.DigestTime = v
+	}
+	if v, ok := record["digestView"].(string); ok {
+		settings.DigestView = v
+	}
+	if values, ok := record["notificationChannels"].([]interface{}); ok {
+		settings.NotificationChannels = make([]string, 0, len(values))
+		for _, v := range values {
+			if channel, ok := v.(string); ok {
+				settings.NotificationChannels = append(settings.NotificationChannels, channel)
+			}
+		}
+	}
+
 	// Parse appUsageHours if present
 	if usageMap, ok := record["appUsageHours"].(map[string]interface{}); ok {
 		settings.AppUsageHours = make(map[string]int)
@@ -239,6 +331,101 @@ func ParseSettingsRecord(record map[string]interface{}) *models.NotificationSett
 	return settings
 }
 
+// FetchSettings reads a user's settings without a session (public read), for
+// background jobs. Users without a settings record get the defaults.
+func (h *SettingsHandler) FetchSettings(ctx context.Context, did string) (*models.NotificationSettings, error) {
+	pds, err := h.resolvePDSEndpoint(ctx, did)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve PDS endpoint: %w", err)
+	}
+
+	record, err := getPublicRecord(ctx, pds, did, SettingsCollection, SettingsRKey)
+	if errors.Is(err, errRecordNotFound) {
+		return models.DefaultNotificationSettings(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseSettingsRecord(record.Value), nil
+}
+
+// RecordAppUsage counts the user opening the app in the current hour of their
+// timezone, at most once per hour. It runs in the background so the page
+// isn't held up; failures are only logged. A token refreshed on the way is
+// saved to the session identified by sessionID.
+func (h *SettingsHandler) RecordAppUsage(sess *bskyoauth.Session, sessionID string) {
+	now := time.Now()
+	slot := now.UTC().Format("2006-01-02T15")
+
+	h.usageMu.Lock()
+	if h.usageSlot != slot {
+		h.usageSlot = slot
+		h.usageRecorded = make(map[string]bool)
+	}
+	if h.usageRecorded[sess.DID] {
+		h.usageMu.Unlock()
+		return
+	}
+	h.usageRecorded[sess.DID] = true
+	h.usageMu.Unlock()
+
+	// Work on a copy: the transports update the session's DPoP nonce
+	s := *sess
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		updated, err := h.recordAppUsage(ctx, &s, now)
+		if updated.AccessToken != sess.AccessToken && sessionID != "" {
+			h.client.UpdateSession(sessionID, updated)
+		}
+		if err != nil {
+			log.Printf("Failed to record app usage for %s: %v", s.DID, err)
+			h.usageMu.Lock()
+			if h.usageSlot == slot {
+				delete(h.usageRecorded, s.DID)
+			}
+			h.usageMu.Unlock()
+		}
+	}()
+}
+
+// recordAppUsage adds an app open at now to the user's settings record. The
+// write only applies over the record that was read, so a settings save made
+// in between is re-read rather than overwritten.
+func (h *SettingsHandler) recordAppUsage(ctx context.Context, sess *bskyoauth.Session, now time.Time) (*bskyoauth.Session, error) {
+	pds, err := h.resolvePDSEndpoint(ctx, sess.DID)
+	if err != nil {
+		return sess, fmt.Errorf("failed to resolve PDS endpoint: %w", err)
+	}
+
+	for attempt := 1; ; attempt++ {
+		current, err := getPublicRecord(ctx, pds, sess.DID, SettingsCollection, SettingsRKey)
+		if errors.Is(err, errRecordNotFound) {
+			// Usage is only kept once the user has saved settings
+			return sess, nil
+		}
+		if err != nil {
+			return sess, err
+		}
+
+		record := current.Value
+		settings := ParseSettingsRecord(record)
+		settings.RecordUsage(now.In(models.UserLocation(settings.Timezone)).Hour())
+		record["appUsageHours"] = settings.AppUsageHours
+
+		sess, err = h.WithRetry(ctx, sess, func(s *bskyoauth.Session) error {
+			_, err := putRepoRecord(ctx, s, pds, SettingsCollection, SettingsRKey, record, current.CID)
+			return err
+		})
+		if errors.Is(err, errSwapConflict) && attempt < maxSwapAttempts {
+			continue
+		}
+		return sess, err
+	}
+}
+
 // GetRecord retrieves a settings record using com.atproto.repo.getRecord
 func (h *SettingsHandler) GetRecord(ctx context.Context, sess *bskyoauth.Session, rkey string) (map[string]interface{}, error) {
 	url := fmt.Sprintf("%s/xrpc/com.atproto.repo.getRecord?repo=%s&collection=%s&rkey=%s",
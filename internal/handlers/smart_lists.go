@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/shindakun/attodo/internal/models"
+	"github.com/shindakun/bskyoauth"
+)
+
+const maxFilterTags = 10
+
+// applySmartListForm sets or clears a list's smart rules from form fields
+// (smart, filterStatus, filterTags, filterDue, filterText, filterState,
+// filterContext, materialize)
+func applySmartListForm(r *http.Request, list *models.TaskList) {
+	if smart := r.FormValue("smart"); smart != "on" && smart != "true" {
+		list.Filter = nil
+		list.Materialize = false
+		return
+	}
+
+	filter := &models.TaskFilter{
+		Text: strings.TrimSpace(r.FormValue("filterText")),
+		Tags: parseFilterTags(r.FormValue("filterTags")),
+	}
+
+	switch status := r.FormValue("filterStatus"); status {
+	case models.FilterStatusIncomplete, models.FilterStatusCompleted:
+		filter.Status = status
+	}
+
+	switch due := r.FormValue("filterDue"); due {
+	case models.FilterDueOverdue, models.FilterDueToday, models.FilterDueUpcoming, models.FilterDueNone, models.FilterDueHas:
+		filter.Due = due
+	}
+
+	if state := r.FormValue("filterState"); models.IsGTDState(state) || state == models.FilterStateAll {
+		filter.State = state
+	}
+	filter.Context = models.NormalizeContext(r.FormValue("filterContext"))
+
+	list.Filter = filter
+	materialize := r.FormValue("materialize")
+	list.Materialize = materialize == "on" || materialize == "true"
+}
+
+// parseFilterTags splits "work, #urgent home" into unique tags without the #
+func parseFilterTags(input string) []string {
+	fields := strings.FieldsFunc(input, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+
+	tags := make([]string, 0, len(fields))
+	seen := make(map[string]bool)
+	for _, field := range fields {
+		tag := strings.TrimPrefix(strings.TrimSpace(field), "#")
+		if tag == "" || seen[strings.ToLower(tag)] {
+			continue
+		}
+		seen[strings.ToLower(tag)] = true
+		tags = append(tags, tag)
+		if len(tags) == maxFilterTags {
+			break
+		}
+	}
+	return tags
+}
+
+// taskURIs returns the URIs of a set of tasks
+func taskURIs(tasks []*models.Task) []string {
+	uris := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		uris = append(uris, task.URI)
+	}
+	return uris
+}
+
+// syncSmartList writes a smart list's current matches to its TaskURIs if they changed
+// list.Tasks must already be resolved (see loadListTasks)
+func (h *ListHandler) syncSmartList(ctx context.Context, sess *bskyoauth.Session, list *models.TaskList) (*bskyoauth.Session, error) {
+	uris := taskURIs(list.Tasks)
+	if equalStrings(list.TaskURIs, uris) {
+		return sess, nil
+	}
+
+	list.TaskURIs = uris
+	list.UpdatedAt = time.Now().UTC()
+	record := buildListRecord(list)
+
+	var err error
+	sess, err = h.WithRetry(ctx, sess, func(s *bskyoauth.Session) error {
+		return h.updateRecord(ctx, s, list.RKey, record)
+	})
+	if err != nil {
+		return sess, fmt.Errorf("failed to update list %s: %w", list.RKey, err)
+	}
+
+	log.Printf("Smart list %s materialized with %d tasks", list.RKey, len(uris))
+	return sess, nil
+}
+
+// rememberSession records the session behind a request so background jobs can
+// act for the user while they stay logged in
+func (h *ListHandler) rememberSession(r *http.Request, did string) {
+	cookie, err := r.Cookie("session_id")
+	if err != nil || cookie.Value == "" {
+		return
+	}
+
+	h.sessionsMu.Lock()
+	h.activeSessions[did] = cookie.Value
+	h.sessionsMu.Unlock()
+}
+
+// MaterializeSmartLists refreshes the TaskURIs of every materialized smart list
+// belonging to users with an active session. Returns the number of users processed.
+func (h *ListHandler) MaterializeSmartLists(ctx context.Context) (int, error) {
+	h.sessionsMu.Lock()
+	sessions := make(map[string]string, len(h.activeSessions))
+	for did, id := range h.activeSessions {
+		sessions[did] = id
+	}
+	h.sessionsMu.Unlock()
+
+	processed := 0
+	for did, id := range sessions {
+		if ctx.Err() != nil {
+			return processed, ctx.Err()
+		}
+
+		sess, err := h.client.GetSession(id)
+		if err != nil || sess == nil {
+			// Logged out or expired - stop acting for this user
+			h.sessionsMu.Lock()
+			if h.activeSessions[did] == id {
+				delete(h.activeSessions, did)
+			}
+			h.sessionsMu.Unlock()
+			continue
+		}
+
+		sess, err = h.materializeForSession(ctx, sess)
+		if err != nil {
+			log.Printf("Failed to materialize smart lists for %s: %v", did, err)
+		}
+		processed++
+
+		// Keep the stored session in step with any token refresh
+		h.client.UpdateSession(id, sess)
+	}
+
+	return processed, nil
+}
+
+// materializeForSession syncs the materialized smart lists of one user
+func (h *ListHandler) materializeForSession(ctx context.Context, sess *bskyoauth.Session) (*bskyoauth.Session, error) {
+	var lists []*models.TaskList
+	var err error
+	sess, err = h.WithRetry(ctx, sess, func(s *bskyoauth.Session) error {
+		lists, err = h.ListRecords(ctx, s)
+		return err
+	})
+	if err != nil {
+		return sess, fmt.Errorf("failed to list lists: %w", err)
+	}
+
+	smart := make([]*models.TaskList, 0)
+	for _, list := range lists {
+		if list.IsSmart() && list.Materialize {
+			smart = append(smart, list)
+		}
+	}
+	if len(smart) == 0 {
+		return sess, nil
+	}
+
+	if err := h.loadListTasks(ctx, sess, smart); err != nil {
+		return sess, err
+	}
+
+	for _, list := range smart {
+		sess, err = h.syncSmartList(ctx, sess, list)
+		if err != nil {
+			return sess, err
+		}
+	}
+
+	return sess, nil
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -31,6 +31,7 @@ const (
 type TaskHandler struct {
 	client      *bskyoauth.Client
 	listHandler *ListHandler
+	reminders   ReminderScheduler
 }
 
 func NewTaskHandler(client *bskyoauth.Client) *TaskHandler {
@@ -42,6 +43,11 @@ func (h *TaskHandler) SetListHandler(listHandler *ListHandler) {
 	h.listHandler = listHandler
 }
 
+// SetReminderScheduler sets the scheduler told about changed reminders
+func (h *TaskHandler) SetReminderScheduler(reminders ReminderScheduler) {
+	h.reminders = reminders
+}
+
 // withRetry executes an operation with automatic token refresh on DPoP errors
 func (h *TaskHandler) withRetry(ctx context.Context, sess *bskyoauth.Session, operation func(*bskyoauth.Session) error) (*bskyoauth.Session, error) {
 	var err error
@@ -142,8 +148,8 @@ func parseTags(input string) []string {
 	return tags
 }
 
-// parseTaskFields extracts task fields from a record value map
-func parseTaskFields(record map[string]interface{}) models.Task {
+// ParseTaskFields extracts task fields from a record value map
+func ParseTaskFields(record map[string]interface{}) models.Task {
 	task := models.Task{}
 
 	if title, ok := record["title"].(string); ok {
@@ -180,6 +186,37 @@ func parseTaskFields(record map[string]interface{}) models.Task {
 			}
 		}
 	}
+	// Parse workflow status if present
+	if status, ok := record["status"].(string); ok {
+		task.Status = status
+	}
+	// Parse GTD fields if present
+	if state, ok := record["state"].(string); ok {
+		task.State = state
+	}
+	if contexts, ok := record["contexts"].([]interface{}); ok {
+		task.Contexts = make([]string, 0, len(contexts))
+		for _, context := range contexts {
+			if contextStr, ok := context.(string); ok {
+				task.Contexts = append(task.Contexts, contextStr)
+			}
+		}
+	}
+	if waitingFor, ok := record["waitingFor"].(string); ok {
+		task.WaitingFor = waitingFor
+	}
+	if followUpAt, ok := record["followUpAt"].(string); ok {
+		if t, err := time.Parse(time.RFC3339, followUpAt); err == nil {
+			task.FollowUpAt = &t
+		}
+	}
+	if reviewedAt, ok := record["reviewedAt"].(string); ok {
+		if t, err := time.Parse(time.RFC3339, reviewedAt); err == nil {
+			task.ReviewedAt = &t
+		}
+	}
+	// Parse reminders if present
+	task.Reminders = parseReminderRecords(record)
 	// Parse recurring flag if present
 	if isRecurring, ok := record["isRecurring"].(bool); ok {
 		task.IsRecurring = isRecurring
@@ -230,6 +267,33 @@ func buildTaskRecord(task *models.Task) map[string]interface{} {
 		record["tags"] = []string{}
 	}
 
+	// Add workflow status if set
+	if task.Status != "" {
+		record["status"] = task.Status
+	}
+
+	// Add GTD fields if set
+	if task.State != "" {
+		record["state"] = task.State
+	}
+	if len(task.Contexts) > 0 {
+		record["contexts"] = task.Contexts
+	}
+	if task.WaitingFor != "" {
+		record["waitingFor"] = task.WaitingFor
+	}
+	if task.FollowUpAt != nil {
+		record["followUpAt"] = task.FollowUpAt.Format(time.RFC3339)
+	}
+	if task.ReviewedAt != nil {
+		record["reviewedAt"] = task.ReviewedAt.Format(time.RFC3339)
+	}
+
+	// Add reminders if set
+	if len(task.Reminders) > 0 {
+		record["reminders"] = buildReminderRecords(task.Reminders)
+	}
+
 	// Add recurring flag and pattern if set
 	if task.IsRecurring {
 		record["isRecurring"] = true
@@ -298,6 +362,13 @@ func (h *TaskHandler) handleCreateTask(w http.ResponseWriter, r *http.Request) {
 	now := time.Now()
 	var dueDate *time.Time
 
+	// Pull "remind me ..." phrases out of the title before looking for a due date
+	reminderResult := dateparse.ParseReminders(title, now)
+	if len(reminderResult.Reminders) > 0 && reminderResult.CleanedTitle != "" {
+		title = reminderResult.CleanedTitle
+	}
+	reminders := reminderModels(reminderResult.Reminders)
+
 	if dueDateInput != "" {
 		// Explicit due date provided via form field
 		if t, err := time.Parse("2006-01-02", dueDateInput); err == nil {
@@ -348,6 +419,15 @@ func (h *TaskHandler) handleCreateTask(w http.ResponseWriter, r *http.Request) {
 		record["tags"] = tags
 	}
 
+	// Add reminders if present
+	if err := validateReminders(&models.Task{DueDate: dueDate, Reminders: reminders}); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(reminders) > 0 {
+		record["reminders"] = buildReminderRecords(reminders)
+	}
+
 	// Check if this is a recurring task and parse pattern
 	isRecurring := r.FormValue("isRecurring") == "on"
 	var frequency string
@@ -418,6 +498,7 @@ func (h *TaskHandler) handleCreateTask(w http.ResponseWriter, r *http.Request) {
 		CreatedAt:     nowUTC,
 		DueDate:       dueDate,
 		Tags:          tags,
+		Reminders:     reminders,
 		RKey:          rkey,
 		URI:           output.Uri,
 		IsRecurring:   isRecurring,
@@ -425,6 +506,7 @@ func (h *TaskHandler) handleCreateTask(w http.ResponseWriter, r *http.Request) {
 		RecInterval:   interval,
 		RecDaysOfWeek: daysOfWeek,
 	}
+	h.rescheduleReminders(sess.DID, &task)
 
 	// Return HTMX response with new task partial
 	w.Header().Set("Content-Type", "text/html")
@@ -467,16 +549,8 @@ func (h *TaskHandler) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Toggle completion
-	task.Completed = !task.Completed
-
-	// Update completedAt based on completion status
-	if task.Completed {
-		now := time.Now().UTC()
-		task.CompletedAt = &now
-	} else {
-		task.CompletedAt = nil
-	}
+	// Toggle completion (also moves the workflow status to done or todo)
+	task.SetCompleted(!task.Completed, time.Now().UTC())
 
 	// Build the record for update
 	record := buildTaskRecord(task)
@@ -565,6 +639,29 @@ func (h *TaskHandler) handleEditTask(w http.ResponseWriter, r *http.Request) {
 	tagsInput := r.FormValue("tags")
 	task.Tags = parseTags(tagsInput)
 
+	// Update GTD fields (only sent by forms that edit them)
+	if _, ok := r.Form["state"]; ok {
+		if err := applyGTDForm(r, task, time.Now().UTC()); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Update reminders from the reminders field (only sent by forms that edit
+	// them) and any "remind me ..." phrases in the title
+	if _, ok := r.Form["reminders"]; ok {
+		reminders, err := parseRemindersInput(r.FormValue("reminders"), time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		task.Reminders = reminders
+	}
+	if reminderResult := dateparse.ParseReminders(task.Title, time.Now()); len(reminderResult.Reminders) > 0 && reminderResult.CleanedTitle != "" {
+		task.Title = reminderResult.CleanedTitle
+		task.Reminders = append(task.Reminders, reminderModels(reminderResult.Reminders)...)
+	}
+
 	// Update due date and time
 	dueDateInput := r.FormValue("dueDate")
 	dueTimeInput := r.FormValue("dueTime")
@@ -644,6 +741,11 @@ func (h *TaskHandler) handleEditTask(w http.ResponseWriter, r *http.Request) {
 		log.Printf("Converting task to recurring: frequency=%s, interval=%d, daysOfWeek=%v", frequency, interval, task.RecDaysOfWeek)
 	}
 
+	if err := validateReminders(task); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Build the record for update
 	record := buildTaskRecord(task)
 
@@ -665,6 +767,7 @@ func (h *TaskHandler) handleEditTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	log.Printf("Task edited: %s (isRecurring: %v)", rkey, task.IsRecurring)
+	h.rescheduleReminders(sess.DID, task)
 
 	// Return updated task partial for HTMX to swap
 	w.Header().Set("Content-Type", "text/html")
@@ -716,13 +819,27 @@ func (h *TaskHandler) handleListTasks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get filter parameters
-	filter := r.URL.Query().Get("filter")
-	tagFilter := r.URL.Query().Get("tag")
-	sortBy := r.URL.Query().Get("sort")
-	dueFilter := r.URL.Query().Get("due")
+	// Get filter parameters, either directly or from a saved view
+	view := &models.SavedView{
+		Filter:  r.URL.Query().Get("filter"),
+		Tag:     r.URL.Query().Get("tag"),
+		Sort:    r.URL.Query().Get("sort"),
+		Due:     r.URL.Query().Get("due"),
+		State:   r.URL.Query().Get("state"),
+		Context: r.URL.Query().Get("context"),
+	}
+	if ref := r.URL.Query().Get("view"); ref != "" {
+		saved, err := resolveViewRef(r.Context(), h.listHandler, sess, ref)
+		if err != nil {
+			log.Printf("Failed to load view %s: %v", ref, err)
+			writeViewError(w, err)
+			return
+		}
+		view = saved
+	}
+	sortBy := view.Sort
 
-	log.Printf("Listing tasks for DID: %s (filter: %s, tag: %s, sort: %s, due: %s)", sess.DID, filter, tagFilter, sortBy, dueFilter)
+	log.Printf("Listing tasks for DID: %s (filter: %s, tag: %s, sort: %s, due: %s, state: %s)", sess.DID, view.Filter, view.Tag, sortBy, view.Due, view.State)
 
 	// Use com.atproto.repo.listRecords to fetch all tasks
 	var tasks []models.Task
@@ -766,63 +883,28 @@ func (h *TaskHandler) handleListTasks(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Filter tasks based on completion status and tags
+	// Filter tasks based on completion status, tag and due date
+	taskFilter := view.TaskFilter()
 	filteredTasks := make([]models.Task, 0)
-	for _, task := range tasks {
-		// Apply completion filter
-		if filter == "completed" && !task.Completed {
-			continue
-		} else if filter == "incomplete" && task.Completed {
-			continue
-		}
-
-		// Apply tag filter
-		if tagFilter != "" {
-			hasTag := false
-			for _, tag := range task.Tags {
-				if strings.EqualFold(tag, tagFilter) {
-					hasTag = true
-					break
-				}
-			}
-			if !hasTag {
-				continue
-			}
-		}
-
-		// Apply due date filter
-		if dueFilter != "" {
-			switch dueFilter {
-			case "overdue":
-				if !task.IsOverdue() {
-					continue
-				}
-			case "today":
-				if !task.IsDueToday() {
-					continue
-				}
-			case "upcoming":
-				if !task.IsDueSoon() {
-					continue
-				}
-			case "none":
-				if task.DueDate != nil {
-					continue
-				}
-			case "has":
-				// Show only tasks that have a due date
-				if task.DueDate == nil {
-					continue
-				}
-			}
+	for i := range tasks {
+		if taskFilter.Matches(&tasks[i]) {
+			filteredTasks = append(filteredTasks, tasks[i])
 		}
-
-		filteredTasks = append(filteredTasks, task)
 	}
 
 	// Sort tasks
 	sortTasks(filteredTasks, sortBy)
 
+	// Manual order places tasks by their saved positions, newest first until placed
+	if sortBy == models.ViewSortManual {
+		positions, _, err := fetchInboxOrder(r.Context(), sess.PDS, sess.DID)
+		if err != nil {
+			log.Printf("Failed to get inbox order: %v", err)
+		} else {
+			orderTasksByPosition(filteredTasks, positions)
+		}
+	}
+
 	log.Printf("Found %d tasks (filtered: %d)", len(tasks), len(filteredTasks))
 
 	// Check if client wants JSON response
@@ -928,7 +1010,7 @@ func (h *TaskHandler) listRecords(ctx context.Context, sess *bskyoauth.Session)
 	// Convert to Task models
 	tasks := make([]models.Task, 0, len(result.Records))
 	for _, record := range result.Records {
-		task := parseTaskFields(record.Value)
+		task := ParseTaskFields(record.Value)
 		task.URI = record.Uri
 		task.RKey = extractRKey(record.Uri)
 		tasks = append(tasks, task)
@@ -973,7 +1055,7 @@ func (h *TaskHandler) getRecord(ctx context.Context, sess *bskyoauth.Session, rk
 		return nil, err
 	}
 
-	task := parseTaskFields(result.Value)
+	task := ParseTaskFields(result.Value)
 	task.URI = result.Uri
 	task.RKey = rkey
 
@@ -1112,6 +1194,13 @@ func (h *TaskHandler) handleRecurringTaskCompletion(ctx context.Context, sess *b
 		RecDaysOfWeek: completedTask.RecDaysOfWeek,
 	}
 
+	// Reminders relative to the due date carry over to the next occurrence
+	for _, reminder := range completedTask.Reminders {
+		if reminder.At == nil {
+			newTask.Reminders = append(newTask.Reminders, reminder)
+		}
+	}
+
 	// Build the record
 	record := buildTaskRecord(newTask)
 
@@ -1128,6 +1217,7 @@ func (h *TaskHandler) handleRecurringTaskCompletion(ctx context.Context, sess *b
 	}
 
 	log.Printf("Created next recurring instance: %s (due: %v)", output.Uri, nextDueDate.Format(time.RFC3339))
+	h.rescheduleReminders(sess.DID, newTask)
 	return nil
 }
 
@@ -0,0 +1,355 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bluesky-social/indigo/api/atproto"
+	"github.com/shindakun/attodo/internal/models"
+	"github.com/shindakun/attodo/internal/session"
+	"github.com/shindakun/bskyoauth"
+)
+
+const ViewCollection = "app.attodo.view"
+
+const maxViewNameLength = 64
+
+var errViewNotFound = errors.New("view not found")
+
+type ViewHandler struct {
+	client      *bskyoauth.Client
+	listHandler *ListHandler
+}
+
+func NewViewHandler(client *bskyoauth.Client, listHandler *ListHandler) *ViewHandler {
+	return &ViewHandler{
+		client:      client,
+		listHandler: listHandler,
+	}
+}
+
+// HandleViews handles saved view CRUD operations
+func (h *ViewHandler) HandleViews(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.handleListViews(w, r)
+	case http.MethodPost:
+		h.handleCreateView(w, r)
+	case http.MethodDelete:
+		h.handleDeleteView(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleListViews renders the current user's saved views
+func (h *ViewHandler) handleListViews(w http.ResponseWriter, r *http.Request) {
+	sess, ok := session.GetSession(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	views, err := fetchViews(r.Context(), sess.PDS, sess.DID)
+	if err != nil {
+		log.Printf("Failed to list views: %v", err)
+		http.Error(w, "Failed to list views", http.StatusInternalServerError)
+		return
+	}
+
+	// JSON for the settings page's digest view picker
+	if strings.Contains(r.Header.Get("Accept"), "application/json") && r.Header.Get("HX-Request") == "" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(views)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	for _, view := range views {
+		Render(w, "view-item.html", view)
+	}
+}
+
+// handleCreateView saves a view from form fields (name, filter, tag, due, sort),
+// or copies a shared view when "from" holds its AT URI
+func (h *ViewHandler) handleCreateView(w http.ResponseWriter, r *http.Request) {
+	sess, ok := session.GetSession(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	view := &models.SavedView{
+		Name:    r.FormValue("name"),
+		Filter:  r.FormValue("filter"),
+		Tag:     r.FormValue("tag"),
+		Due:     r.FormValue("due"),
+		State:   r.FormValue("state"),
+		Context: r.FormValue("context"),
+		Sort:    r.FormValue("sort"),
+	}
+
+	if from := strings.TrimSpace(r.FormValue("from")); from != "" {
+		shared, err := h.FetchViewByURI(r.Context(), from)
+		if err != nil {
+			log.Printf("Failed to fetch shared view %s: %v", from, err)
+			writeViewError(w, err)
+			return
+		}
+		name := view.Name
+		view = shared
+		if strings.TrimSpace(name) != "" {
+			view.Name = name
+		}
+	}
+
+	view.Normalize()
+	if view.Name == "" {
+		http.Error(w, "Name is required", http.StatusBadRequest)
+		return
+	}
+	if utf8.RuneCountInString(view.Name) > maxViewNameLength {
+		http.Error(w, fmt.Sprintf("Name must be %d characters or less", maxViewNameLength), http.StatusBadRequest)
+		return
+	}
+	if len(view.Tag) > MaxTagLength {
+		http.Error(w, fmt.Sprintf("Tag must be %d characters or less", MaxTagLength), http.StatusBadRequest)
+		return
+	}
+
+	view.CreatedAt = time.Now().UTC()
+	record := buildViewRecord(view)
+
+	var output *atproto.RepoCreateRecord_Output
+	var err error
+	sess, err = h.listHandler.WithRetry(r.Context(), sess, func(s *bskyoauth.Session) error {
+		output, err = h.client.CreateRecord(r.Context(), s, ViewCollection, record)
+		return err
+	})
+
+	if err != nil {
+		log.Printf("Failed to create view after retries: %v", err)
+		http.Error(w, "Failed to save view", http.StatusInternalServerError)
+		return
+	}
+
+	// Update session
+	cookie, _ := r.Cookie("session_id")
+	if cookie != nil {
+		h.client.UpdateSession(cookie.Value, sess)
+	}
+
+	view.URI = output.Uri
+	view.RKey = extractRKey(output.Uri)
+
+	log.Printf("View created: %s (%s)", view.Name, view.RKey)
+
+	w.Header().Set("Content-Type", "text/html")
+	Render(w, "view-item.html", view)
+}
+
+// handleDeleteView removes a saved view
+func (h *ViewHandler) handleDeleteView(w http.ResponseWriter, r *http.Request) {
+	sess, ok := session.GetSession(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	rkey := r.URL.Query().Get("rkey")
+	if rkey == "" {
+		http.Error(w, "rkey is required", http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	sess, err = h.listHandler.WithRetry(r.Context(), sess, func(s *bskyoauth.Session) error {
+		return h.client.DeleteRecord(r.Context(), s, ViewCollection, rkey)
+	})
+
+	if err != nil {
+		log.Printf("Failed to delete view after retries: %v", err)
+		http.Error(w, "Failed to delete view", http.StatusInternalServerError)
+		return
+	}
+
+	// Update session
+	cookie, _ := r.Cookie("session_id")
+	if cookie != nil {
+		h.client.UpdateSession(cookie.Value, sess)
+	}
+
+	log.Printf("View deleted: %s", rkey)
+	w.WriteHeader(http.StatusOK)
+}
+
+// FetchView reads one of a user's saved views (no authentication, used by feeds and background jobs)
+func (h *ViewHandler) FetchView(ctx context.Context, did, rkey string) (*models.SavedView, error) {
+	pds, err := h.listHandler.resolvePDSEndpoint(ctx, did)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve PDS endpoint: %w", err)
+	}
+	return fetchPublicView(ctx, pds, did, rkey)
+}
+
+// FetchViewByURI reads a saved view from its AT URI, e.g. from a shared link
+func (h *ViewHandler) FetchViewByURI(ctx context.Context, uri string) (*models.SavedView, error) {
+	did, rkey, err := parseViewURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	return h.FetchView(ctx, did, rkey)
+}
+
+// resolveViewRef loads the view named by a /app/tasks?view= parameter: either
+// the rkey of one of the current user's views or the AT URI of a shared view
+func resolveViewRef(ctx context.Context, listHandler *ListHandler, sess *bskyoauth.Session, ref string) (*models.SavedView, error) {
+	if !strings.HasPrefix(ref, "at://") {
+		return fetchPublicView(ctx, sess.PDS, sess.DID, ref)
+	}
+
+	did, rkey, err := parseViewURI(ref)
+	if err != nil {
+		return nil, err
+	}
+	if did == sess.DID {
+		return fetchPublicView(ctx, sess.PDS, did, rkey)
+	}
+	if listHandler == nil {
+		return nil, errViewNotFound
+	}
+
+	pds, err := listHandler.resolvePDSEndpoint(ctx, did)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve PDS endpoint: %w", err)
+	}
+	return fetchPublicView(ctx, pds, did, rkey)
+}
+
+// parseViewURI splits at://did/app.attodo.view/rkey into its DID and rkey
+func parseViewURI(uri string) (string, string, error) {
+	parts := strings.Split(strings.TrimPrefix(uri, "at://"), "/")
+	if !strings.HasPrefix(uri, "at://") || len(parts) != 3 || parts[1] != ViewCollection || parts[0] == "" || parts[2] == "" {
+		return "", "", fmt.Errorf("%w: invalid view URI %q", errViewNotFound, uri)
+	}
+	return parts[0], parts[2], nil
+}
+
+// fetchViews reads all view records from a repository (view records are public)
+func fetchViews(ctx context.Context, pds, did string) ([]*models.SavedView, error) {
+	records, err := listPublicRecords(ctx, pds, did, ViewCollection)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]*models.SavedView, 0, len(records))
+	for _, record := range records {
+		view := parseViewRecord(record.Value)
+		if view.Name == "" {
+			continue
+		}
+		view.URI = record.URI
+		view.RKey = extractRKey(record.URI)
+		views = append(views, view)
+	}
+
+	return views, nil
+}
+
+// fetchPublicView reads a single view record without authentication
+func fetchPublicView(ctx context.Context, pds, did, rkey string) (*models.SavedView, error) {
+	record, err := getPublicRecord(ctx, pds, did, ViewCollection, rkey)
+	if errors.Is(err, errRecordNotFound) {
+		return nil, errViewNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	view := parseViewRecord(record.Value)
+	view.URI = record.URI
+	view.RKey = rkey
+	return view, nil
+}
+
+func writeViewError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errViewNotFound) {
+		http.Error(w, "View not found", http.StatusNotFound)
+		return
+	}
+	http.Error(w, "Failed to load view", http.StatusBadGateway)
+}
+
+func buildViewRecord(view *models.SavedView) map[string]interface{} {
+	record := map[string]interface{}{
+		"$type":     ViewCollection,
+		"name":      view.Name,
+		"createdAt": view.CreatedAt.Format(time.RFC3339),
+	}
+	if view.Filter != "" {
+		record["filter"] = view.Filter
+	}
+	if view.Tag != "" {
+		record["tag"] = view.Tag
+	}
+	if view.Due != "" {
+		record["due"] = view.Due
+	}
+	if view.State != "" {
+		record["state"] = view.State
+	}
+	if view.Context != "" {
+		record["context"] = view.Context
+	}
+	if view.Sort != "" {
+		record["sort"] = view.Sort
+	}
+	return record
+}
+
+func parseViewRecord(value map[string]interface{}) *models.SavedView {
+	view := &models.SavedView{}
+
+	if name, ok := value["name"].(string); ok {
+		view.Name = name
+	}
+	if filter, ok := value["filter"].(string); ok {
+		view.Filter = filter
+	}
+	if tag, ok := value["tag"].(string); ok {
+		view.Tag = tag
+	}
+	if due, ok := value["due"].(string); ok {
+		view.Due = due
+	}
+	if state, ok := value["state"].(string); ok {
+		view.State = state
+	}
+	if context, ok := value["context"].(string); ok {
+		view.Context = context
+	}
+	if sort, ok := value["sort"].(string); ok {
+		view.Sort = sort
+	}
+	if createdAt, ok := value["createdAt"].(string); ok {
+		if t, err := time.Parse(time.RFC3339, createdAt); err == nil {
+			view.CreatedAt = t
+		}
+	}
+
+	// Records written by other clients may hold values we don't understand
+	view.Normalize()
+	return view
+}
@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/shindakun/attodo/internal/database"
+	"github.com/shindakun/attodo/internal/models"
+	"github.com/shindakun/attodo/internal/push"
+	"github.com/shindakun/attodo/internal/session"
+	"github.com/shindakun/attodo/internal/webhook"
+)
+
+// MaxWebhooksPerUser caps how many webhook, ntfy and Gotify targets a user
+// can register
+const MaxWebhooksPerUser = 5
+
+// WebhookHandler manages a user's webhook, ntfy and Gotify notification
+// targets
+type WebhookHandler struct {
+	repo    *database.NotificationRepo
+	sender  *webhook.Sender
+	baseURL string
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(repo *database.NotificationRepo, sender *webhook.Sender, baseURL string) *WebhookHandler {
+	return &WebhookHandler{
+		repo:    repo,
+		sender:  sender,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// HandleWebhooks handles /app/webhooks: GET lists the user's targets, POST
+// adds one, DELETE ?id= removes one
+func (h *WebhookHandler) HandleWebhooks(w http.ResponseWriter, r *http.Request) {
+	sess, ok := session.GetSession(r)
+	if !ok || sess == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.writeList(w, sess.DID)
+
+	case http.MethodPost:
+		h.handleCreate(w, r, sess.DID)
+
+	case http.MethodDelete:
+		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+			return
+		}
+		if err := h.repo.DeleteWebhook(sess.DID, id); err != nil {
+			log.Printf("Failed to delete webhook: %v", err)
+			http.Error(w, "Failed to remove webhook", http.StatusInternalServerError)
+			return
+		}
+		disableIfUnreachable(h.repo, sess.DID)
+		log.Printf("Webhook %d removed for DID: %s", id, sess.DID)
+		h.writeList(w, sess.DID)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleCreate validates and saves a new target. Webhooks get a signing
+// secret, returned only in this response.
+func (h *WebhookHandler) handleCreate(w http.ResponseWriter, r *http.Request, did string) {
+	var req struct {
+		Kind  string `json:"kind"`
+		Name  string `json:"name"`
+		URL   string `json:"url"`
+		Token string `json:"token"` // ntfy access token or Gotify application token
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	hook := &models.Webhook{
+		DID:  did,
+		Kind: req.Kind,
+		Name: strings.TrimSpace(req.Name),
+		URL:  strings.TrimSpace(req.URL),
+	}
+	if len(hook.Name) > 64 {
+		http.Error(w, "Name must be 64 characters or fewer", http.StatusBadRequest)
+		return
+	}
+	if err := webhook.ValidateURL(hook.URL); err != nil {
+		http.Error(w, fmt.Sprintf("Please enter a valid URL: it %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(hook.URL) > 2048 {
+		http.Error(w, "URL is too long", http.StatusBadRequest)
+		return
+	}
+
+	switch hook.Kind {
+	case models.WebhookKindWebhook:
+		secret, err := webhook.NewSecret()
+		if err != nil {
+			log.Printf("Failed to generate webhook secret: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		hook.Secret = secret
+	case models.WebhookKindNtfy:
+		if u, _ := url.Parse(hook.URL); strings.Trim(u.Path, "/") == "" {
+			http.Error(w, "Please include the topic in the URL, e.g. https://ntfy.sh/my-tasks", http.StatusBadRequest)
+			return
+		}
+		hook.Secret = strings.TrimSpace(req.Token)
+	case models.WebhookKindGotify:
+		hook.Secret = strings.TrimSpace(req.Token)
+		if hook.Secret == "" {
+			http.Error(w, "Please enter the Gotify application token", http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "Unknown webhook type", http.StatusBadRequest)
+		return
+	}
+
+	existing, err := h.repo.GetWebhooksByDID(did)
+	if err != nil {
+		log.Printf("Failed to get webhooks: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if len(existing) >= MaxWebhooksPerUser {
+		http.Error(w, fmt.Sprintf("You can add at most %d webhooks", MaxWebhooksPerUser), http.StatusBadRequest)
+		return
+	}
+
+	// Webhooks reference the notification user (foreign key constraint), and
+	// a new target gets notifications right away
+	if err := enableNotifications(h.repo, did); err != nil {
+		log.Printf("Failed to enable notifications: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if err := h.repo.CreateWebhook(hook); err != nil {
+		log.Printf("Failed to create webhook: %v", err)
+		http.Error(w, "Failed to add webhook", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Webhook %d (%s) added for DID: %s", hook.ID, hook.Kind, did)
+
+	response := map[string]interface{}{"webhook": hook}
+	if hook.Kind == models.WebhookKindWebhook {
+		response["secret"] = hook.Secret
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// HandleTest handles POST /app/webhooks/test?id=, sending a test notification
+// to one target
+func (h *WebhookHandler) HandleTest(w http.ResponseWriter, r *http.Request) {
+	hook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	notification := &push.Notification{
+		Title: "Test Notification",
+		Body:  "Your AT Todo webhook is working.",
+		Icon:  "/static/icon-192.png",
+		Tag:   "test",
+		Data: map[string]interface{}{
+			"type": "test",
+			"url":  "/app",
+		},
+	}
+
+	err := h.sender.Deliver(r.Context(), hook, webhook.NewPayload(notification, h.baseURL))
+	webhook.RecordResult(hook, err)
+	if saveErr := h.repo.UpdateWebhookDelivery(hook); saveErr != nil {
+		log.Printf("Failed to record webhook delivery: %v", saveErr)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		log.Printf("Test delivery to webhook %d failed: %v", hook.ID, err)
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "error": err.Error(), "webhook": hook})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "webhook": hook})
+}
+
+// HandleEnable handles POST /app/webhooks/enable?id=, turning a target
+// disabled after repeated failures back on
+func (h *WebhookHandler) HandleEnable(w http.ResponseWriter, r *http.Request) {
+	hook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	hook.DisabledAt = nil
+	hook.FailureCount = 0
+	if err := h.repo.UpdateWebhookDelivery(hook); err != nil {
+		log.Printf("Failed to enable webhook: %v", err)
+		http.Error(w, "Failed to turn the webhook back on", http.StatusInternalServerError)
+		return
+	}
+	if err := enableNotifications(h.repo, hook.DID); err != nil {
+		log.Printf("Failed to enable notifications: %v", err)
+	}
+
+	log.Printf("Webhook %d re-enabled for DID: %s", hook.ID, hook.DID)
+	h.writeList(w, hook.DID)
+}
+
+// ownedWebhook reads the ?id= target of a POST, writing an error response if
+// it isn't one of the user's
+func (h *WebhookHandler) ownedWebhook(w http.ResponseWriter, r *http.Request) (*models.Webhook, bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return nil, false
+	}
+
+	sess, ok := session.GetSession(r)
+	if !ok || sess == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return nil, false
+	}
+	hook, err := h.repo.GetWebhook(sess.DID, id)
+	if err != nil {
+		log.Printf("Failed to get webhook: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil, false
+	}
+	if hook == nil {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return nil, false
+	}
+	return hook, true
+}
+
+// writeList writes the user's targets as JSON
+func (h *WebhookHandler) writeList(w http.ResponseWriter, did string) {
+	hooks, err := h.repo.GetWebhooksByDID(did)
+	if err != nil {
+		log.Printf("Failed to get webhooks: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if hooks == nil {
+		hooks = []*models.Webhook{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"webhooks":    hooks,
+		"maxWebhooks": MaxWebhooksPerUser,
+		"maxFailures": webhook.MaxConsecutiveFailures,
+	})
+}
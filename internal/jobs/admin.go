@@ -0,0 +1,106 @@
+package jobs
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/shindakun/attodo/internal/database"
+	"github.com/shindakun/attodo/internal/models"
+)
+
+// recentRunsLimit is how many past runs the status endpoint lists
+const recentRunsLimit = 50
+
+// AdminHandler serves job status and manual triggers to operators holding
+// the admin token
+type AdminHandler struct {
+	runner *Runner
+	runs   *database.JobRunRepo
+	token  string
+}
+
+// NewAdminHandler creates the job admin handler. Requests must carry the
+// token as a bearer token.
+func NewAdminHandler(runner *Runner, runs *database.JobRunRepo, token string) *AdminHandler {
+	return &AdminHandler{
+		runner: runner,
+		runs:   runs,
+		token:  token,
+	}
+}
+
+// HandleStatus handles GET /admin/jobs: every job's schedule and latest run,
+// plus the most recent run history
+func (h *AdminHandler) HandleStatus(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(w, r) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	runs, err := h.runs.GetRecentJobRuns(r.URL.Query().Get("job"), recentRunsLimit)
+	if err != nil {
+		log.Printf("Failed to get job runs: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if runs == nil {
+		runs = []*models.JobRun{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"jobs": h.runner.Status(),
+		"runs": runs,
+	})
+}
+
+// HandleRun handles POST /admin/jobs/run?job=, starting a job now
+func (h *AdminHandler) HandleRun(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(w, r) {
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := r.URL.Query().Get("job")
+	err := h.runner.Trigger(name)
+	switch {
+	case errors.Is(err, ErrUnknownJob):
+		http.Error(w, "Unknown job", http.StatusNotFound)
+		return
+	case errors.Is(err, ErrJobRunning):
+		http.Error(w, "Job is already running", http.StatusConflict)
+		return
+	case errors.Is(err, ErrNotLeader):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	log.Printf("Job %s triggered manually", name)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{"started": name})
+}
+
+// authorized checks the bearer token, writing an error response if it's
+// missing or wrong
+func (h *AdminHandler) authorized(w http.ResponseWriter, r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
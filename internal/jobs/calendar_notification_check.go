@@ -8,11 +8,10 @@ import (
 	"net/http"
 	"time"
 
-	"github.com/bluesky-social/indigo/atproto/identity"
-	"github.com/bluesky-social/indigo/atproto/syntax"
 	"github.com/shindakun/attodo/internal/database"
 	"github.com/shindakun/attodo/internal/handlers"
 	"github.com/shindakun/attodo/internal/models"
+	"github.com/shindakun/attodo/internal/notify"
 	"github.com/shindakun/attodo/internal/push"
 	"github.com/shindakun/bskyoauth"
 )
@@ -21,17 +20,17 @@ import (
 type CalendarNotificationJob struct {
 	repo            *database.NotificationRepo
 	client          *bskyoauth.Client
-	sender          *push.Sender
+	notifier        *notify.Dispatcher
 	calendarHandler *handlers.CalendarHandler
 	settingsHandler *handlers.SettingsHandler
 }
 
 // NewCalendarNotificationJob creates a new calendar notification job
-func NewCalendarNotificationJob(repo *database.NotificationRepo, client *bskyoauth.Client, sender *push.Sender, settingsHandler *handlers.SettingsHandler) *CalendarNotificationJob {
+func NewCalendarNotificationJob(repo *database.NotificationRepo, client *bskyoauth.Client, notifier *notify.Dispatcher, settingsHandler *handlers.SettingsHandler) *CalendarNotificationJob {
 	return &CalendarNotificationJob{
 		repo:            repo,
 		client:          client,
-		sender:          sender,
+		notifier:        notifier,
 		calendarHandler: handlers.NewCalendarHandler(client),
 		settingsHandler: settingsHandler,
 	}
@@ -57,43 +56,71 @@ func (c *CalendarNotificationJob) Run(ctx context.Context) error {
 
 	log.Printf("[CalendarNotificationCheck] Checking calendar events for %d user(s)", len(users))
 
-	// Check events for each user
-	for _, user := range users {
-		if err := c.checkUserEvents(ctx, user); err != nil {
-			log.Printf("[CalendarNotificationCheck] Error checking events for %s: %v", user.DID, err)
-			// Continue to next user instead of failing the whole job
-			continue
-		}
-	}
+	// Check users concurrently; one user's error doesn't fail the whole job
+	stats := fanOut(ctx, fanOutWorkers, users, c.checkUserEvents, func(user *models.NotificationUser, err error) {
+		log.Printf("[CalendarNotificationCheck] Error checking events for %s: %v", user.DID, err)
+	})
+	log.Printf("[CalendarNotificationCheck] Run %s", stats)
 
-	return nil
+	return ctx.Err()
 }
 
 // checkUserEvents checks calendar events for a single user and sends notifications
 func (c *CalendarNotificationJob) checkUserEvents(ctx context.Context, user *models.NotificationUser) error {
-	// Get user's push subscriptions
-	subscriptions, err := c.repo.GetPushSubscriptionsByDID(user.DID)
+	// Read from the user's PDS within its concurrency limit
+	pds, release, err := acquirePDS(ctx, user.DID)
 	if err != nil {
-		return fmt.Errorf("failed to get push subscriptions: %w", err)
+		return err
+	}
+	events, recipient, leadTime, err := c.readUserEvents(ctx, pds, user)
+	release()
+	if err != nil || len(events) == 0 {
+		return err
 	}
 
-	if len(subscriptions) == 0 {
-		log.Printf("[CalendarNotificationCheck] User %s has no push subscriptions", user.DID)
-		return nil
+	log.Printf("[CalendarNotificationCheck] Found %d upcoming events for %s", len(events), user.DID)
+
+	// Send notifications for events
+	for _, event := range events {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := c.sendEventNotification(ctx, recipient, event, leadTime); err != nil {
+			log.Printf("WARNING: Failed to send notification for event %s: %v", event.RKey, err)
+			continue
+		}
 	}
 
+	return nil
+}
+
+// readUserEvents reads a user's settings and upcoming events from their PDS,
+// returning no events if the user can't be reached or has calendar
+// notifications off
+func (c *CalendarNotificationJob) readUserEvents(ctx context.Context, pds string, user *models.NotificationUser) ([]*models.CalendarEvent, *notify.Recipient, time.Duration, error) {
 	// Get user's calendar notification settings
-	settings, err := c.getUserSettings(ctx, user.DID)
+	settings, err := c.getUserSettings(ctx, pds, user.DID)
 	if err != nil {
 		log.Printf("[CalendarNotificationCheck] Failed to get settings for %s: %v", user.DID, err)
 		// Continue with defaults
 		settings = models.DefaultNotificationSettings()
 	}
 
+	// Get the channels that can reach the user
+	recipient, err := c.notifier.RecipientWithSettings(ctx, user.DID, settings)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("failed to resolve notification channels: %w", err)
+	}
+
+	if !recipient.Reachable() {
+		log.Printf("[CalendarNotificationCheck] User %s has no notification channels", user.DID)
+		return nil, nil, 0, nil
+	}
+
 	// Skip if calendar notifications are disabled
 	if !settings.CalendarNotificationsEnabled {
 		log.Printf("[CalendarNotificationCheck] Calendar notifications disabled for %s", user.DID)
-		return nil
+		return nil, nil, 0, nil
 	}
 
 	// Get notification lead time (default 1 hour)
@@ -106,36 +133,16 @@ func (c *CalendarNotificationJob) checkUserEvents(ctx context.Context, user *mod
 	}
 
 	// Fetch upcoming events within the lead time window
-	events, err := c.fetchUpcomingEventsForUser(ctx, user.DID, leadTime)
+	events, err := c.fetchUpcomingEventsForUser(ctx, pds, user.DID, leadTime)
 	if err != nil {
-		return fmt.Errorf("failed to fetch upcoming events: %w", err)
+		return nil, nil, 0, fmt.Errorf("failed to fetch upcoming events: %w", err)
 	}
 
-	if len(events) == 0 {
-		return nil // No upcoming events
-	}
-
-	log.Printf("[CalendarNotificationCheck] Found %d upcoming events for %s", len(events), user.DID)
-
-	// Send notifications for events
-	for _, event := range events {
-		if err := c.sendEventNotification(ctx, user.DID, event, leadTime, subscriptions); err != nil {
-			log.Printf("WARNING: Failed to send notification for event %s: %v", event.RKey, err)
-			continue
-		}
-	}
-
-	return nil
+	return events, recipient, leadTime, nil
 }
 
 // getUserSettings fetches user settings without requiring a session (public read)
-func (c *CalendarNotificationJob) getUserSettings(ctx context.Context, did string) (*models.NotificationSettings, error) {
-	// Resolve PDS endpoint for this DID
-	pds, err := c.resolvePDSEndpoint(ctx, did)
-	if err != nil {
-		return nil, fmt.Errorf("failed to resolve PDS endpoint: %w", err)
-	}
-
+func (c *CalendarNotificationJob) getUserSettings(ctx context.Context, pds, did string) (*models.NotificationSettings, error) {
 	// Build the XRPC URL for public read (no auth needed)
 	url := fmt.Sprintf("%s/xrpc/com.atproto.repo.getRecord?repo=%s&collection=%s&rkey=%s",
 		pds, did, handlers.SettingsCollection, handlers.SettingsRKey)
@@ -147,8 +154,7 @@ func (c *CalendarNotificationJob) getUserSettings(ctx context.Context, did strin
 	}
 
 	// Make request (no auth needed for public reads)
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := pdsClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -174,13 +180,7 @@ func (c *CalendarNotificationJob) getUserSettings(ctx context.Context, did strin
 }
 
 // fetchUpcomingEventsForUser fetches events for a user without requiring a session (public read)
-func (c *CalendarNotificationJob) fetchUpcomingEventsForUser(ctx context.Context, did string, within time.Duration) ([]*models.CalendarEvent, error) {
-	// Resolve PDS endpoint for this DID
-	pds, err := c.resolvePDSEndpoint(ctx, did)
-	if err != nil {
-		return nil, fmt.Errorf("failed to resolve PDS endpoint: %w", err)
-	}
-
+func (c *CalendarNotificationJob) fetchUpcomingEventsForUser(ctx context.Context, pds, did string, within time.Duration) ([]*models.CalendarEvent, error) {
 	// Build the XRPC URL for public read (no auth needed)
 	url := fmt.Sprintf("%s/xrpc/com.atproto.repo.listRecords?repo=%s&collection=%s",
 		pds, did, handlers.CalendarEventCollection)
@@ -192,8 +192,7 @@ func (c *CalendarNotificationJob) fetchUpcomingEventsForUser(ctx context.Context
 	}
 
 	// Make request (no auth needed for public reads)
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := pdsClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -234,24 +233,10 @@ func (c *CalendarNotificationJob) fetchUpcomingEventsForUser(ctx context.Context
 	return upcomingEvents, nil
 }
 
-// resolvePDSEndpoint resolves the PDS endpoint for a given DID
-func (c *CalendarNotificationJob) resolvePDSEndpoint(ctx context.Context, did string) (string, error) {
-	dir := identity.DefaultDirectory()
-	atid, err := syntax.ParseAtIdentifier(did)
-	if err != nil {
-		return "", err
-	}
-
-	ident, err := dir.Lookup(ctx, *atid)
-	if err != nil {
-		return "", err
-	}
-
-	return ident.PDSEndpoint(), nil
-}
-
 // sendEventNotification sends a notification for an event
-func (c *CalendarNotificationJob) sendEventNotification(ctx context.Context, did string, event *models.CalendarEvent, leadTime time.Duration, subscriptions []*models.PushSubscription) error {
+func (c *CalendarNotificationJob) sendEventNotification(ctx context.Context, recipient *notify.Recipient, event *models.CalendarEvent, leadTime time.Duration) error {
+	did := recipient.DID
+
 	// Check if we've already sent a notification for this event
 	eventURI := event.URI
 	recent, err := c.repo.GetRecentNotification(did, eventURI, 24) // Don't spam within 24 hours
@@ -281,38 +266,8 @@ func (c *CalendarNotificationJob) sendEventNotification(ctx context.Context, did
 		},
 	}
 
-	// Send to all subscriptions
-	successCount, errors := c.sender.SendToAll(subscriptions, notification)
-	log.Printf("INFO: Sent calendar notification for event %s to %d/%d subscriptions", event.RKey, successCount, len(subscriptions))
-
-	// Record notification history
-	status := "sent"
-	var errMsg string
-	if successCount == 0 {
-		status = "failed"
-		if len(errors) > 0 {
-			errMsg = fmt.Sprintf("%v", errors[0])
-		}
-	} else if len(errors) > 0 {
-		errMsg = fmt.Sprintf("Sent to %d/%d subscriptions. Errors: %v", successCount, len(subscriptions), errors[0])
-	}
-
-	history := &models.NotificationHistory{
-		DID:              did,
-		TaskURI:          eventURI, // Reuse TaskURI field for event URI
-		NotificationType: "calendar_event",
-		Status:           status,
-		ErrorMessage:     errMsg,
-	}
-	if err := c.repo.CreateNotificationHistory(history); err != nil {
-		log.Printf("WARNING: Failed to create notification history: %v", err)
-	}
-
-	if successCount == 0 {
-		return fmt.Errorf("failed to send to all subscriptions: %v", errors)
-	}
-
-	return nil
+	// Send over all channels; the event URI goes in the history's task URI
+	return sendAndRecord(ctx, c.notifier, c.repo, recipient, notification, "calendar_event", eventURI)
 }
 
 // buildNotificationBody builds the notification message body
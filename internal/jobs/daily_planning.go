@@ -0,0 +1,218 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/shindakun/attodo/internal/database"
+	"github.com/shindakun/attodo/internal/handlers"
+	"github.com/shindakun/attodo/internal/models"
+	"github.com/shindakun/attodo/internal/notify"
+	"github.com/shindakun/attodo/internal/push"
+)
+
+// planningNudgeWindow is how long after a planning time a missed nudge is
+// still sent (e.g. after a restart); later than that it's skipped for the day
+const planningNudgeWindow = 2 * time.Hour
+
+// DailyPlanningJob nudges users to plan their day at their planning time and
+// sends a summary of the day's plan at their review time
+type DailyPlanningJob struct {
+	repo            *database.NotificationRepo
+	planHandler     *handlers.PlanHandler
+	settingsHandler *handlers.SettingsHandler
+	notifier        *notify.Dispatcher
+}
+
+// NewDailyPlanningJob creates a new daily planning job
+func NewDailyPlanningJob(repo *database.NotificationRepo, planHandler *handlers.PlanHandler, settingsHandler *handlers.SettingsHandler, notifier *notify.Dispatcher) *DailyPlanningJob {
+	return &DailyPlanningJob{
+		repo:            repo,
+		planHandler:     planHandler,
+		settingsHandler: settingsHandler,
+		notifier:        notifier,
+	}
+}
+
+// Name returns the job name
+func (j *DailyPlanningJob) Name() string {
+	return "DailyPlanning"
+}
+
+// Run executes the daily planning check
+func (j *DailyPlanningJob) Run(ctx context.Context) error {
+	users, err := j.repo.GetEnabledNotificationUsers()
+	if err != nil {
+		return fmt.Errorf("failed to get enabled users: %w", err)
+	}
+
+	for _, user := range users {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := j.checkUser(ctx, user.DID); err != nil {
+			log.Printf("[DailyPlanning] Error checking plan for %s: %v", user.DID, err)
+			continue
+		}
+	}
+
+	return nil
+}
+
+// checkUser sends whichever of the user's planning notifications are due
+func (j *DailyPlanningJob) checkUser(ctx context.Context, did string) error {
+	settings, err := j.settingsHandler.FetchSettings(ctx, did)
+	if err != nil {
+		return fmt.Errorf("failed to get settings: %w", err)
+	}
+
+	if settings.PlanningTime == "" && settings.ReviewTime == "" {
+		return nil
+	}
+
+	loc := models.UserLocation(settings.Timezone)
+	now := time.Now()
+	date := models.PlanDate(now, loc)
+
+	due := make([]string, 0, 2)
+	if planningTimeDue(now, settings.PlanningTime, loc) {
+		due = append(due, models.NotificationTypePlanNudge)
+	}
+	if planningTimeDue(now, settings.ReviewTime, loc) {
+		due = append(due, models.NotificationTypePlanSummary)
+	}
+	if len(due) == 0 {
+		return nil
+	}
+
+	recipient, err := j.notifier.RecipientWithSettings(ctx, did, settings)
+	if err != nil {
+		return fmt.Errorf("failed to resolve notification channels: %w", err)
+	}
+	if !recipient.Reachable() {
+		return nil
+	}
+
+	var planning *models.DayPlanning
+	for _, kind := range due {
+		// One of each per day: the history key names the day's plan
+		key := fmt.Sprintf("at://%s/%s/%s#%s", did, handlers.PlanCollection, date, kind)
+		recent, err := j.repo.GetRecentNotification(did, key, 24)
+		if err != nil {
+			return fmt.Errorf("failed to check notification history: %w", err)
+		}
+		if recent != nil {
+			continue
+		}
+
+		if planning == nil {
+			planning, err = j.planHandler.FetchDayPlanning(ctx, did, date)
+			if err != nil {
+				return fmt.Errorf("failed to load plan: %w", err)
+			}
+		}
+
+		var notification *push.Notification
+		if kind == models.NotificationTypePlanNudge {
+			notification = buildPlanNudge(planning)
+		} else {
+			notification = buildPlanSummary(planning)
+		}
+		if notification == nil {
+			continue
+		}
+
+		if err := sendAndRecord(ctx, j.notifier, j.repo, recipient, notification, kind, key); err != nil {
+			log.Printf("[DailyPlanning] Failed to send %s to %s: %v", kind, did, err)
+		}
+	}
+
+	return nil
+}
+
+// planningTimeDue reports whether an "HH:MM" time passed within the nudge window
+func planningTimeDue(now time.Time, clock string, loc *time.Location) bool {
+	at, ok := models.AtClock(now, clock, loc)
+	if !ok {
+		return false
+	}
+	return !now.Before(at) && now.Sub(at) < planningNudgeWindow
+}
+
+// buildPlanNudge reminds the user of today's plan, or asks them to make one.
+// Returns nil when there is nothing to plan.
+func buildPlanNudge(planning *models.DayPlanning) *push.Notification {
+	var title, body string
+
+	if unfinished := planning.Plan.Unfinished(); len(unfinished) > 0 {
+		title = fmt.Sprintf("Today's plan: %d task%s", len(unfinished), pluralize(len(unfinished)))
+		body = buildTaskList(unfinished, 3)
+	} else if len(planning.Plan.Tasks) == 0 {
+		parts := make([]string, 0, 3)
+		if n := len(planning.Overdue); n > 0 {
+			parts = append(parts, fmt.Sprintf("%d overdue", n))
+		}
+		if n := len(planning.DueToday); n > 0 {
+			parts = append(parts, fmt.Sprintf("%d due today", n))
+		}
+		if n := len(planning.CarryOver); n > 0 {
+			parts = append(parts, fmt.Sprintf("%d unfinished from %s", n, planning.Previous.Date))
+		}
+		if len(parts) == 0 && len(planning.Suggest) == 0 {
+			return nil
+		}
+
+		title = "Plan your day"
+		body = strings.Join(parts, " • ")
+		if body == "" {
+			body = "Pick what you'll work on today."
+		}
+	} else {
+		// Everything planned is already done
+		return nil
+	}
+
+	return &push.Notification{
+		Title: title,
+		Body:  body,
+		Icon:  "/static/icon-192.png",
+		Badge: "/static/icon-192.png",
+		Tag:   "daily-plan",
+		Data: map[string]interface{}{
+			"type": models.NotificationTypePlanNudge,
+			"date": planning.Date,
+			"url":  "/app#today",
+		},
+	}
+}
+
+// buildPlanSummary sums up the day's plan. Returns nil when nothing was planned.
+func buildPlanSummary(planning *models.DayPlanning) *push.Notification {
+	plan := planning.Plan
+	if len(plan.Tasks) == 0 {
+		return nil
+	}
+
+	title := fmt.Sprintf("Today: %d of %d done", plan.CompletedCount(), len(plan.Tasks))
+	body := "Everything you planned is done. Nice work!"
+	if unfinished := plan.Unfinished(); len(unfinished) > 0 {
+		body = "Still open (you can carry these over tomorrow):\n" + buildTaskList(unfinished, 3)
+	}
+
+	return &push.Notification{
+		Title: title,
+		Body:  body,
+		Icon:  "/static/icon-192.png",
+		Badge: "/static/icon-192.png",
+		Tag:   "daily-plan-summary",
+		Data: map[string]interface{}{
+			"type":  models.NotificationTypePlanSummary,
+			"date":  planning.Date,
+			"count": plan.CompletedCount(),
+			"url":   "/app#today",
+		},
+	}
+}
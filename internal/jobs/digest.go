@@ -0,0 +1,195 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/shindakun/attodo/internal/database"
+	"github.com/shindakun/attodo/internal/handlers"
+	"github.com/shindakun/attodo/internal/models"
+	"github.com/shindakun/attodo/internal/notify"
+	"github.com/shindakun/attodo/internal/push"
+)
+
+// DigestJob sends opted-in users a daily digest of today's tasks and events,
+// and a weekly digest on Sundays, at their digest time
+type DigestJob struct {
+	repo            *database.NotificationRepo
+	digestHandler   *handlers.DigestHandler
+	settingsHandler *handlers.SettingsHandler
+	notifier        *notify.Dispatcher
+}
+
+// NewDigestJob creates a new digest job
+func NewDigestJob(repo *database.NotificationRepo, digestHandler *handlers.DigestHandler, settingsHandler *handlers.SettingsHandler, notifier *notify.Dispatcher) *DigestJob {
+	return &DigestJob{
+		repo:            repo,
+		digestHandler:   digestHandler,
+		settingsHandler: settingsHandler,
+		notifier:        notifier,
+	}
+}
+
+// Name returns the job name
+func (j *DigestJob) Name() string {
+	return "Digest"
+}
+
+// Run executes the digest check
+func (j *DigestJob) Run(ctx context.Context) error {
+	users, err := j.repo.GetEnabledNotificationUsers()
+	if err != nil {
+		return fmt.Errorf("failed to get enabled users: %w", err)
+	}
+
+	for _, user := range users {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := j.checkUser(ctx, user.DID); err != nil {
+			log.Printf("[Digest] Error checking digests for %s: %v", user.DID, err)
+			continue
+		}
+	}
+
+	return nil
+}
+
+// checkUser sends whichever of the user's digests are due
+func (j *DigestJob) checkUser(ctx context.Context, did string) error {
+	settings, err := j.settingsHandler.FetchSettings(ctx, did)
+	if err != nil {
+		return fmt.Errorf("failed to get settings: %w", err)
+	}
+
+	if !settings.DigestDaily && !settings.DigestWeekly {
+		return nil
+	}
+
+	// Without a set time, digests go out at the start of the user's first
+	// habitually active hour
+	clock := settings.DigestTime
+	if clock == "" {
+		clock = settings.SmartClock(models.DefaultDigestTime)
+	}
+	loc := models.UserLocation(settings.Timezone)
+	now := time.Now()
+	if !planningTimeDue(now, clock, loc) {
+		return nil
+	}
+
+	due := make([]string, 0, 2)
+	if settings.DigestDaily {
+		due = append(due, models.DigestDaily)
+	}
+	if settings.DigestWeekly && now.In(loc).Weekday() == time.Sunday {
+		due = append(due, models.DigestWeekly)
+	}
+	if len(due) == 0 {
+		return nil
+	}
+
+	recipient, err := j.notifier.RecipientWithSettings(ctx, did, settings)
+	if err != nil {
+		return fmt.Errorf("failed to resolve notification channels: %w", err)
+	}
+	if !recipient.Reachable() {
+		return nil
+	}
+
+	date := models.PlanDate(now, loc)
+	for _, kind := range due {
+		// One of each per day: the history key names the digest and its date
+		key := fmt.Sprintf("digest:%s:%s", kind, date)
+		recent, err := j.repo.GetRecentNotification(did, key, 24)
+		if err != nil {
+			return fmt.Errorf("failed to check notification history: %w", err)
+		}
+		if recent != nil {
+			continue
+		}
+
+		digest, err := j.digestHandler.FetchDigest(ctx, did, kind, settings.DigestView, now, loc)
+		if err != nil {
+			return fmt.Errorf("failed to load %s digest: %w", kind, err)
+		}
+		if digest.IsEmpty() {
+			continue
+		}
+
+		notification := buildDigestNotification(digest)
+
+		if err := sendAndRecord(ctx, j.notifier, j.repo, recipient, notification, digestNotificationType(kind), key); err != nil {
+			log.Printf("[Digest] Failed to send %s digest to %s: %v", kind, did, err)
+		}
+	}
+
+	return nil
+}
+
+// buildDigestNotification sums up a digest, linking to the full digest page;
+// channels that can show the whole digest get it attached
+func buildDigestNotification(digest *models.Digest) *push.Notification {
+	var title, tag string
+	parts := make([]string, 0, 3)
+
+	if digest.Kind == models.DigestWeekly {
+		title = "Your week in review"
+		tag = "digest-weekly"
+		parts = append(parts, fmt.Sprintf("%d completed", len(digest.Completed)))
+		if n := len(digest.Slipped); n > 0 {
+			parts = append(parts, fmt.Sprintf("%d slipped", n))
+		}
+		parts = append(parts, fmt.Sprintf("%d coming up next week", digest.WeekLoad()))
+	} else {
+		title = "Your day ahead"
+		tag = "digest-daily"
+		if n := len(digest.Events); n > 0 {
+			parts = append(parts, fmt.Sprintf("%d event%s", n, pluralize(n)))
+		}
+		if n := len(digest.DueToday); n > 0 {
+			parts = append(parts, fmt.Sprintf("%d due today", n))
+		}
+		if n := len(digest.Overdue); n > 0 {
+			parts = append(parts, fmt.Sprintf("%d overdue", n))
+		}
+	}
+
+	body := strings.Join(parts, " • ")
+	if digest.Kind == models.DigestDaily {
+		for i, event := range digest.Events {
+			if i >= 2 {
+				break
+			}
+			body += fmt.Sprintf("\n📅 %s %s", digest.Clock(event.StartsAt), event.Name)
+		}
+		if len(digest.DueToday) > 0 {
+			body += "\n" + strings.TrimSuffix(buildTaskList(digest.DueToday, 3), "\n")
+		}
+	}
+
+	return &push.Notification{
+		Title: title,
+		Body:  body,
+		Icon:  "/static/icon-192.png",
+		Badge: "/static/icon-192.png",
+		Tag:   tag,
+		Data: map[string]interface{}{
+			"type": digestNotificationType(digest.Kind),
+			"date": digest.Date,
+			"url":  "/app/digest?kind=" + digest.Kind,
+		},
+		Digest: digest,
+	}
+}
+
+// digestNotificationType returns the notification history type of a digest kind
+func digestNotificationType(kind string) string {
+	if kind == models.DigestWeekly {
+		return models.NotificationTypeDigestWeekly
+	}
+	return models.NotificationTypeDigestDaily
+}
@@ -0,0 +1,199 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/identity"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/shindakun/attodo/internal/models"
+)
+
+const (
+	// fanOutWorkers is how many users a notification job checks at once
+	fanOutWorkers = 16
+
+	// perPDSConcurrency is how many users on the same PDS are read at once,
+	// across all jobs
+	perPDSConcurrency = 4
+)
+
+var (
+	// directory resolves DIDs for the jobs; it's shared so its cache is too
+	directory = identity.DefaultDirectory()
+
+	// pdsClient reads public records from users' PDSes
+	pdsClient = &http.Client{Timeout: 10 * time.Second}
+
+	// pdsSlots limits concurrent reads per PDS for every job
+	pdsSlots = newPDSLimiter(perPDSConcurrency)
+)
+
+// RunStats describes one run of a job over its users
+type RunStats struct {
+	Users        int           // Users the run was given
+	Processed    int           // Users checked, whether or not they failed
+	Failed       int           // Users whose check returned an error
+	Duration     time.Duration // Wall time of the whole run
+	TotalLatency time.Duration // Sum of the time spent on each user
+	MaxLatency   time.Duration // Slowest single user
+}
+
+// AvgLatency returns the average time spent on a user
+func (s RunStats) AvgLatency() time.Duration {
+	if s.Processed == 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(s.Processed)
+}
+
+func (s RunStats) String() string {
+	return fmt.Sprintf("processed %d/%d user(s) in %s (%d failed, avg %s, max %s per user)",
+		s.Processed, s.Users, s.Duration.Round(time.Millisecond), s.Failed,
+		s.AvgLatency().Round(time.Millisecond), s.MaxLatency.Round(time.Millisecond))
+}
+
+// fanOut calls check for each user on a pool of workers. Once ctx is
+// cancelled no more users are started; the users not reached are left out
+// of Processed.
+func fanOut(ctx context.Context, workers int, users []*models.NotificationUser, check func(context.Context, *models.NotificationUser) error, onError func(*models.NotificationUser, error)) RunStats {
+	stats := RunStats{Users: len(users)}
+	start := time.Now()
+
+	queue := make(chan *models.NotificationUser)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	if workers > len(users) {
+		workers = len(users)
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for user := range queue {
+				userStart := time.Now()
+				err := check(ctx, user)
+				latency := time.Since(userStart)
+				if err != nil && onError != nil {
+					onError(user, err)
+				}
+
+				mu.Lock()
+				stats.Processed++
+				stats.TotalLatency += latency
+				if latency > stats.MaxLatency {
+					stats.MaxLatency = latency
+				}
+				if err != nil {
+					stats.Failed++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, user := range users {
+		select {
+		case queue <- user:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	stats.Duration = time.Since(start)
+	return stats
+}
+
+// pdsLimiter caps concurrent requests to each PDS, so a large run doesn't
+// hammer the hosts many users share. A PDS's slots are dropped once nobody
+// holds or waits for them, so the map only holds the PDSes in use.
+type pdsLimiter struct {
+	limit int
+	mu    sync.Mutex
+	slots map[string]*pdsSlot
+}
+
+// pdsSlot is one PDS's slots and the number of callers holding or waiting
+// for them
+type pdsSlot struct {
+	ch    chan struct{}
+	users int
+}
+
+func newPDSLimiter(limit int) *pdsLimiter {
+	return &pdsLimiter{
+		limit: limit,
+		slots: make(map[string]*pdsSlot),
+	}
+}
+
+// acquire waits for a free slot on a PDS and returns the function that frees
+// it, or ctx's error if cancelled first
+func (l *pdsLimiter) acquire(ctx context.Context, pds string) (func(), error) {
+	l.mu.Lock()
+	slots, ok := l.slots[pds]
+	if !ok {
+		slots = &pdsSlot{ch: make(chan struct{}, l.limit)}
+		l.slots[pds] = slots
+	}
+	slots.users++
+	l.mu.Unlock()
+
+	select {
+	case slots.ch <- struct{}{}:
+		return func() {
+			<-slots.ch
+			l.leave(pds, slots)
+		}, nil
+	case <-ctx.Done():
+		l.leave(pds, slots)
+		return nil, ctx.Err()
+	}
+}
+
+// leave drops a caller from a PDS's slots, forgetting the PDS after the last
+func (l *pdsLimiter) leave(pds string, slots *pdsSlot) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	slots.users--
+	if slots.users == 0 {
+		delete(l.slots, pds)
+	}
+}
+
+// resolvePDS resolves the PDS endpoint for a DID
+func resolvePDS(ctx context.Context, did string) (string, error) {
+	atid, err := syntax.ParseAtIdentifier(did)
+	if err != nil {
+		return "", err
+	}
+
+	ident, err := directory.Lookup(ctx, *atid)
+	if err != nil {
+		return "", err
+	}
+
+	return ident.PDSEndpoint(), nil
+}
+
+// acquirePDS resolves a user's PDS and waits for a slot on it. The caller
+// must call release once done reading from the PDS.
+func acquirePDS(ctx context.Context, did string) (pds string, release func(), err error) {
+	pds, err = resolvePDS(ctx, did)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to resolve PDS endpoint: %w", err)
+	}
+	release, err = pdsSlots.acquire(ctx, pds)
+	if err != nil {
+		return "", nil, err
+	}
+	return pds, release, nil
+}
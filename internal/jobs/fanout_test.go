@@ -0,0 +1,160 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shindakun/attodo/internal/models"
+)
+
+func testUsers(n int) []*models.NotificationUser {
+	users := make([]*models.NotificationUser, n)
+	for i := range users {
+		users[i] = &models.NotificationUser{DID: fmt.Sprintf("did:plc:user%d", i)}
+	}
+	return users
+}
+
+// concurrency tracks how many calls are running at once
+type concurrency struct {
+	current, max atomic.Int32
+}
+
+func (c *concurrency) enter() {
+	n := c.current.Add(1)
+	for {
+		max := c.max.Load()
+		if n <= max || c.max.CompareAndSwap(max, n) {
+			return
+		}
+	}
+}
+
+func (c *concurrency) leave() {
+	c.current.Add(-1)
+}
+
+func TestFanOutWorkerLimit(t *testing.T) {
+	var running concurrency
+	stats := fanOut(context.Background(), 3, testUsers(20), func(ctx context.Context, user *models.NotificationUser) error {
+		running.enter()
+		defer running.leave()
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	}, nil)
+
+	if max := running.max.Load(); max > 3 {
+		t.Errorf("Expected at most 3 users checked at once, got %d", max)
+	}
+	if stats.Users != 20 || stats.Processed != 20 || stats.Failed != 0 {
+		t.Errorf("Expected 20 users processed without failures, got %+v", stats)
+	}
+	if stats.MaxLatency < 5*time.Millisecond || stats.AvgLatency() < 5*time.Millisecond {
+		t.Errorf("Expected latencies of at least 5ms, got %s", stats)
+	}
+}
+
+func TestFanOutErrors(t *testing.T) {
+	users := testUsers(10)
+	failing := errors.New("check failed")
+
+	var mu sync.Mutex
+	reported := map[string]error{}
+	stats := fanOut(context.Background(), 4, users, func(ctx context.Context, user *models.NotificationUser) error {
+		if user == users[2] || user == users[7] {
+			return failing
+		}
+		return nil
+	}, func(user *models.NotificationUser, err error) {
+		mu.Lock()
+		reported[user.DID] = err
+		mu.Unlock()
+	})
+
+	if stats.Processed != 10 || stats.Failed != 2 {
+		t.Errorf("Expected 10 processed and 2 failed, got %+v", stats)
+	}
+	if len(reported) != 2 || reported[users[2].DID] != failing || reported[users[7].DID] != failing {
+		t.Errorf("Expected the two failures reported, got %v", reported)
+	}
+}
+
+func TestFanOutCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var checked atomic.Int32
+	stats := fanOut(ctx, 1, testUsers(100), func(ctx context.Context, user *models.NotificationUser) error {
+		if checked.Add(1) == 3 {
+			cancel()
+		}
+		return nil
+	}, nil)
+
+	if stats.Processed < 3 || stats.Processed >= 100 {
+		t.Errorf("Expected the run to stop soon after cancelling, processed %d", stats.Processed)
+	}
+	if stats.Processed != int(checked.Load()) {
+		t.Errorf("Expected Processed to count checked users, got %d of %d", stats.Processed, checked.Load())
+	}
+}
+
+func TestPDSLimiter(t *testing.T) {
+	limiter := newPDSLimiter(2)
+
+	var wg sync.WaitGroup
+	running := map[string]*concurrency{"https://a.example": {}, "https://b.example": {}}
+	for pds, c := range running {
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				release, err := limiter.acquire(context.Background(), pds)
+				if err != nil {
+					t.Errorf("acquire failed: %v", err)
+					return
+				}
+				c.enter()
+				time.Sleep(2 * time.Millisecond)
+				c.leave()
+				release()
+			}()
+		}
+	}
+	wg.Wait()
+
+	for pds, c := range running {
+		if max := c.max.Load(); max > 2 {
+			t.Errorf("Expected at most 2 requests to %s at once, got %d", pds, max)
+		}
+	}
+
+	// A cancelled wait gives up without taking a slot
+	first, _ := limiter.acquire(context.Background(), "https://a.example")
+	second, _ := limiter.acquire(context.Background(), "https://a.example")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.acquire(ctx, "https://a.example"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the wait to be cancelled, got %v", err)
+	}
+
+	// Only PDSes in use are kept
+	limiter.mu.Lock()
+	if len(limiter.slots) != 1 || limiter.slots["https://a.example"] == nil {
+		t.Errorf("Expected only the PDS in use to be kept, got %d", len(limiter.slots))
+	}
+	limiter.mu.Unlock()
+
+	first()
+	second()
+	limiter.mu.Lock()
+	if len(limiter.slots) != 0 {
+		t.Errorf("Expected idle PDSes to be forgotten, got %d", len(limiter.slots))
+	}
+	limiter.mu.Unlock()
+}
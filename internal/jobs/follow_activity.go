@@ -0,0 +1,173 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/shindakun/attodo/internal/database"
+	"github.com/shindakun/attodo/internal/handlers"
+	"github.com/shindakun/attodo/internal/models"
+	"github.com/shindakun/attodo/internal/notify"
+	"github.com/shindakun/attodo/internal/push"
+)
+
+// FollowActivityJob notifies followers when lists they follow gain or complete tasks
+type FollowActivityJob struct {
+	repo          *database.NotificationRepo
+	followRepo    *database.FollowRepo
+	followHandler *handlers.FollowHandler
+	notifier      *notify.Dispatcher
+}
+
+// NewFollowActivityJob creates a new follow activity job
+func NewFollowActivityJob(repo *database.NotificationRepo, followRepo *database.FollowRepo, followHandler *handlers.FollowHandler, notifier *notify.Dispatcher) *FollowActivityJob {
+	return &FollowActivityJob{
+		repo:          repo,
+		followRepo:    followRepo,
+		followHandler: followHandler,
+		notifier:      notifier,
+	}
+}
+
+// Name returns the job name
+func (j *FollowActivityJob) Name() string {
+	return "FollowActivity"
+}
+
+// Run executes the follow activity check
+func (j *FollowActivityJob) Run(ctx context.Context) error {
+	users, err := j.repo.GetEnabledNotificationUsers()
+	if err != nil {
+		return fmt.Errorf("failed to get enabled users: %w", err)
+	}
+
+	for _, user := range users {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := j.checkUserFollows(ctx, user.DID); err != nil {
+			log.Printf("[FollowActivity] Error checking follows for %s: %v", user.DID, err)
+			continue
+		}
+	}
+
+	return nil
+}
+
+// checkUserFollows checks every notifying follow of a single user
+func (j *FollowActivityJob) checkUserFollows(ctx context.Context, did string) error {
+	follows, err := j.followHandler.ListFollows(ctx, did)
+	if err != nil {
+		return fmt.Errorf("failed to list follows: %w", err)
+	}
+
+	if len(follows) == 0 {
+		return nil
+	}
+
+	recipient, err := j.notifier.Recipient(ctx, did)
+	if err != nil {
+		return fmt.Errorf("failed to resolve notification channels: %w", err)
+	}
+
+	if !recipient.Reachable() {
+		return nil
+	}
+
+	for _, follow := range follows {
+		if !follow.Notify {
+			// Forget progress so re-enabling doesn't replay old activity
+			if err := j.followRepo.DeleteState(did, follow.Subject); err != nil {
+				log.Printf("[FollowActivity] Failed to clear state for %s: %v", follow.Subject, err)
+			}
+			continue
+		}
+
+		if err := j.checkFollow(ctx, recipient, follow); err != nil {
+			log.Printf("[FollowActivity] Error checking %s for %s: %v", follow.Subject, did, err)
+		}
+	}
+
+	return nil
+}
+
+// checkFollow sends one notification summarizing new activity on a followed subject
+func (j *FollowActivityJob) checkFollow(ctx context.Context, recipient *notify.Recipient, follow *models.Follow) error {
+	did := recipient.DID
+
+	lastSeen, err := j.followRepo.GetLastSeen(did, follow.Subject)
+	if err != nil {
+		return err
+	}
+
+	// First time we see this follow: start tracking from now without notifying
+	// about everything that happened before the user followed
+	if lastSeen == nil {
+		return j.followRepo.SetLastSeen(did, follow.Subject, time.Now().UTC())
+	}
+
+	lists, err := j.followHandler.FollowedLists(ctx, follow)
+	if err != nil {
+		return fmt.Errorf("failed to fetch followed lists: %w", err)
+	}
+
+	items := models.BuildActivity(lists, *lastSeen, 0)
+	if len(items) == 0 {
+		return nil
+	}
+
+	notification := buildActivityNotification(items)
+
+	if err := sendAndRecord(ctx, j.notifier, j.repo, recipient, notification, models.NotificationTypeListActivity, follow.Subject); err != nil {
+		return err
+	}
+
+	// Items are newest first
+	return j.followRepo.SetLastSeen(did, follow.Subject, items[0].At)
+}
+
+// buildActivityNotification summarizes activity items into a single push
+func buildActivityNotification(items []*models.ActivityItem) *push.Notification {
+	listNames := make(map[string]bool)
+	for _, item := range items {
+		listNames[item.ListName] = true
+	}
+
+	title := fmt.Sprintf("%d update%s on %s", len(items), pluralize(len(items)), items[0].ListName)
+	if len(listNames) > 1 {
+		title = fmt.Sprintf("%d update%s on %d followed lists", len(items), pluralize(len(items)), len(listNames))
+	}
+
+	body := ""
+	for i, item := range items {
+		if i >= 3 {
+			body += fmt.Sprintf("\n...and %d more", len(items)-3)
+			break
+		}
+		verb := "Added"
+		if item.Kind == models.ActivityTaskCompleted {
+			verb = "Completed"
+		}
+		body += fmt.Sprintf("• %s: %s\n", verb, item.Task.Title)
+	}
+
+	url := "/app"
+	if len(listNames) == 1 && items[0].PublicURL() != "" {
+		url = items[0].PublicURL()
+	}
+
+	return &push.Notification{
+		Title: title,
+		Body:  body,
+		Icon:  "/static/icon-192.png",
+		Badge: "/static/icon-192.png",
+		Tag:   "list-activity",
+		Data: map[string]interface{}{
+			"type":  models.NotificationTypeListActivity,
+			"count": len(items),
+			"url":   url,
+		},
+	}
+}
@@ -0,0 +1,64 @@
+package jobs
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/shindakun/attodo/internal/database"
+)
+
+// DefaultLeaseTTL is how long a job lease lasts without renewal. The runner
+// renews its leases three times per TTL, so a dead instance's jobs move to
+// another instance within this long.
+const DefaultLeaseTTL = 30 * time.Second
+
+// Leases claims database leases for this server instance, so that when
+// several instances share the database each job, or each reminder, is run by
+// only one of them
+type Leases struct {
+	repo     *database.LeaseRepo
+	instance string
+}
+
+// NewLeases creates leases held under the given instance ID
+func NewLeases(repo *database.LeaseRepo, instance string) *Leases {
+	return &Leases{
+		repo:     repo,
+		instance: instance,
+	}
+}
+
+// DefaultInstanceID identifies this process among the instances sharing the
+// database: the host name and process ID
+func DefaultInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "attodo"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// Instance returns this instance's ID
+func (l *Leases) Instance() string {
+	return l.instance
+}
+
+// Claim takes or renews a lease for this instance and returns who holds it
+// afterwards; it's ours if the holder is Instance()
+func (l *Leases) Claim(name string, ttl time.Duration) (string, error) {
+	return l.repo.AcquireLease(name, l.instance, ttl)
+}
+
+// Release gives up a lease this instance holds
+func (l *Leases) Release(name string) {
+	if err := l.repo.ReleaseLease(name, l.instance); err != nil {
+		log.Printf("Failed to release lease %s: %v", name, err)
+	}
+}
+
+// jobLeaseName is the lease name for running a job
+func jobLeaseName(job string) string {
+	return "job:" + job
+}
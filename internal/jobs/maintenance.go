@@ -0,0 +1,103 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/shindakun/attodo/internal/database"
+)
+
+// Retention is how many days each kind of old data is kept before the
+// maintenance job deletes it. Zero keeps it forever.
+type Retention struct {
+	NotificationHistoryDays int // Notification history entries, by send time
+	PushSubscriptionDays    int // Push subscriptions with no delivery or re-registration
+	OrphanedUserDays        int // Notification users with no channel left, by creation time
+	ExpiredSupporterDays    int // Supporter records past their end date
+	JobRunDays              int // Finished background job runs
+}
+
+// MaintenanceJob deletes data past its retention, then lets SQLite refresh
+// its statistics and return free pages to the file system
+type MaintenanceJob struct {
+	db            *database.DB
+	repo          *database.NotificationRepo
+	supporterRepo *database.SupporterRepo
+	jobRuns       *database.JobRunRepo
+	retention     Retention
+}
+
+// NewMaintenanceJob creates a new database maintenance job
+func NewMaintenanceJob(db *database.DB, repo *database.NotificationRepo, supporterRepo *database.SupporterRepo, jobRuns *database.JobRunRepo, retention Retention) *MaintenanceJob {
+	return &MaintenanceJob{
+		db:            db,
+		repo:          repo,
+		supporterRepo: supporterRepo,
+		jobRuns:       jobRuns,
+		retention:     retention,
+	}
+}
+
+// Name returns the job name
+func (j *MaintenanceJob) Name() string {
+	return "Maintenance"
+}
+
+// Run executes the maintenance. A failed step is logged and the rest still
+// run; the run fails if any step did.
+func (j *MaintenanceJob) Run(ctx context.Context) error {
+	cleanups := []struct {
+		what    string
+		days    int
+		cleanup func(int) (int64, error)
+	}{
+		{"notification history entries", j.retention.NotificationHistoryDays, j.repo.CleanupOldHistory},
+		{"stale push subscriptions", j.retention.PushSubscriptionDays, j.repo.CleanupStalePushSubscriptions},
+		{"orphaned notification users", j.retention.OrphanedUserDays, j.repo.CleanupOrphanedNotificationUsers},
+		{"expired supporter records", j.retention.ExpiredSupporterDays, j.supporterRepo.CleanupExpired},
+		{"job runs", j.retention.JobRunDays, j.jobRuns.CleanupOldJobRuns},
+	}
+
+	var errs []error
+	for _, c := range cleanups {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if c.days <= 0 {
+			continue
+		}
+
+		count, err := c.cleanup(c.days)
+		if err != nil {
+			log.Printf("[Maintenance] %v", err)
+			errs = append(errs, err)
+			continue
+		}
+		log.Printf("[Maintenance] Deleted %d %s older than %d day(s)", count, c.what, c.days)
+	}
+
+	if err := j.db.Optimize(ctx); err != nil {
+		log.Printf("[Maintenance] %v", err)
+		errs = append(errs, err)
+	} else {
+		log.Println("[Maintenance] Optimized database")
+	}
+
+	freed, switched, err := j.db.IncrementalVacuum(ctx)
+	switch {
+	case err != nil:
+		log.Printf("[Maintenance] %v", err)
+		errs = append(errs, err)
+	case switched:
+		log.Printf("[Maintenance] Switched database to incremental vacuum with a full vacuum, freeing %d page(s)", freed)
+	default:
+		log.Printf("[Maintenance] Incremental vacuum freed %d page(s)", freed)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%d maintenance step(s) failed: %w", len(errs), errors.Join(errs...))
+	}
+	return nil
+}
@@ -6,12 +6,13 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"path"
 	"time"
 
-	"github.com/bluesky-social/indigo/atproto/identity"
-	"github.com/bluesky-social/indigo/atproto/syntax"
 	"github.com/shindakun/attodo/internal/database"
+	"github.com/shindakun/attodo/internal/handlers"
 	"github.com/shindakun/attodo/internal/models"
+	"github.com/shindakun/attodo/internal/notify"
 	"github.com/shindakun/attodo/internal/push"
 	"github.com/shindakun/bskyoauth"
 )
@@ -20,19 +21,19 @@ const (
 	NOTIFICATION_COOLDOWN_HOURS = 12 // Don't spam the same task within 12 hours
 )
 
-// NotificationCheckJob checks for due tasks and sends push notifications
+// NotificationCheckJob checks for due tasks and sends notifications
 type NotificationCheckJob struct {
-	repo   *database.NotificationRepo
-	client *bskyoauth.Client
-	sender *push.Sender
+	repo     *database.NotificationRepo
+	client   *bskyoauth.Client
+	notifier *notify.Dispatcher
 }
 
 // NewNotificationCheckJob creates a new notification check job
-func NewNotificationCheckJob(repo *database.NotificationRepo, client *bskyoauth.Client, sender *push.Sender) *NotificationCheckJob {
+func NewNotificationCheckJob(repo *database.NotificationRepo, client *bskyoauth.Client, notifier *notify.Dispatcher) *NotificationCheckJob {
 	return &NotificationCheckJob{
-		repo:   repo,
-		client: client,
-		sender: sender,
+		repo:     repo,
+		client:   client,
+		notifier: notifier,
 	}
 }
 
@@ -56,39 +57,53 @@ func (j *NotificationCheckJob) Run(ctx context.Context) error {
 
 	log.Printf("[NotificationCheck] Checking tasks for %d user(s)", len(users))
 
-	// Check tasks for each user
-	for _, user := range users {
-		if err := j.checkUserTasks(ctx, user); err != nil {
-			log.Printf("[NotificationCheck] Error checking tasks for %s: %v", user.DID, err)
-			// Continue to next user instead of failing the whole job
-			continue
-		}
+	// Check users concurrently; one user's error doesn't fail the whole job
+	stats := fanOut(ctx, fanOutWorkers, users, j.checkUser, func(user *models.NotificationUser, err error) {
+		log.Printf("[NotificationCheck] Error checking tasks for %s: %v", user.DID, err)
+	})
+	log.Printf("[NotificationCheck] Run %s", stats)
 
-		// Update last checked time
-		user.LastCheckedAt = timePtr(time.Now())
-		if err := j.repo.UpdateNotificationUser(user); err != nil {
-			log.Printf("[NotificationCheck] Failed to update last checked time for %s: %v", user.DID, err)
-		}
+	return ctx.Err()
+}
+
+// checkUser checks a user's tasks and records when they were last checked
+func (j *NotificationCheckJob) checkUser(ctx context.Context, user *models.NotificationUser) error {
+	if err := j.checkUserTasks(ctx, user); err != nil {
+		return err
 	}
 
+	// Update last checked time
+	user.LastCheckedAt = timePtr(time.Now())
+	if err := j.repo.UpdateNotificationUser(user); err != nil {
+		log.Printf("[NotificationCheck] Failed to update last checked time for %s: %v", user.DID, err)
+	}
 	return nil
 }
 
 // checkUserTasks checks tasks for a single user and sends notifications
 func (j *NotificationCheckJob) checkUserTasks(ctx context.Context, user *models.NotificationUser) error {
-	// Get user's push subscriptions
-	subscriptions, err := j.repo.GetPushSubscriptionsByDID(user.DID)
+	// Read from the user's PDS within its concurrency limit
+	pds, release, err := acquirePDS(ctx, user.DID)
 	if err != nil {
-		return fmt.Errorf("failed to get push subscriptions: %w", err)
+		return err
 	}
 
-	if len(subscriptions) == 0 {
-		log.Printf("[NotificationCheck] User %s has no push subscriptions", user.DID)
+	// Get the channels that can reach the user
+	recipient, err := j.notifier.Recipient(ctx, user.DID)
+	if err != nil {
+		release()
+		return fmt.Errorf("failed to resolve notification channels: %w", err)
+	}
+
+	if !recipient.Reachable() {
+		release()
+		log.Printf("[NotificationCheck] User %s has no notification channels", user.DID)
 		return nil
 	}
 
 	// Fetch user's tasks from AT Protocol
-	tasks, err := j.fetchUserTasks(ctx, user.DID)
+	tasks, err := j.fetchUserTasks(ctx, pds, user.DID)
+	release()
 	if err != nil {
 		return fmt.Errorf("failed to fetch tasks: %w", err)
 	}
@@ -129,26 +144,45 @@ func (j *NotificationCheckJob) checkUserTasks(ctx context.Context, user *models.
 
 	// Send notifications (prioritize overdue > today > soon)
 	if len(overdue) > 0 {
-		return j.sendOverdueNotification(user.DID, overdue, subscriptions)
+		return j.sendOverdueNotification(ctx, recipient, overdue)
 	}
 	if len(dueToday) > 0 {
-		return j.sendDueTodayNotification(user.DID, dueToday, subscriptions)
+		if j.deferUntilActive(recipient, "due today", dueToday) {
+			return nil
+		}
+		return j.sendDueTodayNotification(ctx, recipient, dueToday)
 	}
 	if len(dueSoon) > 0 {
-		return j.sendDueSoonNotification(user.DID, dueSoon, subscriptions)
+		if j.deferUntilActive(recipient, "due soon", dueSoon) {
+			return nil
+		}
+		return j.sendDueSoonNotification(ctx, recipient, dueSoon)
 	}
 
 	return nil
 }
 
-// fetchUserTasks fetches incomplete tasks for a user from AT Protocol
-func (j *NotificationCheckJob) fetchUserTasks(ctx context.Context, did string) ([]*models.Task, error) {
-	// Resolve PDS endpoint for this DID
-	pds, err := j.resolvePDSEndpoint(ctx, did)
-	if err != nil {
-		return nil, fmt.Errorf("failed to resolve PDS endpoint: %w", err)
+// deferUntilActive reports whether a due today or due soon notification
+// should wait for the user's next active hour, as long as that's before the
+// first task is due. Overdue notifications are never held back.
+func (j *NotificationCheckJob) deferUntilActive(recipient *notify.Recipient, kind string, tasks []*models.Task) bool {
+	settings := recipient.Settings
+	deadline := *tasks[0].DueDate
+	for _, task := range tasks[1:] {
+		if task.DueDate.Before(deadline) {
+			deadline = *task.DueDate
+		}
 	}
 
+	if settings.DeferUntilActive(time.Now(), deadline, models.UserLocation(settings.Timezone)) {
+		log.Printf("[NotificationCheck] Holding %s notification for %s until their next active hour", kind, recipient.DID)
+		return true
+	}
+	return false
+}
+
+// fetchUserTasks fetches incomplete tasks for a user from their PDS
+func (j *NotificationCheckJob) fetchUserTasks(ctx context.Context, pds, did string) ([]*models.Task, error) {
 	// Build the XRPC URL for public read (no auth needed)
 	url := fmt.Sprintf("%s/xrpc/com.atproto.repo.listRecords?repo=%s&collection=app.attodo.task",
 		pds, did)
@@ -160,8 +194,7 @@ func (j *NotificationCheckJob) fetchUserTasks(ctx context.Context, did string) (
 	}
 
 	// Make request (no auth needed for public reads)
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := pdsClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -186,12 +219,14 @@ func (j *NotificationCheckJob) fetchUserTasks(ctx context.Context, did string) (
 	// Convert to Task models and filter for incomplete tasks
 	tasks := make([]*models.Task, 0)
 	for _, record := range result.Records {
-		task := j.parseTaskFields(record.Value)
+		task := handlers.ParseTaskFields(record.Value)
 		task.URI = record.Uri
+		task.RKey = path.Base(record.Uri)
 
-		// Only include incomplete tasks
-		if !task.Completed {
-			tasks = append(tasks, task)
+		// Only include incomplete tasks; someday/maybe tasks are parked and
+		// never alert, as in digests and planning
+		if !task.Completed && !task.IsSomeday() {
+			tasks = append(tasks, &task)
 		}
 	}
 
@@ -200,7 +235,7 @@ func (j *NotificationCheckJob) fetchUserTasks(ctx context.Context, did string) (
 }
 
 // sendOverdueNotification sends a notification for overdue tasks
-func (j *NotificationCheckJob) sendOverdueNotification(did string, tasks []*models.Task, subs []*models.PushSubscription) error {
+func (j *NotificationCheckJob) sendOverdueNotification(ctx context.Context, recipient *notify.Recipient, tasks []*models.Task) error {
 	title := fmt.Sprintf("%d Overdue Task%s", len(tasks), pluralize(len(tasks)))
 	body := buildTaskList(tasks, 3)
 
@@ -214,51 +249,14 @@ func (j *NotificationCheckJob) sendOverdueNotification(did string, tasks []*mode
 			"type":  "overdue",
 			"count": len(tasks),
 		},
+		Tasks: tasks,
 	}
 
-	successCount, errors := j.sender.SendToAll(subs, notification)
-	log.Printf("[NotificationCheck] Sent overdue notification to %d/%d subscriptions", successCount, len(subs))
-
-	// Record notification history for each task
-	// Mark as "sent" if at least one subscription succeeded
-	for _, task := range tasks {
-		status := "sent"
-		var errMsg string
-		if successCount == 0 {
-			// Only mark as failed if ALL subscriptions failed
-			status = "failed"
-			if len(errors) > 0 {
-				errMsg = fmt.Sprintf("%v", errors[0])
-			}
-		} else if len(errors) > 0 {
-			// Partial success - note the errors but mark as sent
-			errMsg = fmt.Sprintf("Sent to %d/%d subscriptions. Errors: %v", successCount, len(subs), errors[0])
-		}
-
-		history := &models.NotificationHistory{
-			DID:              did,
-			TaskURI:          task.URI,
-			NotificationType: "overdue",
-			Status:           status,
-			ErrorMessage:     errMsg,
-		}
-		if err := j.repo.CreateNotificationHistory(history); err != nil {
-			log.Printf("[NotificationCheck] Failed to create notification history: %v", err)
-		}
-	}
-
-	if successCount == 0 {
-		// Only return error if ALL subscriptions failed
-		return fmt.Errorf("failed to send to all subscriptions: %v", errors)
-	}
-	if len(errors) > 0 {
-		log.Printf("[NotificationCheck] Partial success: %d/%d subscriptions succeeded", successCount, len(subs))
-	}
-	return nil
+	return sendAndRecord(ctx, j.notifier, j.repo, recipient, notification, "overdue", taskURIs(tasks)...)
 }
 
 // sendDueTodayNotification sends a notification for tasks due today
-func (j *NotificationCheckJob) sendDueTodayNotification(did string, tasks []*models.Task, subs []*models.PushSubscription) error {
+func (j *NotificationCheckJob) sendDueTodayNotification(ctx context.Context, recipient *notify.Recipient, tasks []*models.Task) error {
 	title := fmt.Sprintf("%d Task%s Due Today", len(tasks), pluralize(len(tasks)))
 	body := buildTaskList(tasks, 3)
 
@@ -272,51 +270,14 @@ func (j *NotificationCheckJob) sendDueTodayNotification(did string, tasks []*mod
 			"type":  "due_today",
 			"count": len(tasks),
 		},
+		Tasks: tasks,
 	}
 
-	successCount, errors := j.sender.SendToAll(subs, notification)
-	log.Printf("[NotificationCheck] Sent due today notification to %d/%d subscriptions", successCount, len(subs))
-
-	// Record notification history
-	// Mark as "sent" if at least one subscription succeeded
-	for _, task := range tasks {
-		status := "sent"
-		var errMsg string
-		if successCount == 0 {
-			// Only mark as failed if ALL subscriptions failed
-			status = "failed"
-			if len(errors) > 0 {
-				errMsg = fmt.Sprintf("%v", errors[0])
-			}
-		} else if len(errors) > 0 {
-			// Partial success - note the errors but mark as sent
-			errMsg = fmt.Sprintf("Sent to %d/%d subscriptions. Errors: %v", successCount, len(subs), errors[0])
-		}
-
-		history := &models.NotificationHistory{
-			DID:              did,
-			TaskURI:          task.URI,
-			NotificationType: "due_today",
-			Status:           status,
-			ErrorMessage:     errMsg,
-		}
-		if err := j.repo.CreateNotificationHistory(history); err != nil {
-			log.Printf("[NotificationCheck] Failed to create notification history: %v", err)
-		}
-	}
-
-	if successCount == 0 {
-		// Only return error if ALL subscriptions failed
-		return fmt.Errorf("failed to send to all subscriptions: %v", errors)
-	}
-	if len(errors) > 0 {
-		log.Printf("[NotificationCheck] Partial success: %d/%d subscriptions succeeded", successCount, len(subs))
-	}
-	return nil
+	return sendAndRecord(ctx, j.notifier, j.repo, recipient, notification, "due_today", taskURIs(tasks)...)
 }
 
 // sendDueSoonNotification sends a notification for tasks due soon
-func (j *NotificationCheckJob) sendDueSoonNotification(did string, tasks []*models.Task, subs []*models.PushSubscription) error {
+func (j *NotificationCheckJob) sendDueSoonNotification(ctx context.Context, recipient *notify.Recipient, tasks []*models.Task) error {
 	title := fmt.Sprintf("%d Task%s Due Soon", len(tasks), pluralize(len(tasks)))
 	body := buildTaskList(tasks, 3)
 
@@ -330,51 +291,23 @@ func (j *NotificationCheckJob) sendDueSoonNotification(did string, tasks []*mode
 			"type":  "due_soon",
 			"count": len(tasks),
 		},
+		Tasks: tasks,
 	}
 
-	successCount, errors := j.sender.SendToAll(subs, notification)
-	log.Printf("[NotificationCheck] Sent due soon notification to %d/%d subscriptions", successCount, len(subs))
-
-	// Record notification history
-	// Mark as "sent" if at least one subscription succeeded
-	for _, task := range tasks {
-		status := "sent"
-		var errMsg string
-		if successCount == 0 {
-			// Only mark as failed if ALL subscriptions failed
-			status = "failed"
-			if len(errors) > 0 {
-				errMsg = fmt.Sprintf("%v", errors[0])
-			}
-		} else if len(errors) > 0 {
-			// Partial success - note the errors but mark as sent
-			errMsg = fmt.Sprintf("Sent to %d/%d subscriptions. Errors: %v", successCount, len(subs), errors[0])
-		}
+	return sendAndRecord(ctx, j.notifier, j.repo, recipient, notification, "due_soon", taskURIs(tasks)...)
+}
 
-		history := &models.NotificationHistory{
-			DID:              did,
-			TaskURI:          task.URI,
-			NotificationType: "due_soon",
-			Status:           status,
-			ErrorMessage:     errMsg,
-		}
-		if err := j.repo.CreateNotificationHistory(history); err != nil {
-			log.Printf("[NotificationCheck] Failed to create notification history: %v", err)
-		}
-	}
+// Helper functions
 
-	if successCount == 0 {
-		// Only return error if ALL subscriptions failed
-		return fmt.Errorf("failed to send to all subscriptions: %v", errors)
-	}
-	if len(errors) > 0 {
-		log.Printf("[NotificationCheck] Partial success: %d/%d subscriptions succeeded", successCount, len(subs))
+// taskURIs returns the URIs of tasks, the history keys of their notifications
+func taskURIs(tasks []*models.Task) []string {
+	uris := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		uris = append(uris, task.URI)
 	}
-	return nil
+	return uris
 }
 
-// Helper functions
-
 func pluralize(count int) string {
 	if count == 1 {
 		return ""
@@ -398,63 +331,3 @@ func buildTaskList(tasks []*models.Task, limit int) string {
 func timePtr(t time.Time) *time.Time {
 	return &t
 }
-
-// resolvePDSEndpoint resolves the PDS endpoint for a given DID
-func (j *NotificationCheckJob) resolvePDSEndpoint(ctx context.Context, did string) (string, error) {
-	dir := identity.DefaultDirectory()
-	atid, err := syntax.ParseAtIdentifier(did)
-	if err != nil {
-		return "", err
-	}
-
-	ident, err := dir.Lookup(ctx, *atid)
-	if err != nil {
-		return "", err
-	}
-
-	return ident.PDSEndpoint(), nil
-}
-
-// parseTaskFields extracts task fields from a record value map
-func (j *NotificationCheckJob) parseTaskFields(record map[string]interface{}) *models.Task {
-	task := &models.Task{}
-
-	if title, ok := record["title"].(string); ok {
-		task.Title = title
-	}
-	if description, ok := record["description"].(string); ok {
-		task.Description = description
-	}
-	if completed, ok := record["completed"].(bool); ok {
-		task.Completed = completed
-	}
-
-	// Parse timestamps
-	if createdAt, ok := record["createdAt"].(string); ok {
-		if t, err := time.Parse(time.RFC3339, createdAt); err == nil {
-			task.CreatedAt = t
-		}
-	}
-	if completedAt, ok := record["completedAt"].(string); ok {
-		if t, err := time.Parse(time.RFC3339, completedAt); err == nil {
-			task.CompletedAt = &t
-		}
-	}
-	if dueDate, ok := record["dueDate"].(string); ok {
-		if t, err := time.Parse(time.RFC3339, dueDate); err == nil {
-			task.DueDate = &t
-		}
-	}
-
-	// Parse tags
-	if tags, ok := record["tags"].([]interface{}); ok {
-		task.Tags = make([]string, 0, len(tags))
-		for _, tag := range tags {
-			if tagStr, ok := tag.(string); ok {
-				task.Tags = append(task.Tags, tagStr)
-			}
-		}
-	}
-
-	return task
-}
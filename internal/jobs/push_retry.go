@@ -0,0 +1,30 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/shindakun/attodo/internal/notify"
+)
+
+// PushRetryJob retries queued push deliveries that the push service rate
+// limited or failed
+type PushRetryJob struct {
+	webPush *notify.WebPush
+}
+
+// NewPushRetryJob creates a new push retry job
+func NewPushRetryJob(webPush *notify.WebPush) *PushRetryJob {
+	return &PushRetryJob{
+		webPush: webPush,
+	}
+}
+
+// Name returns the job name
+func (j *PushRetryJob) Name() string {
+	return "PushRetry"
+}
+
+// Run retries every queued delivery that's due
+func (j *PushRetryJob) Run(ctx context.Context) error {
+	return j.webPush.RetryDue(ctx)
+}
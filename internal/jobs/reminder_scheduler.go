@@ -0,0 +1,247 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/shindakun/attodo/internal/database"
+	"github.com/shindakun/attodo/internal/handlers"
+	"github.com/shindakun/attodo/internal/models"
+	"github.com/shindakun/attodo/internal/notify"
+	"github.com/shindakun/attodo/internal/push"
+)
+
+const (
+	// reminderLookahead is how far ahead each run arms reminder timers; it's
+	// longer than the runner interval so no reminder falls between runs
+	reminderLookahead = 10 * time.Minute
+
+	// reminderGracePeriod is how late a missed reminder (e.g. across a
+	// restart) is still sent
+	reminderGracePeriod = time.Hour
+
+	// reminderHistoryHours is how far back the history is checked for a sent
+	// reminder; the history key names the reminder's time
+	reminderHistoryHours = 48
+
+	// reminderClaimTTL is how long an instance's claim on sending a reminder
+	// keeps other instances from sending it too
+	reminderClaimTTL = 10 * time.Minute
+)
+
+// ReminderScheduler fires tasks' explicit reminders at their exact time. Each
+// run arms a timer for every reminder due within the lookahead; Reschedule
+// does the same for one user right after their tasks change.
+type ReminderScheduler struct {
+	repo        *database.NotificationRepo
+	taskHandler *handlers.TaskHandler
+	notifier    *notify.Dispatcher
+	leases      *Leases
+
+	mu     sync.Mutex
+	timers map[string]*time.Timer // History key -> armed timer
+}
+
+// NewReminderScheduler creates a new reminder scheduler
+func NewReminderScheduler(repo *database.NotificationRepo, taskHandler *handlers.TaskHandler, notifier *notify.Dispatcher) *ReminderScheduler {
+	return &ReminderScheduler{
+		repo:        repo,
+		taskHandler: taskHandler,
+		notifier:    notifier,
+		timers:      make(map[string]*time.Timer),
+	}
+}
+
+// SetLeases makes each reminder claim a lease before it's sent, so that when
+// several instances have armed the same reminder only one sends it
+func (s *ReminderScheduler) SetLeases(leases *Leases) {
+	s.leases = leases
+}
+
+// Name returns the job name
+func (s *ReminderScheduler) Name() string {
+	return "ReminderScheduler"
+}
+
+// Run arms timers for every user's upcoming reminders
+func (s *ReminderScheduler) Run(ctx context.Context) error {
+	users, err := s.repo.GetEnabledNotificationUsers()
+	if err != nil {
+		return fmt.Errorf("failed to get enabled users: %w", err)
+	}
+
+	for _, user := range users {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := s.scheduleUser(ctx, user.DID); err != nil {
+			log.Printf("[ReminderScheduler] Error scheduling reminders for %s: %v", user.DID, err)
+			continue
+		}
+	}
+
+	return nil
+}
+
+// Reschedule arms timers for a user's upcoming reminders in the background
+func (s *ReminderScheduler) Reschedule(did string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := s.scheduleUser(ctx, did); err != nil {
+			log.Printf("[ReminderScheduler] Error rescheduling reminders for %s: %v", did, err)
+		}
+	}()
+}
+
+// Stop cancels all armed timers
+func (s *ReminderScheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, timer := range s.timers {
+		timer.Stop()
+		delete(s.timers, key)
+	}
+}
+
+// scheduleUser arms a timer for each of the user's reminders due within the
+// lookahead, or missed within the grace period, that isn't armed or sent yet
+func (s *ReminderScheduler) scheduleUser(ctx context.Context, did string) error {
+	tasks, err := s.taskHandler.FetchTasks(ctx, did)
+	if err != nil {
+		return fmt.Errorf("failed to fetch tasks: %w", err)
+	}
+
+	now := time.Now()
+	for _, task := range tasks {
+		if task.Completed {
+			continue
+		}
+		for _, fireAt := range task.ReminderTimes() {
+			if fireAt.Before(now.Add(-reminderGracePeriod)) || fireAt.After(now.Add(reminderLookahead)) {
+				continue
+			}
+
+			key := reminderKey(task.URI, fireAt)
+			s.mu.Lock()
+			_, armed := s.timers[key]
+			s.mu.Unlock()
+			if armed {
+				continue
+			}
+
+			recent, err := s.repo.GetRecentNotification(did, key, reminderHistoryHours)
+			if err != nil {
+				return fmt.Errorf("failed to check notification history: %w", err)
+			}
+			if recent != nil {
+				continue
+			}
+
+			s.arm(did, task.URI, fireAt, key)
+		}
+	}
+
+	return nil
+}
+
+// arm starts the timer for one reminder
+func (s *ReminderScheduler) arm(did, taskURI string, fireAt time.Time, key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, armed := s.timers[key]; armed {
+		return
+	}
+	s.timers[key] = time.AfterFunc(time.Until(fireAt), func() {
+		s.mu.Lock()
+		delete(s.timers, key)
+		s.mu.Unlock()
+
+		if err := s.fire(did, taskURI, fireAt, key); err != nil {
+			log.Printf("[ReminderScheduler] Error sending reminder %s: %v", key, err)
+		}
+	})
+}
+
+// fire sends a reminder, after checking the task is still open and still has
+// the reminder (it may have been edited since the timer was armed)
+func (s *ReminderScheduler) fire(did, taskURI string, fireAt time.Time, key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if s.leases != nil {
+		holder, err := s.leases.Claim("reminder:"+key, reminderClaimTTL)
+		if err != nil {
+			return fmt.Errorf("failed to claim reminder: %w", err)
+		}
+		if holder != s.leases.Instance() {
+			return nil // Another instance is sending it
+		}
+	}
+
+	recent, err := s.repo.GetRecentNotification(did, key, reminderHistoryHours)
+	if err != nil {
+		return fmt.Errorf("failed to check notification history: %w", err)
+	}
+	if recent != nil {
+		return nil
+	}
+
+	tasks, err := s.taskHandler.FetchTasks(ctx, did)
+	if err != nil {
+		return fmt.Errorf("failed to fetch tasks: %w", err)
+	}
+	var task *models.Task
+	for _, t := range tasks {
+		if t.URI == taskURI {
+			task = t
+			break
+		}
+	}
+	if task == nil || task.Completed || !task.HasReminderAt(fireAt) {
+		return nil
+	}
+
+	recipient, err := s.notifier.Recipient(ctx, did)
+	if err != nil {
+		return fmt.Errorf("failed to resolve notification channels: %w", err)
+	}
+	if !recipient.Reachable() {
+		return nil
+	}
+
+	return sendAndRecord(ctx, s.notifier, s.repo, recipient, buildReminderNotification(task), models.NotificationTypeReminder, key)
+}
+
+// reminderKey is the notification history key of one reminder of a task
+func reminderKey(taskURI string, fireAt time.Time) string {
+	return fmt.Sprintf("%s#reminder-%s", taskURI, fireAt.UTC().Format(time.RFC3339))
+}
+
+// buildReminderNotification reminds the user of a task, linking to the task
+func buildReminderNotification(task *models.Task) *push.Notification {
+	body := "Reminder"
+	if task.DueDate != nil {
+		body = "Due " + task.DueDateDisplay()
+	}
+
+	return &push.Notification{
+		Title: task.Title,
+		Body:  body,
+		Icon:  "/static/icon-192.png",
+		Badge: "/static/icon-192.png",
+		Tag:   "reminder-" + task.RKey,
+		Data: map[string]interface{}{
+			"type":    models.NotificationTypeReminder,
+			"url":     "/app#task-" + task.RKey,
+			"taskUri": task.URI,
+		},
+		Tasks: []*models.Task{task},
+	}
+}
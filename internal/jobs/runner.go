@@ -2,9 +2,16 @@ package jobs
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"math/rand/v2"
+	"sort"
 	"sync"
 	"time"
+
+	"github.com/shindakun/attodo/internal/database"
+	"github.com/shindakun/attodo/internal/models"
 )
 
 // Job represents a background job that runs periodically
@@ -15,88 +22,451 @@ type Job interface {
 	Run(ctx context.Context) error
 }
 
-// Runner manages and executes background jobs
+var (
+	// ErrUnknownJob means no job with that name is registered
+	ErrUnknownJob = errors.New("unknown job")
+
+	// ErrJobRunning means the job is already running; runs of the same job
+	// never overlap
+	ErrJobRunning = errors.New("job is already running")
+
+	// ErrRunnerStopped means the runner has been stopped
+	ErrRunnerStopped = errors.New("job runner stopped")
+
+	// ErrNotLeader means another instance holds the job's lease and runs it
+	ErrNotLeader = errors.New("job runs on another instance")
+)
+
+// JobStatus is a job's schedule and the state of its latest run
+type JobStatus struct {
+	Name           string     `json:"name"`
+	Schedule       string     `json:"schedule"`
+	Running        bool       `json:"running"`
+	NextRunAt      *time.Time `json:"nextRunAt,omitempty"`
+	LastStartedAt  *time.Time `json:"lastStartedAt,omitempty"`
+	LastFinishedAt *time.Time `json:"lastFinishedAt,omitempty"`
+	LastError      string     `json:"lastError,omitempty"`
+	SkippedRuns    int        `json:"skippedRuns"`           // Scheduled runs skipped because the last was still going
+	Leader         bool       `json:"leader"`                // This instance runs the job
+	LeaseHolder    string     `json:"leaseHolder,omitempty"` // Instance running the job, when leases are used
+}
+
+// entry is a registered job and its run state
+type entry struct {
+	job      Job
+	schedule Schedule
+	jitter   time.Duration
+	local    bool // Runs on every instance, without a lease
+
+	running        bool
+	nextRunAt      time.Time
+	lastStartedAt  time.Time
+	lastFinishedAt time.Time
+	lastError      string
+	skippedRuns    int
+
+	leaseHolder string             // Last seen holder of the job's lease
+	leaseUntil  time.Time          // When our hold on the lease runs out
+	cancelRun   context.CancelFunc // Cancels the run in progress
+}
+
+// leads reports whether this instance holds the job's lease. Without leases,
+// and for local jobs, every instance leads. Callers hold the runner's lock.
+func (e *entry) leads(leases *Leases, now time.Time) bool {
+	return leases == nil || e.local || now.Before(e.leaseUntil)
+}
+
+// Runner runs background jobs, each on its own schedule. A job never runs
+// twice at once: a scheduled run is skipped while the previous one is still
+// going. Runs are recorded in the job_runs table when a repo is given. With
+// leases, a job only runs on the instance holding its lease.
 type Runner struct {
-	jobs     []Job
-	interval time.Duration
-	ctx      context.Context
-	cancel   context.CancelFunc
-	wg       sync.WaitGroup
+	runs     *database.JobRunRepo
+	leases   *Leases
+	leaseTTL time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	started bool
+	stopped bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup // Schedule loops and in-flight runs
 }
 
-// NewRunner creates a new job runner
-// interval is how often to run all jobs
-func NewRunner(interval time.Duration) *Runner {
+// NewRunner creates a new job runner. runs may be nil to skip recording run
+// history.
+func NewRunner(runs *database.JobRunRepo) *Runner {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &Runner{
-		jobs:     make([]Job, 0),
-		interval: interval,
-		ctx:      ctx,
-		cancel:   cancel,
+		runs:    runs,
+		entries: make(map[string]*entry),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// AddJob registers a job to run on a schedule. Each scheduled run is delayed
+// by a random amount up to jitter, so jobs sharing a schedule don't all start
+// at once. Jobs must be added before Start.
+func (r *Runner) AddJob(job Job, schedule Schedule, jitter time.Duration) {
+	r.add(&entry{job: job, schedule: schedule, jitter: jitter})
+}
+
+// AddLocalJob registers a job like AddJob, but one that runs on every
+// instance even with leases: for jobs working on state only this instance
+// has, like its in-memory sessions.
+func (r *Runner) AddLocalJob(job Job, schedule Schedule, jitter time.Duration) {
+	r.add(&entry{job: job, schedule: schedule, jitter: jitter, local: true})
+}
+
+func (r *Runner) add(e *entry) {
+	job := e.job
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.started {
+		panic("jobs: AddJob called after Start")
+	}
+	if _, exists := r.entries[job.Name()]; exists {
+		panic("jobs: duplicate job name " + job.Name())
 	}
+	r.entries[job.Name()] = e
+	log.Printf("Registered job: %s (%s)", job.Name(), e.schedule)
 }
 
-// AddJob registers a job to run
-func (r *Runner) AddJob(job Job) {
-	r.jobs = append(r.jobs, job)
-	log.Printf("Registered job: %s", job.Name())
+// UseLeases makes the runner run each job only while this instance holds the
+// job's lease, for deployments with several instances sharing the database.
+// Must be called before Start.
+func (r *Runner) UseLeases(leases *Leases, ttl time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.started {
+		panic("jobs: UseLeases called after Start")
+	}
+	r.leases = leases
+	r.leaseTTL = ttl
 }
 
-// Start begins running jobs in the background
+// Start begins running jobs on their schedules in the background
 func (r *Runner) Start() {
-	if len(r.jobs) == 0 {
-		log.Println("No jobs registered, runner will not start")
+	r.mu.Lock()
+	if len(r.entries) == 0 || r.started {
+		r.mu.Unlock()
+		if !r.started {
+			log.Println("No jobs registered, runner will not start")
+		}
 		return
 	}
+	r.started = true
+	r.mu.Unlock()
 
-	log.Printf("Starting job runner with %d job(s), interval: %s", len(r.jobs), r.interval)
+	if r.leases != nil {
+		// Claim leases before the first runs; after that they're renewed in
+		// the background
+		log.Printf("Job runner using leases as instance %s", r.leases.Instance())
+		r.claimLeases()
+		r.wg.Add(1)
+		go r.renewLeases()
+	} else if r.runs != nil {
+		if count, err := r.runs.InterruptRunningJobRuns(); err != nil {
+			log.Printf("Failed to mark interrupted job runs: %v", err)
+		} else if count > 0 {
+			log.Printf("Marked %d job run(s) interrupted by the last shutdown", count)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	log.Printf("Starting job runner with %d job(s)", len(r.entries))
+
+	now := time.Now()
+	for _, e := range r.entries {
+		next := e.schedule.Next(now)
+		if _, ok := e.schedule.(intervalSchedule); ok {
+			next = now // Interval jobs run right away on start
+		}
+		e.nextRunAt = e.withJitter(next)
+
+		r.wg.Add(1)
+		go r.loop(e, e.nextRunAt)
+	}
+}
+
+// withJitter delays a scheduled time by a random amount up to the job's
+// jitter
+func (e *entry) withJitter(t time.Time) time.Time {
+	if t.IsZero() || e.jitter <= 0 {
+		return t
+	}
+	return t.Add(rand.N(e.jitter))
+}
+
+// loop runs one job on its schedule until the runner stops, starting with
+// the run at next
+func (r *Runner) loop(e *entry, next time.Time) {
+	defer r.wg.Done()
+
+	for {
+		if next.IsZero() {
+			log.Printf("Job %s has no upcoming run on its schedule (%s)", e.job.Name(), e.schedule)
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+			if err := r.start(e, models.JobTriggerSchedule); errors.Is(err, ErrJobRunning) {
+				r.mu.Lock()
+				e.skippedRuns++
+				r.mu.Unlock()
+				log.Printf("Job %s is still running, skipping this run", e.job.Name())
+			}
+		case <-r.ctx.Done():
+			timer.Stop()
+			return
+		}
+
+		next = e.withJitter(e.schedule.Next(time.Now()))
+		r.mu.Lock()
+		e.nextRunAt = next
+		r.mu.Unlock()
+	}
+}
+
+// Trigger starts a run of a job now, outside its schedule. It doesn't wait
+// for the run to finish.
+func (r *Runner) Trigger(name string) error {
+	r.mu.Lock()
+	e, ok := r.entries[name]
+	r.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownJob, name)
+	}
+	return r.start(e, models.JobTriggerManual)
+}
+
+// start runs a job in the background unless it's already running
+func (r *Runner) start(e *entry, trigger string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stopped {
+		return ErrRunnerStopped
+	}
+	if !e.leads(r.leases, time.Now()) {
+		return fmt.Errorf("%w (%s)", ErrNotLeader, e.leaseHolder)
+	}
+	if e.running {
+		return ErrJobRunning
+	}
+	e.running = true
+	e.lastStartedAt = time.Now()
+
+	ctx, cancel := context.WithCancel(r.ctx)
+	e.cancelRun = cancel
 
 	r.wg.Add(1)
-	go r.run()
+	go r.run(ctx, e, trigger)
+	return nil
 }
 
-// run is the main job loop
-func (r *Runner) run() {
+// run executes one run of a job and records how it went
+func (r *Runner) run(ctx context.Context, e *entry, trigger string) {
 	defer r.wg.Done()
 
-	// Run jobs immediately on start
-	r.runAll()
+	name := e.job.Name()
+	record := &models.JobRun{JobName: name, TriggeredBy: trigger}
+	if r.leases != nil {
+		record.Instance = r.leases.Instance()
+	}
+	if r.runs != nil {
+		if err := r.runs.StartJobRun(record); err != nil {
+			log.Printf("Failed to record start of job %s: %v", name, err)
+		}
+	}
+
+	start := time.Now()
+	log.Printf("Running job: %s (%s)", name, trigger)
+	err := r.runJob(ctx, e.job)
+	if err != nil {
+		log.Printf("Job %s failed: %v", name, err)
+	} else {
+		log.Printf("Job %s completed in %s", name, time.Since(start))
+	}
 
-	ticker := time.NewTicker(r.interval)
+	if r.runs != nil && record.ID != 0 {
+		if recordErr := r.runs.FinishJobRun(record, err); recordErr != nil {
+			log.Printf("Failed to record end of job %s: %v", name, recordErr)
+		}
+	}
+
+	r.mu.Lock()
+	e.cancelRun()
+	e.cancelRun = nil
+	e.running = false
+	e.lastFinishedAt = time.Now()
+	e.lastError = ""
+	if err != nil {
+		e.lastError = err.Error()
+	}
+	r.mu.Unlock()
+}
+
+// runJob calls a job's Run, turning a panic into an error so one bad run
+// doesn't take down the server
+func (r *Runner) runJob(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+	return job.Run(ctx)
+}
+
+// renewLeases keeps claiming job leases until the runner stops, well within
+// the lease TTL so a held lease never lapses while this instance is alive
+func (r *Runner) renewLeases() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.leaseTTL / 3)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
-			r.runAll()
+			r.claimLeases()
 		case <-r.ctx.Done():
-			log.Println("Job runner stopping...")
 			return
 		}
 	}
 }
 
-// runAll executes all registered jobs
-func (r *Runner) runAll() {
-	for _, job := range r.jobs {
-		// Run each job in its own goroutine
-		go func(j Job) {
-			start := time.Now()
-			log.Printf("Running job: %s", j.Name())
+// claimLeases takes or renews each job's lease. A job whose lease went to
+// another instance has its run in progress cancelled, so the new holder
+// doesn't overlap it.
+func (r *Runner) claimLeases() {
+	r.mu.Lock()
+	entries := make([]*entry, 0, len(r.entries))
+	for _, e := range r.entries {
+		if !e.local {
+			entries = append(entries, e)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, e := range entries {
+		name := e.job.Name()
+		claimedAt := time.Now()
+		holder, err := r.leases.Claim(jobLeaseName(name), r.leaseTTL)
 
-			if err := j.Run(r.ctx); err != nil {
-				log.Printf("Job %s failed: %v", j.Name(), err)
-			} else {
-				log.Printf("Job %s completed in %s", j.Name(), time.Since(start))
+		r.mu.Lock()
+		wasLeader := e.leads(r.leases, claimedAt)
+		switch {
+		case err != nil:
+			// Keep what we have; an unrenewed lease runs out on its own
+			log.Printf("Failed to claim lease for job %s: %v", name, err)
+		case holder == r.leases.Instance():
+			e.leaseHolder = holder
+			e.leaseUntil = claimedAt.Add(r.leaseTTL)
+			if !wasLeader {
+				log.Printf("Instance %s now runs job %s", holder, name)
+			}
+		default:
+			e.leaseHolder = holder
+			e.leaseUntil = time.Time{}
+			if wasLeader {
+				log.Printf("Job %s moved to instance %s", name, holder)
 			}
-		}(job)
+		}
+		if e.cancelRun != nil && !e.leads(r.leases, time.Now()) {
+			log.Printf("Lost the lease for job %s, cancelling its run", name)
+			e.cancelRun()
+		}
+		r.mu.Unlock()
+	}
+
+	// Expired leases are dead weight; reminder leases especially pile up
+	if _, err := r.leases.repo.DeleteExpiredLeases(time.Now().Add(-time.Hour)); err != nil {
+		log.Printf("Failed to delete expired leases: %v", err)
+	}
+
+	if r.runs != nil {
+		if count, err := r.runs.InterruptOrphanedJobRuns(); err != nil {
+			log.Printf("Failed to mark interrupted job runs: %v", err)
+		} else if count > 0 {
+			log.Printf("Marked %d job run(s) interrupted by a stopped instance", count)
+		}
 	}
 }
 
-// Stop gracefully stops the job runner
+// releaseLeases gives up this instance's job leases so other instances can
+// take the jobs over right away
+func (r *Runner) releaseLeases() {
+	r.mu.Lock()
+	var held []string
+	for name, e := range r.entries {
+		if e.leaseHolder == r.leases.Instance() {
+			held = append(held, name)
+		}
+		e.leaseUntil = time.Time{}
+	}
+	r.mu.Unlock()
+
+	for _, name := range held {
+		r.leases.Release(jobLeaseName(name))
+	}
+}
+
+// Status returns every job's schedule and latest run, sorted by name
+func (r *Runner) Status() []JobStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	statuses := make([]JobStatus, 0, len(r.entries))
+	for _, e := range r.entries {
+		status := JobStatus{
+			Name:        e.job.Name(),
+			Schedule:    e.schedule.String(),
+			Running:     e.running,
+			LastError:   e.lastError,
+			SkippedRuns: e.skippedRuns,
+			Leader:      e.leads(r.leases, time.Now()),
+			LeaseHolder: e.leaseHolder,
+		}
+		if !e.nextRunAt.IsZero() && !r.stopped {
+			status.NextRunAt = timePtr(e.nextRunAt)
+		}
+		if !e.lastStartedAt.IsZero() {
+			status.LastStartedAt = timePtr(e.lastStartedAt)
+		}
+		if !e.lastFinishedAt.IsZero() {
+			status.LastFinishedAt = timePtr(e.lastFinishedAt)
+		}
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// Stop stops scheduling jobs, cancels the context of running jobs and waits
+// for them to return
 func (r *Runner) Stop() {
 	log.Println("Stopping job runner...")
+
+	r.mu.Lock()
+	r.stopped = true
+	started := r.started
+	r.mu.Unlock()
+
 	r.cancel()
 	r.wg.Wait()
+	if r.leases != nil && started {
+		r.releaseLeases()
+	}
 	log.Println("Job runner stopped")
 }
@@ -0,0 +1,162 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shindakun/attodo/internal/database"
+)
+
+// blockingJob runs until released or cancelled
+type blockingJob struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (j *blockingJob) Name() string { return "Blocking" }
+
+func (j *blockingJob) Run(ctx context.Context) error {
+	j.started <- struct{}{}
+	select {
+	case <-j.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestRunnerPreventsOverlap(t *testing.T) {
+	job := &blockingJob{started: make(chan struct{}, 1), release: make(chan struct{})}
+	runner := NewRunner(nil)
+	runner.AddJob(job, MustCron("0 0 1 1 *"), 0)
+	runner.Start()
+	defer runner.Stop()
+
+	if err := runner.Trigger("Blocking"); err != nil {
+		t.Fatalf("Trigger failed: %v", err)
+	}
+	<-job.started
+
+	if err := runner.Trigger("Blocking"); !errors.Is(err, ErrJobRunning) {
+		t.Errorf("Expected a second run to be refused, got %v", err)
+	}
+	if err := runner.Trigger("Missing"); !errors.Is(err, ErrUnknownJob) {
+		t.Errorf("Expected an unknown job error, got %v", err)
+	}
+	if status := runner.Status(); len(status) != 1 || !status[0].Running || status[0].NextRunAt == nil {
+		t.Errorf("Expected a running job with a next run, got %+v", status)
+	}
+
+	job.release <- struct{}{}
+	deadline := time.Now().Add(time.Second)
+	for runner.Status()[0].Running {
+		if time.Now().After(deadline) {
+			t.Fatal("Job didn't finish")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := runner.Trigger("Blocking"); err != nil {
+		t.Errorf("Expected the job to run again once finished, got %v", err)
+	}
+	<-job.started
+}
+
+func TestRunnerStopWaitsForRuns(t *testing.T) {
+	job := &blockingJob{started: make(chan struct{}, 1), release: make(chan struct{})}
+	runner := NewRunner(nil)
+	runner.AddJob(job, Every(time.Hour), 0)
+	runner.Start()
+	<-job.started // Interval jobs run on start
+
+	runner.Stop()
+	status := runner.Status()[0]
+	if status.Running || status.LastError != context.Canceled.Error() {
+		t.Errorf("Expected Stop to cancel and wait for the run, got %+v", status)
+	}
+	if err := runner.Trigger("Blocking"); !errors.Is(err, ErrRunnerStopped) {
+		t.Errorf("Expected no runs after Stop, got %v", err)
+	}
+}
+
+// countingJob counts its runs
+type countingJob struct {
+	runs atomic.Int32
+}
+
+func (j *countingJob) Name() string { return "Counting" }
+
+func (j *countingJob) Run(ctx context.Context) error {
+	j.runs.Add(1)
+	return nil
+}
+
+// localJob is a countingJob run on every instance
+type localJob struct {
+	countingJob
+}
+
+func (j *localJob) Name() string { return "Local" }
+
+func TestRunnerLeases(t *testing.T) {
+	dbPath := "./test_job_leases.db"
+	defer os.Remove(dbPath)
+	defer os.Remove(dbPath + "-shm")
+	defer os.Remove(dbPath + "-wal")
+
+	db, err := database.New(dbPath, "../../migrations")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer db.Close()
+
+	// Two instances sharing the database
+	newInstance := func(id string) (*Runner, *countingJob) {
+		job := &countingJob{}
+		runner := NewRunner(database.NewJobRunRepo(db))
+		runner.UseLeases(NewLeases(database.NewLeaseRepo(db), id), 300*time.Millisecond)
+		runner.AddJob(job, Every(time.Hour), 0)
+		runner.AddLocalJob(&localJob{}, Every(time.Hour), 0)
+		return runner, job
+	}
+	first, firstJob := newInstance("instance-a")
+	second, secondJob := newInstance("instance-b")
+	first.Start()
+	second.Start()
+	defer second.Stop()
+
+	waitFor := func(what string, done func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for !done() {
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for %s", what)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	waitFor("the leader's first run", func() bool { return firstJob.runs.Load() == 1 })
+
+	// Local jobs run on every instance, leader or not
+	if err := second.Trigger("Local"); err != nil {
+		t.Errorf("Expected a local job to run on any instance, got %v", err)
+	}
+	if err := second.Trigger("Counting"); !errors.Is(err, ErrNotLeader) {
+		t.Errorf("Expected the second instance to defer to the leader, got %v", err)
+	}
+	if status := second.Status()[0]; status.Name != "Counting" || status.Leader || status.LeaseHolder != "instance-a" {
+		t.Errorf("Expected instance-a to lead, got %+v", status)
+	}
+
+	// Stopping releases the lease and the other instance takes over
+	first.Stop()
+	waitFor("failover", func() bool { return second.Status()[0].Leader })
+	if err := second.Trigger("Counting"); err != nil {
+		t.Fatalf("Expected the new leader to run the job, got %v", err)
+	}
+	waitFor("the new leader's run", func() bool { return secondJob.runs.Load() == 1 })
+}
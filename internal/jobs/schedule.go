@@ -0,0 +1,193 @@
+package jobs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule decides when a job next runs
+type Schedule interface {
+	// Next returns the first run time after t
+	Next(t time.Time) time.Time
+	// String describes the schedule for status output
+	String() string
+}
+
+// intervalSchedule runs a job at a fixed interval, starting when the runner
+// starts
+type intervalSchedule struct {
+	interval time.Duration
+}
+
+// Every returns a schedule that runs a job once when the runner starts and
+// then every interval
+func Every(interval time.Duration) Schedule {
+	return intervalSchedule{interval: interval}
+}
+
+func (s intervalSchedule) Next(t time.Time) time.Time {
+	return t.Add(s.interval)
+}
+
+func (s intervalSchedule) String() string {
+	return "every " + s.interval.String()
+}
+
+// cronSchedule runs a job at the times matching a cron expression, in the
+// server's local time
+type cronSchedule struct {
+	expr                              string
+	minute, hour, dom, month, weekday uint64 // Bit sets of the allowed values
+	anyDOM, anyWeekday                bool   // Field starts with *, for the day matching rule
+}
+
+// cronFields are the fields of a cron expression, in order, with their
+// allowed ranges
+var cronFields = []struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7}, // 0 and 7 are both Sunday
+}
+
+// Cron parses a standard five-field cron expression: minute, hour, day of
+// month, month and day of week. Fields take *, single values, ranges (1-5),
+// lists (1,15) and steps (*/10, 0-30/5). As in cron, when both day fields
+// are restricted a day matching either runs the job.
+func Cron(expr string) (Schedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("cron expression %q must have %d fields", expr, len(cronFields))
+	}
+
+	var sets [5]uint64
+	for i, field := range fields {
+		set, err := parseCronField(field, cronFields[i].min, cronFields[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s in cron expression %q: %w", cronFields[i].name, expr, err)
+		}
+		sets[i] = set
+	}
+
+	// Sunday is 0 or 7
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+
+	return &cronSchedule{
+		expr:       expr,
+		minute:     sets[0],
+		hour:       sets[1],
+		dom:        sets[2],
+		month:      sets[3],
+		weekday:    sets[4],
+		anyDOM:     strings.HasPrefix(fields[2], "*"),
+		anyWeekday: strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+// MustCron is like Cron but panics on an invalid expression; for schedules
+// fixed in code
+func MustCron(expr string) Schedule {
+	schedule, err := Cron(expr)
+	if err != nil {
+		panic(err)
+	}
+	return schedule
+}
+
+// parseCronField parses one field into a bit set of its allowed values
+func parseCronField(field string, min, max int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			rangePart = part[:i]
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("bad step in %q", part)
+			}
+		}
+
+		low, high := min, max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err1, err2 error
+			low, err1 = strconv.Atoi(bounds[0])
+			high, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("bad range %q", rangePart)
+			}
+		default:
+			value, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, fmt.Errorf("bad value %q", rangePart)
+			}
+			low, high = value, value
+			if step > 1 {
+				high = max // 5/10 means from 5 every 10
+			}
+		}
+
+		if low < min || high > max || low > high {
+			return 0, fmt.Errorf("%q is outside %d-%d", rangePart, min, max)
+		}
+		for value := low; value <= high; value += step {
+			set |= 1 << uint(value)
+		}
+	}
+	return set, nil
+}
+
+// Next returns the first matching minute after t, or the zero time if
+// nothing matches
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	next := t.Truncate(time.Minute).Add(time.Minute)
+
+	// Give up after five years without a match (e.g. February 30th)
+	limit := next.AddDate(5, 0, 0)
+	for next.Before(limit) {
+		if s.month&(1<<uint(next.Month())) == 0 {
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, next.Location())
+			continue
+		}
+		if !s.dayMatches(next) {
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, next.Location())
+			continue
+		}
+		if s.hour&(1<<uint(next.Hour())) == 0 {
+			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour()+1, 0, 0, 0, next.Location())
+			continue
+		}
+		if s.minute&(1<<uint(next.Minute())) == 0 {
+			next = next.Add(time.Minute)
+			continue
+		}
+		return next
+	}
+	return time.Time{}
+}
+
+// dayMatches applies cron's day rule: if either day field is *, the other
+// decides; if both are restricted, either one matching is enough
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	weekdayMatch := s.weekday&(1<<uint(t.Weekday())) != 0
+	if s.anyDOM || s.anyWeekday {
+		return domMatch && weekdayMatch
+	}
+	return domMatch || weekdayMatch
+}
+
+func (s *cronSchedule) String() string {
+	return "cron " + s.expr
+}
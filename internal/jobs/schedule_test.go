@@ -0,0 +1,66 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	// Saturday
+	from := time.Date(2026, 3, 14, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 3, 14, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 3, 14, 10, 15, 0, 0, time.UTC)},
+		{"30 3 * * *", time.Date(2026, 3, 15, 3, 30, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2026, 3, 14, 13, 0, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)}, // 7 is Sunday
+		{"0 0 1 * *", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 6 *", time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either matching is enough
+		{"0 12 20 * 1", time.Date(2026, 3, 16, 12, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+
+	for _, test := range tests {
+		schedule, err := Cron(test.expr)
+		if err != nil {
+			t.Errorf("Cron(%q) failed: %v", test.expr, err)
+			continue
+		}
+		if got := schedule.Next(from); !got.Equal(test.want) {
+			t.Errorf("Cron(%q).Next = %s, expected %s", test.expr, got, test.want)
+		}
+	}
+}
+
+func TestCronInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+	} {
+		if _, err := Cron(expr); err == nil {
+			t.Errorf("Expected Cron(%q) to fail", expr)
+		}
+	}
+}
+
+func TestEvery(t *testing.T) {
+	from := time.Date(2026, 3, 14, 10, 7, 30, 0, time.UTC)
+	if got := Every(5 * time.Minute).Next(from); !got.Equal(from.Add(5 * time.Minute)) {
+		t.Errorf("Expected the next run 5 minutes later, got %s", got)
+	}
+}
@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/shindakun/attodo/internal/database"
+	"github.com/shindakun/attodo/internal/models"
+	"github.com/shindakun/attodo/internal/notify"
+	"github.com/shindakun/attodo/internal/push"
+)
+
+// sendAndRecord delivers a notification over the recipient's channels and
+// records a history entry for each key (a task URI or other notification
+// key). Entries are "sent" if at least one channel delivered it, noting the
+// first error of any that failed, and "failed" otherwise. It returns an error
+// only when every channel failed.
+func sendAndRecord(ctx context.Context, notifier *notify.Dispatcher, repo *database.NotificationRepo, recipient *notify.Recipient, notification *push.Notification, notificationType string, keys ...string) error {
+	successCount, errors := notifier.Send(ctx, recipient, notification)
+	log.Printf("[Notify] Sent %s notification to %s over %d/%d channels", notificationType, recipient.DID, successCount, len(recipient.Channels))
+
+	status := "sent"
+	var errMsg string
+	if successCount == 0 {
+		status = "failed"
+		if len(errors) > 0 {
+			errMsg = fmt.Sprintf("%v", errors[0])
+		}
+	} else if len(errors) > 0 {
+		// Partial success - note the errors but mark as sent
+		errMsg = fmt.Sprintf("Sent over %d/%d channels. Errors: %v", successCount, len(recipient.Channels), errors[0])
+	}
+
+	for _, key := range keys {
+		history := &models.NotificationHistory{
+			DID:              recipient.DID,
+			TaskURI:          key,
+			NotificationType: notificationType,
+			Status:           status,
+			ErrorMessage:     errMsg,
+		}
+		if err := repo.CreateNotificationHistory(history); err != nil {
+			log.Printf("[Notify] Failed to create notification history: %v", err)
+		}
+	}
+
+	if successCount == 0 {
+		return fmt.Errorf("failed to send over all channels: %v", errors)
+	}
+	return nil
+}
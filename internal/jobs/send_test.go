@@ -0,0 +1,70 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/shindakun/attodo/internal/database"
+	"github.com/shindakun/attodo/internal/notify"
+	"github.com/shindakun/attodo/internal/push"
+)
+
+// stubChannel is a notification channel that succeeds or fails every send
+type stubChannel struct {
+	name string
+	err  error
+}
+
+func (c *stubChannel) Channel() string { return c.name }
+
+func (c *stubChannel) Reaches(ctx context.Context, recipient *notify.Recipient) (bool, error) {
+	return true, nil
+}
+
+func (c *stubChannel) Notify(ctx context.Context, recipient *notify.Recipient, notification *push.Notification) error {
+	return c.err
+}
+
+func TestSendAndRecord(t *testing.T) {
+	dbPath := "./test_send_and_record.db"
+	defer os.Remove(dbPath)
+	defer os.Remove(dbPath + "-shm")
+	defer os.Remove(dbPath + "-wal")
+
+	db, err := database.New(dbPath, "../../migrations")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer db.Close()
+	repo := database.NewNotificationRepo(db)
+
+	working := &stubChannel{name: "push"}
+	broken := &stubChannel{name: "email", err: errors.New("smtp down")}
+	notification := &push.Notification{Title: "Buy milk"}
+
+	// One channel delivering is enough to count as sent, for every key
+	recipient := &notify.Recipient{DID: "did:plc:alice", Channels: []notify.Notifier{working, broken}}
+	if err := sendAndRecord(context.Background(), notify.NewDispatcher(nil), repo, recipient, notification, "due_today", "at://a/1", "at://a/2"); err != nil {
+		t.Fatalf("Expected a partial success to succeed, got %v", err)
+	}
+	for _, key := range []string{"at://a/1", "at://a/2"} {
+		history, err := repo.GetRecentNotification("did:plc:alice", key, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if history == nil || history.NotificationType != "due_today" || history.ErrorMessage == "" {
+			t.Errorf("Expected a sent entry noting the failed channel for %s, got %+v", key, history)
+		}
+	}
+
+	// Every channel failing is an error, recorded as failed
+	recipient = &notify.Recipient{DID: "did:plc:bob", Channels: []notify.Notifier{broken}}
+	if err := sendAndRecord(context.Background(), notify.NewDispatcher(nil), repo, recipient, notification, "reminder", "at://b/1"); err == nil {
+		t.Error("Expected an error when every channel failed")
+	}
+	if history, err := repo.GetRecentNotification("did:plc:bob", "at://b/1", 1); err != nil || history != nil {
+		t.Errorf("Expected no sent entry after every channel failed, got %+v, %v", history, err)
+	}
+}
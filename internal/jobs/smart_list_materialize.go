@@ -0,0 +1,41 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/shindakun/attodo/internal/handlers"
+)
+
+// SmartListMaterializeJob writes the current matches of smart lists into their
+// taskUris, for lists that opted in, so public views and feeds stay current
+// Only users with an active session can be updated, since writes need their tokens
+// Those sessions live in this instance's memory, so with several instances the
+// job must be added with AddLocalJob to run on each of them
+type SmartListMaterializeJob struct {
+	listHandler *handlers.ListHandler
+}
+
+// NewSmartListMaterializeJob creates a new smart list materialization job
+func NewSmartListMaterializeJob(listHandler *handlers.ListHandler) *SmartListMaterializeJob {
+	return &SmartListMaterializeJob{
+		listHandler: listHandler,
+	}
+}
+
+// Name returns the job name
+func (j *SmartListMaterializeJob) Name() string {
+	return "SmartListMaterialize"
+}
+
+// Run executes the smart list materialization
+func (j *SmartListMaterializeJob) Run(ctx context.Context) error {
+	processed, err := j.listHandler.MaterializeSmartLists(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to materialize smart lists: %w", err)
+	}
+
+	log.Printf("[SmartListMaterialize] Checked smart lists for %d active user(s)", processed)
+	return nil
+}
@@ -0,0 +1,130 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/shindakun/attodo/internal/database"
+	"github.com/shindakun/attodo/internal/handlers"
+	"github.com/shindakun/attodo/internal/models"
+	"github.com/shindakun/attodo/internal/notify"
+	"github.com/shindakun/attodo/internal/push"
+)
+
+// followUpHistoryHours is how far back the history is checked for a sent
+// follow-up; the history key names the follow-up date, so a new date
+// (after following up) notifies again
+const followUpHistoryHours = 24 * 60
+
+// WaitingFollowUpJob reminds users to follow up on waiting-for tasks once
+// their follow-up date passes
+type WaitingFollowUpJob struct {
+	repo       *database.NotificationRepo
+	gtdHandler *handlers.GTDHandler
+	notifier   *notify.Dispatcher
+}
+
+// NewWaitingFollowUpJob creates a new waiting-for follow-up job
+func NewWaitingFollowUpJob(repo *database.NotificationRepo, gtdHandler *handlers.GTDHandler, notifier *notify.Dispatcher) *WaitingFollowUpJob {
+	return &WaitingFollowUpJob{
+		repo:       repo,
+		gtdHandler: gtdHandler,
+		notifier:   notifier,
+	}
+}
+
+// Name returns the job name
+func (j *WaitingFollowUpJob) Name() string {
+	return "WaitingFollowUp"
+}
+
+// Run executes the follow-up check
+func (j *WaitingFollowUpJob) Run(ctx context.Context) error {
+	users, err := j.repo.GetEnabledNotificationUsers()
+	if err != nil {
+		return fmt.Errorf("failed to get enabled users: %w", err)
+	}
+
+	for _, user := range users {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := j.checkUser(ctx, user.DID); err != nil {
+			log.Printf("[WaitingFollowUp] Error checking follow-ups for %s: %v", user.DID, err)
+			continue
+		}
+	}
+
+	return nil
+}
+
+// checkUser sends one notification for the user's follow-ups that haven't
+// been notified about yet
+func (j *WaitingFollowUpJob) checkUser(ctx context.Context, did string) error {
+	recipient, err := j.notifier.Recipient(ctx, did)
+	if err != nil {
+		return fmt.Errorf("failed to resolve notification channels: %w", err)
+	}
+	if !recipient.Reachable() {
+		return nil
+	}
+
+	tasks, err := j.gtdHandler.FetchFollowUps(ctx, did, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to fetch follow-ups: %w", err)
+	}
+
+	due := make([]*models.Task, 0, len(tasks))
+	keys := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		key := fmt.Sprintf("%s#follow-up-%s", task.URI, task.FollowUpAt.UTC().Format("2006-01-02"))
+		recent, err := j.repo.GetRecentNotification(did, key, followUpHistoryHours)
+		if err != nil {
+			return fmt.Errorf("failed to check notification history: %w", err)
+		}
+		if recent != nil {
+			continue
+		}
+		due = append(due, task)
+		keys = append(keys, key)
+	}
+	if len(due) == 0 {
+		return nil
+	}
+
+	notification := buildFollowUpNotification(due)
+
+	return sendAndRecord(ctx, j.notifier, j.repo, recipient, notification, models.NotificationTypeFollowUp, keys...)
+}
+
+// buildFollowUpNotification lists the waiting-for tasks to follow up on
+func buildFollowUpNotification(tasks []*models.Task) *push.Notification {
+	body := ""
+	for i, task := range tasks {
+		if i >= 3 {
+			body += fmt.Sprintf("\n...and %d more", len(tasks)-3)
+			break
+		}
+		if task.WaitingFor != "" {
+			body += fmt.Sprintf("• %s (%s)\n", task.Title, task.WaitingFor)
+		} else {
+			body += fmt.Sprintf("• %s\n", task.Title)
+		}
+	}
+
+	return &push.Notification{
+		Title: fmt.Sprintf("Follow up on %d waiting-for task%s", len(tasks), pluralize(len(tasks))),
+		Body:  body,
+		Icon:  "/static/icon-192.png",
+		Badge: "/static/icon-192.png",
+		Tag:   "waiting-follow-up",
+		Data: map[string]interface{}{
+			"type":  models.NotificationTypeFollowUp,
+			"count": len(tasks),
+			"url":   "/app/review?step=waiting",
+		},
+		Tasks: tasks,
+	}
+}
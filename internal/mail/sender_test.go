@@ -0,0 +1,118 @@
+package mail
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+)
+
+// fakeSMTPServer is a minimal local SMTP stand-in that accepts one message
+// and hands back the envelope and data
+func fakeSMTPServer(t *testing.T) (host, port string, received <-chan []string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	ch := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+		var lines []string
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			lines = append(lines, line)
+
+			switch cmd := strings.ToUpper(line); {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(cmd, "DATA"):
+				reply("354 go ahead")
+				for {
+					data, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					data = strings.TrimRight(data, "\r\n")
+					if data == "." {
+						break
+					}
+					lines = append(lines, data)
+				}
+				reply("250 queued")
+			case strings.HasPrefix(cmd, "QUIT"):
+				reply("221 bye")
+				ch <- lines
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	host, port, _ = net.SplitHostPort(listener.Addr().String())
+	return host, port, ch
+}
+
+func TestSenderSend(t *testing.T) {
+	host, port, received := fakeSMTPServer(t)
+
+	sender := NewSender(host, port, "", "", "AT Todo <todo@example.com>")
+	err := sender.Send(&Message{
+		To:      "alice@example.com",
+		Subject: "3 Tasks Due Today",
+		HTML:    "<p>Buy milk</p>",
+		Text:    "Buy milk",
+		Headers: map[string]string{"List-Unsubscribe": "<https://example.com/email/unsubscribe?token=abc>"},
+	})
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	lines := <-received
+	message := strings.Join(lines, "\n")
+
+	for _, want := range []string{
+		"MAIL FROM:<todo@example.com>",
+		"RCPT TO:<alice@example.com>",
+		"Subject: 3 Tasks Due Today",
+		"To: alice@example.com",
+		"List-Unsubscribe: <https://example.com/email/unsubscribe?token=abc>",
+		"Content-Type: text/plain; charset=utf-8",
+		"Content-Type: text/html; charset=utf-8",
+		"<p>Buy milk</p>",
+	} {
+		if !strings.Contains(message, want) {
+			t.Errorf("Expected message to contain %q, got:\n%s", want, message)
+		}
+	}
+}
+
+func TestSenderRejectsBadAddress(t *testing.T) {
+	sender := NewSender("127.0.0.1", "1", "", "", "todo@example.com")
+	if err := sender.Send(&Message{To: "not an address"}); err == nil {
+		t.Error("Expected an error for an invalid recipient")
+	}
+}
+
+func TestSanitizeHeader(t *testing.T) {
+	if got := sanitizeHeader("Hello\r\nBcc: evil@example.com"); got != "HelloBcc: evil@example.com" {
+		t.Errorf("Expected header to stay on one line, got %q", got)
+	}
+}
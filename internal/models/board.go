@@ -0,0 +1,203 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Built-in workflow statuses
+const (
+	StatusTodo       = "todo"
+	StatusInProgress = "in-progress"
+	StatusReview     = "review"
+	StatusDone       = "done"
+)
+
+// Board column limits
+const (
+	MinBoardColumns     = 2
+	MaxBoardColumns     = 8
+	MaxBoardColumnName  = 50 // Characters
+	maxBoardColumnIDLen = 32
+)
+
+// BoardColumn is one column of a list's board. Task statuses hold the column ID.
+type BoardColumn struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// DefaultBoardColumns are used by lists that don't define their own columns
+var DefaultBoardColumns = []BoardColumn{
+	{ID: StatusTodo, Name: "To Do"},
+	{ID: StatusInProgress, Name: "In Progress"},
+	{ID: StatusReview, Name: "Review"},
+	{ID: StatusDone, Name: "Done"},
+}
+
+// BoardLane is a column of a rendered board with the tasks in it
+type BoardLane struct {
+	Column   BoardColumn
+	Terminal bool // Tasks in this column are completed
+	Tasks    []*Task
+}
+
+// BoardColumns returns the list's columns, or the defaults
+func (l *TaskList) BoardColumns() []BoardColumn {
+	if len(l.Columns) >= MinBoardColumns {
+		return l.Columns
+	}
+	return DefaultBoardColumns
+}
+
+// TerminalColumn returns the last column; moving a task there completes it
+func (l *TaskList) TerminalColumn() BoardColumn {
+	columns := l.BoardColumns()
+	return columns[len(columns)-1]
+}
+
+// ColumnFor returns the column a task belongs in on this list's board.
+// Tasks with a status the list doesn't know go to the terminal column when
+// completed, otherwise to the first column.
+func (l *TaskList) ColumnFor(task *Task) BoardColumn {
+	columns := l.BoardColumns()
+	terminal := columns[len(columns)-1]
+
+	if task.Completed {
+		return terminal
+	}
+	for _, column := range columns[:len(columns)-1] {
+		if column.ID == task.Status {
+			return column
+		}
+	}
+	return columns[0]
+}
+
+// FindBoardColumn looks up one of the list's columns by ID
+func (l *TaskList) FindBoardColumn(id string) (BoardColumn, bool) {
+	for _, column := range l.BoardColumns() {
+		if column.ID == id {
+			return column, true
+		}
+	}
+	return BoardColumn{}, false
+}
+
+// Board groups the list's resolved tasks into its columns, keeping task order
+func (l *TaskList) Board() []*BoardLane {
+	columns := l.BoardColumns()
+	lanes := make([]*BoardLane, 0, len(columns))
+	byID := make(map[string]*BoardLane, len(columns))
+	for i, column := range columns {
+		lane := &BoardLane{Column: column, Terminal: i == len(columns)-1, Tasks: make([]*Task, 0)}
+		lanes = append(lanes, lane)
+		byID[column.ID] = lane
+	}
+
+	for _, task := range l.Tasks {
+		lane := byID[l.ColumnFor(task).ID]
+		lane.Tasks = append(lane.Tasks, task)
+	}
+
+	return lanes
+}
+
+// ParseBoardColumns turns column names ("Backlog, Doing, Shipped") into columns
+// with IDs derived from the names. Built-in names keep their built-in IDs so
+// tasks stay in place when switching between default and custom columns.
+func ParseBoardColumns(input string) []BoardColumn {
+	columns := make([]BoardColumn, 0)
+	seen := make(map[string]bool)
+
+	for _, name := range strings.FieldsFunc(input, func(r rune) bool { return r == ',' || r == '\n' }) {
+		name = strings.TrimSpace(name)
+		if utf8.RuneCountInString(name) > MaxBoardColumnName {
+			// Cut at a character boundary, not mid-character
+			name = strings.TrimSpace(string([]rune(name)[:MaxBoardColumnName]))
+		}
+		if name == "" {
+			continue
+		}
+		id := boardColumnID(name)
+		if id == "" {
+			// Names without any latin letters or digits
+			id = fmt.Sprintf("column-%d", len(columns)+1)
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		columns = append(columns, BoardColumn{ID: id, Name: name})
+		if len(columns) == MaxBoardColumns {
+			break
+		}
+	}
+
+	return columns
+}
+
+// boardColumnID derives a column ID (a task status value) from its name
+func boardColumnID(name string) string {
+	for _, column := range DefaultBoardColumns {
+		if strings.EqualFold(column.Name, name) || strings.EqualFold(column.ID, name) {
+			return column.ID
+		}
+	}
+
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			b.WriteRune(r)
+			dash = false
+		case b.Len() > 0 && !dash:
+			b.WriteByte('-')
+			dash = true
+		}
+		if b.Len() >= maxBoardColumnIDLen {
+			break
+		}
+	}
+	return strings.Trim(b.String(), "-")
+}
+
+// EffectiveStatus returns the task's status, falling back to todo/done for
+// tasks written before statuses existed
+func (t *Task) EffectiveStatus() string {
+	if t.Completed {
+		if t.Status == "" {
+			return StatusDone
+		}
+		return t.Status
+	}
+	if t.Status == "" {
+		return StatusTodo
+	}
+	return t.Status
+}
+
+// SetStatus moves a task to a workflow status, keeping Completed and
+// CompletedAt in sync: terminal statuses complete the task, others reopen it
+func (t *Task) SetStatus(status string, terminal bool, now time.Time) {
+	t.Status = status
+	if terminal && !t.Completed {
+		t.Completed = true
+		t.CompletedAt = &now
+	} else if !terminal && t.Completed {
+		t.Completed = false
+		t.CompletedAt = nil
+	}
+}
+
+// SetCompleted completes or reopens a task, moving its status to done or todo
+func (t *Task) SetCompleted(completed bool, now time.Time) {
+	if completed {
+		t.SetStatus(StatusDone, true, now)
+	} else {
+		t.SetStatus(StatusTodo, false, now)
+	}
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestParseBoardColumns(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []BoardColumn
+	}{
+		{
+			name:  "custom names",
+			input: "Backlog, Doing,\nShipped It!",
+			want:  []BoardColumn{{ID: "backlog", Name: "Backlog"}, {ID: "doing", Name: "Doing"}, {ID: "shipped-it", Name: "Shipped It!"}},
+		},
+		{
+			name:  "built-in names keep their IDs",
+			input: "to do, Working, DONE",
+			want:  []BoardColumn{{ID: StatusTodo, Name: "to do"}, {ID: "working", Name: "Working"}, {ID: StatusDone, Name: "DONE"}},
+		},
+		{
+			name:  "duplicates and blanks skipped",
+			input: "Doing, , doing,Done",
+			want:  []BoardColumn{{ID: "doing", Name: "Doing"}, {ID: StatusDone, Name: "Done"}},
+		},
+		{
+			name:  "names without latin letters",
+			input: "やること, 完了",
+			want:  []BoardColumn{{ID: "column-1", Name: "やること"}, {ID: "column-2", Name: "完了"}},
+		},
+		{
+			name:  "at most MaxBoardColumns",
+			input: "a,b,c,d,e,f,g,h,i,j",
+			want: []BoardColumn{{ID: "a", Name: "a"}, {ID: "b", Name: "b"}, {ID: "c", Name: "c"}, {ID: "d", Name: "d"},
+				{ID: "e", Name: "e"}, {ID: "f", Name: "f"}, {ID: "g", Name: "g"}, {ID: "h", Name: "h"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseBoardColumns(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseBoardColumns(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBoardColumnsTruncatesByCharacter(t *testing.T) {
+	// 60 three-byte characters; a byte cut would split one
+	long := strings.Repeat("完", 60)
+	columns := ParseBoardColumns(long + ", Done")
+	if len(columns) != 2 {
+		t.Fatalf("Expected 2 columns, got %+v", columns)
+	}
+	name := columns[0].Name
+	if !utf8.ValidString(name) || utf8.RuneCountInString(name) != MaxBoardColumnName {
+		t.Errorf("Expected %d whole characters, got %q", MaxBoardColumnName, name)
+	}
+}
+
+func TestBoardColumnID(t *testing.T) {
+	for name, want := range map[string]string{
+		"In Progress":               StatusInProgress,
+		"in-progress":               StatusInProgress,
+		"Review":                    StatusReview,
+		"  Waiting on  Others! ":    "waiting-on-others",
+		"Café Ideas":                "caf-ideas",
+		"Q3 2026":                   "q3-2026",
+		"!!!":                       "",
+		strings.Repeat("abcd ", 20): "abcd-abcd-abcd-abcd-abcd-abcd-ab",
+	} {
+		if got := boardColumnID(name); got != want {
+			t.Errorf("boardColumnID(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestSetStatusKeepsCompletionInSync(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	earlier := now.Add(-time.Hour)
+
+	tests := []struct {
+		name          string
+		task          Task
+		status        string
+		terminal      bool
+		wantCompleted bool
+		wantAt        *time.Time
+	}{
+		{name: "terminal completes", task: Task{Status: StatusTodo}, status: "shipped", terminal: true, wantCompleted: true, wantAt: &now},
+		{name: "terminal keeps completion time", task: Task{Completed: true, CompletedAt: &earlier}, status: StatusDone, terminal: true, wantCompleted: true, wantAt: &earlier},
+		{name: "non-terminal reopens", task: Task{Status: StatusDone, Completed: true, CompletedAt: &earlier}, status: StatusReview, wantCompleted: false},
+		{name: "non-terminal stays open", task: Task{Status: StatusTodo}, status: StatusInProgress, wantCompleted: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := tt.task
+			task.SetStatus(tt.status, tt.terminal, now)
+
+			if task.Status != tt.status {
+				t.Errorf("Status = %q, want %q", task.Status, tt.status)
+			}
+			if task.Completed != tt.wantCompleted {
+				t.Errorf("Completed = %v, want %v", task.Completed, tt.wantCompleted)
+			}
+			switch {
+			case tt.wantAt == nil && task.CompletedAt != nil:
+				t.Errorf("CompletedAt = %v, want nil", task.CompletedAt)
+			case tt.wantAt != nil && (task.CompletedAt == nil || !task.CompletedAt.Equal(*tt.wantAt)):
+				t.Errorf("CompletedAt = %v, want %v", task.CompletedAt, tt.wantAt)
+			}
+		})
+	}
+
+	// SetCompleted moves between the built-in statuses
+	task := Task{Status: StatusInProgress}
+	task.SetCompleted(true, now)
+	if task.Status != StatusDone || !task.Completed || task.CompletedAt == nil {
+		t.Errorf("SetCompleted(true) left %+v", task)
+	}
+	task.SetCompleted(false, now)
+	if task.Status != StatusTodo || task.Completed || task.CompletedAt != nil {
+		t.Errorf("SetCompleted(false) left %+v", task)
+	}
+}
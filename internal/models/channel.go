@@ -0,0 +1,41 @@
+package models
+
+// Notification delivery channels
+const (
+	ChannelPush    = "push"    // Web Push to the user's subscribed browsers
+	ChannelEmail   = "email"   // Email to the user's verified address
+	ChannelBluesky = "bluesky" // Bluesky chat DM from the service account
+	ChannelWebhook = "webhook" // The user's webhook, ntfy and Gotify targets
+)
+
+// NotificationChannels lists the known delivery channels
+var NotificationChannels = []string{ChannelPush, ChannelEmail, ChannelBluesky, ChannelWebhook}
+
+// IsNotificationChannel reports whether name is a known delivery channel
+func IsNotificationChannel(name string) bool {
+	for _, channel := range NotificationChannels {
+		if channel == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Channels returns the delivery channels the user has turned on. Settings
+// saved before channels existed get Web Push only.
+func (s *NotificationSettings) Channels() []string {
+	if s.NotificationChannels == nil {
+		return []string{ChannelPush}
+	}
+	return s.NotificationChannels
+}
+
+// ChannelEnabled reports whether the user has turned on a delivery channel
+func (s *NotificationSettings) ChannelEnabled(name string) bool {
+	for _, channel := range s.Channels() {
+		if channel == name {
+			return true
+		}
+	}
+	return false
+}
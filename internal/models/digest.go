@@ -0,0 +1,189 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// Digest kinds
+const (
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// DefaultDigestTime is when digests are sent if no time is set
+const DefaultDigestTime = "08:00"
+
+// IsDigestKind reports whether kind is a known digest kind
+func IsDigestKind(kind string) bool {
+	return kind == DigestDaily || kind == DigestWeekly
+}
+
+// Digest is a roundup of a user's tasks and calendar events: the daily digest
+// covers today, the weekly digest looks back over the past week and ahead at
+// the next one
+type Digest struct {
+	Kind string
+	Date string // Local date the digest is for, YYYY-MM-DD
+
+	// Daily
+	Overdue  []*Task          // Incomplete, past due
+	DueToday []*Task          // Incomplete, due later today
+	Events   []*CalendarEvent // Starting today
+
+	// Weekly
+	Completed []*Task     // Completed in the past 7 days
+	Slipped   []*Task     // Incomplete, were due in the past 7 days
+	Week      []DigestDay // The next 7 days
+
+	loc *time.Location // Timezone the digest's days are in
+}
+
+// DigestDay is one day of the week ahead
+type DigestDay struct {
+	Date   time.Time
+	Tasks  []*Task
+	Events []*CalendarEvent
+}
+
+// Load returns the number of tasks and events on the day
+func (d DigestDay) Load() int {
+	return len(d.Tasks) + len(d.Events)
+}
+
+// WeekLoad returns the number of tasks and events in the week ahead
+func (d *Digest) WeekLoad() int {
+	total := 0
+	for _, day := range d.Week {
+		total += day.Load()
+	}
+	return total
+}
+
+// Clock formats t as a local time of day, e.g. "3:04 PM"
+func (d *Digest) Clock(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.In(d.loc).Format("3:04 PM")
+}
+
+// IsEmpty reports whether the digest has nothing to show
+func (d *Digest) IsEmpty() bool {
+	if d.Kind == DigestWeekly {
+		return len(d.Completed) == 0 && len(d.Slipped) == 0 && d.WeekLoad() == 0
+	}
+	return len(d.Overdue) == 0 && len(d.DueToday) == 0 && len(d.Events) == 0
+}
+
+// BuildDailyDigest collects today's overdue and due tasks and events, with
+// "today" being now's day in loc
+func BuildDailyDigest(tasks []*Task, events []*CalendarEvent, now time.Time, loc *time.Location) *Digest {
+	start := startOfDay(now, loc)
+	end := start.AddDate(0, 0, 1)
+
+	digest := &Digest{
+		Kind:     DigestDaily,
+		Date:     PlanDate(now, loc),
+		loc:      loc,
+		Overdue:  make([]*Task, 0),
+		DueToday: make([]*Task, 0),
+		Events:   eventsBetween(events, start, end),
+	}
+
+	for _, task := range tasks {
+		if task.Completed || task.DueDate == nil || task.IsSomeday() {
+			continue
+		}
+		switch {
+		case task.DueDate.Before(now):
+			digest.Overdue = append(digest.Overdue, task)
+		case task.DueDate.Before(end):
+			digest.DueToday = append(digest.DueToday, task)
+		}
+	}
+
+	sortByDue(digest.Overdue)
+	sortByDue(digest.DueToday)
+	return digest
+}
+
+// BuildWeeklyDigest collects the tasks completed and slipped over the past 7
+// days and the tasks and events of the 7 days starting tomorrow in loc
+func BuildWeeklyDigest(tasks []*Task, events []*CalendarEvent, now time.Time, loc *time.Location) *Digest {
+	weekAgo := now.AddDate(0, 0, -7)
+	tomorrow := startOfDay(now, loc).AddDate(0, 0, 1)
+
+	digest := &Digest{
+		Kind:      DigestWeekly,
+		Date:      PlanDate(now, loc),
+		Completed: make([]*Task, 0),
+		Slipped:   make([]*Task, 0),
+		Week:      make([]DigestDay, 7),
+		loc:       loc,
+	}
+	for i := range digest.Week {
+		day := tomorrow.AddDate(0, 0, i)
+		digest.Week[i] = DigestDay{
+			Date:   day,
+			Tasks:  make([]*Task, 0),
+			Events: eventsBetween(events, day, day.AddDate(0, 0, 1)),
+		}
+	}
+
+	for _, task := range tasks {
+		if task.Completed {
+			if task.CompletedAt != nil && task.CompletedAt.After(weekAgo) {
+				digest.Completed = append(digest.Completed, task)
+			}
+			continue
+		}
+		if task.DueDate == nil || task.IsSomeday() {
+			continue
+		}
+		if task.DueDate.After(weekAgo) && task.DueDate.Before(now) {
+			digest.Slipped = append(digest.Slipped, task)
+			continue
+		}
+		for i := range digest.Week {
+			day := digest.Week[i].Date
+			if !task.DueDate.Before(day) && task.DueDate.Before(day.AddDate(0, 0, 1)) {
+				digest.Week[i].Tasks = append(digest.Week[i].Tasks, task)
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(digest.Completed, func(i, j int) bool {
+		return digest.Completed[i].CompletedAt.After(*digest.Completed[j].CompletedAt)
+	})
+	sortByDue(digest.Slipped)
+	for _, day := range digest.Week {
+		sortByDue(day.Tasks)
+	}
+	return digest
+}
+
+// eventsBetween returns the events that aren't cancelled starting in
+// [start, end), soonest first
+func eventsBetween(events []*CalendarEvent, start, end time.Time) []*CalendarEvent {
+	matched := make([]*CalendarEvent, 0)
+	for _, event := range events {
+		if event.StartsAt == nil || event.IsCancelled() {
+			continue
+		}
+		if !event.StartsAt.Before(start) && event.StartsAt.Before(end) {
+			matched = append(matched, event)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].StartsAt.Before(*matched[j].StartsAt)
+	})
+	return matched
+}
+
+// startOfDay returns midnight of t's day in loc
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+}
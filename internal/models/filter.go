@@ -0,0 +1,171 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Task filter status values
+const (
+	FilterStatusIncomplete = "incomplete"
+	FilterStatusCompleted  = "completed"
+)
+
+// Task filter due values (same vocabulary as the /app/tasks?due= parameter)
+const (
+	FilterDueOverdue  = "overdue"
+	FilterDueToday    = "today"
+	FilterDueUpcoming = "upcoming"
+	FilterDueNone     = "none"
+	FilterDueHas      = "has"
+)
+
+// FilterStateAll matches tasks in any GTD state, including someday/maybe
+const FilterStateAll = "all"
+
+// TaskFilter is a saved set of rules that selects tasks for a smart list
+// Empty fields match everything, except that open someday/maybe tasks only
+// match a filter asking for them; all set fields must match
+type TaskFilter struct {
+	Status  string   `json:"status,omitempty"`  // "incomplete", "completed" or "" for any
+	Tags    []string `json:"tags,omitempty"`    // Task must have every tag (case-insensitive)
+	Due     string   `json:"due,omitempty"`     // "overdue", "today", "upcoming", "none", "has" or "" for any
+	Text    string   `json:"text,omitempty"`    // Case-insensitive substring of title or description
+	State   string   `json:"state,omitempty"`   // GTD state, "all", or "" for any but someday/maybe
+	Context string   `json:"context,omitempty"` // Task must have this GTD context, e.g. "@home"
+}
+
+// IsEmpty returns true if the filter has no rules
+func (f *TaskFilter) IsEmpty() bool {
+	return f == nil || (f.Status == "" && len(f.Tags) == 0 && f.Due == "" && strings.TrimSpace(f.Text) == "" &&
+		f.State == "" && f.Context == "")
+}
+
+// Matches returns true if the task satisfies every rule of the filter
+func (f *TaskFilter) Matches(task *Task) bool {
+	if f == nil {
+		return true
+	}
+
+	switch f.State {
+	case "":
+		// Someday/maybe tasks stay out of the way until asked for
+		if task.IsSomeday() && !task.Completed {
+			return false
+		}
+	case FilterStateAll:
+	default:
+		if task.GTDState() != f.State {
+			return false
+		}
+	}
+
+	if f.Context != "" && !task.HasContext(f.Context) {
+		return false
+	}
+
+	switch f.Status {
+	case FilterStatusIncomplete:
+		if task.Completed {
+			return false
+		}
+	case FilterStatusCompleted:
+		if !task.Completed {
+			return false
+		}
+	}
+
+	for _, want := range f.Tags {
+		found := false
+		for _, tag := range task.Tags {
+			if strings.EqualFold(tag, want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	switch f.Due {
+	case FilterDueOverdue:
+		if !task.IsOverdue() {
+			return false
+		}
+	case FilterDueToday:
+		if !task.IsDueToday() {
+			return false
+		}
+	case FilterDueUpcoming:
+		if !task.IsDueSoon() {
+			return false
+		}
+	case FilterDueNone:
+		if task.DueDate != nil {
+			return false
+		}
+	case FilterDueHas:
+		if task.DueDate == nil {
+			return false
+		}
+	}
+
+	if text := strings.ToLower(strings.TrimSpace(f.Text)); text != "" {
+		if !strings.Contains(strings.ToLower(task.Title), text) &&
+			!strings.Contains(strings.ToLower(task.Description), text) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Apply returns the tasks that match the filter, keeping their order
+func (f *TaskFilter) Apply(tasks []*Task) []*Task {
+	matched := make([]*Task, 0)
+	for _, task := range tasks {
+		if f.Matches(task) {
+			matched = append(matched, task)
+		}
+	}
+	return matched
+}
+
+// Summary describes the filter rules for display, e.g. "incomplete • #work • due today"
+func (f *TaskFilter) Summary() string {
+	if f.IsEmpty() {
+		return "all tasks"
+	}
+
+	parts := make([]string, 0)
+	if f.Status != "" {
+		parts = append(parts, f.Status)
+	}
+	for _, tag := range f.Tags {
+		parts = append(parts, "#"+tag)
+	}
+	switch f.Due {
+	case FilterDueOverdue:
+		parts = append(parts, "overdue")
+	case FilterDueToday:
+		parts = append(parts, "due today")
+	case FilterDueUpcoming:
+		parts = append(parts, "due in the next 3 days")
+	case FilterDueNone:
+		parts = append(parts, "no due date")
+	case FilterDueHas:
+		parts = append(parts, "has a due date")
+	}
+	if text := strings.TrimSpace(f.Text); text != "" {
+		parts = append(parts, fmt.Sprintf("%q", text))
+	}
+	if IsGTDState(f.State) {
+		parts = append(parts, strings.ToLower(GTDStateLabel(f.State)))
+	}
+	if f.Context != "" {
+		parts = append(parts, NormalizeContext(f.Context))
+	}
+
+	return strings.Join(parts, " • ")
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Follow subject kinds
+const (
+	FollowKindList = "list" // Subject is the AT URI of a single list
+	FollowKindUser = "user" // Subject is a DID; follows every list that user publishes
+)
+
+// Activity kinds shown in the activity feed
+const (
+	ActivityTaskAdded     = "added"
+	ActivityTaskCompleted = "completed"
+)
+
+// Follow represents an app.attodo.follow record stored in the follower's repository
+type Follow struct {
+	Subject   string    `json:"subject"`          // AT URI of a list, or DID of a user
+	Kind      string    `json:"kind"`             // list or user
+	Notify    bool      `json:"notify,omitempty"` // Send a push when the followed list changes
+	CreatedAt time.Time `json:"createdAt"`
+
+	// Metadata from AT Protocol (populated after creation)
+	RKey string `json:"-"` // Record key (extracted from URI)
+	URI  string `json:"-"` // Full AT URI
+
+	// Transient fields - populated for display
+	DisplayName string `json:"-"` // List name or user handle
+}
+
+// ActivityItem is a single entry in the activity feed of followed lists
+type ActivityItem struct {
+	Kind string    `json:"kind"` // added or completed
+	At   time.Time `json:"at"`
+	Task *Task     `json:"task"`
+	List *TaskList `json:"-"`
+
+	ListName    string `json:"listName"`
+	ListURI     string `json:"listUri"`
+	OwnerHandle string `json:"ownerHandle"`
+}
+
+// PublicURL returns the public share URL of the list this activity happened on
+func (a *ActivityItem) PublicURL() string {
+	if a.List == nil || a.List.OwnerHandle == "" {
+		return ""
+	}
+	return "/list/@" + a.List.OwnerHandle + "/" + a.List.RKey
+}
+
+// BuildActivity collects task additions and completions on the given lists
+// that happened after since, newest first, capped at limit (0 = no limit).
+// Task creation time is used as the "added" time since lists do not record
+// when a task was attached to them.
+func BuildActivity(lists []*TaskList, since time.Time, limit int) []*ActivityItem {
+	items := make([]*ActivityItem, 0)
+	seen := make(map[string]bool)
+
+	for _, list := range lists {
+		for _, task := range list.Tasks {
+			if task.CreatedAt.After(since) {
+				items = appendActivity(items, seen, ActivityTaskAdded, task.CreatedAt, task, list)
+			}
+			if task.Completed && task.CompletedAt != nil && task.CompletedAt.After(since) {
+				items = appendActivity(items, seen, ActivityTaskCompleted, *task.CompletedAt, task, list)
+			}
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].At.After(items[j].At)
+	})
+
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+
+	return items
+}
+
+// appendActivity adds an item unless the same event on the same list was already recorded
+func appendActivity(items []*ActivityItem, seen map[string]bool, kind string, at time.Time, task *Task, list *TaskList) []*ActivityItem {
+	key := strings.Join([]string{kind, list.URI, task.URI}, "|")
+	if seen[key] {
+		return items
+	}
+	seen[key] = true
+
+	return append(items, &ActivityItem{
+		Kind:        kind,
+		At:          at,
+		Task:        task,
+		List:        list,
+		ListName:    list.Name,
+		ListURI:     list.URI,
+		OwnerHandle: list.OwnerHandle,
+	})
+}
@@ -0,0 +1,261 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// GTD (Getting Things Done) task states
+const (
+	GTDInbox   = "inbox"   // Captured but not yet processed (tasks without a state)
+	GTDNext    = "next"    // Next actions
+	GTDWaiting = "waiting" // Waiting for someone else
+	GTDSomeday = "someday" // Someday/maybe, hidden from the default views
+)
+
+// GTDStates lists the states in the order they're processed
+var GTDStates = []string{GTDInbox, GTDNext, GTDWaiting, GTDSomeday}
+
+// DefaultFollowUpDays is when a waiting-for task is followed up on if no date is given
+const DefaultFollowUpDays = 7
+
+// StaleAfter is how long a task can go without being reviewed before the
+// weekly review brings it up again
+const StaleAfter = 7 * 24 * time.Hour
+
+// IsGTDState returns true for a known GTD state
+func IsGTDState(state string) bool {
+	for _, s := range GTDStates {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// GTDStateLabel returns the display name of a GTD state
+func GTDStateLabel(state string) string {
+	switch state {
+	case GTDNext:
+		return "Next"
+	case GTDWaiting:
+		return "Waiting For"
+	case GTDSomeday:
+		return "Someday/Maybe"
+	default:
+		return "Inbox"
+	}
+}
+
+// NormalizeContext cleans a context name to its "@name" form
+func NormalizeContext(context string) string {
+	context = strings.TrimLeft(strings.TrimSpace(context), "@")
+	if context == "" {
+		return ""
+	}
+	return "@" + strings.ToLower(context)
+}
+
+// GTDState returns the task's GTD state; tasks without one are in the inbox
+func (t *Task) GTDState() string {
+	if IsGTDState(t.State) {
+		return t.State
+	}
+	return GTDInbox
+}
+
+// StateLabel returns the display name of the task's GTD state
+func (t *Task) StateLabel() string {
+	return GTDStateLabel(t.GTDState())
+}
+
+// IsSomeday returns true if the task is parked as someday/maybe
+func (t *Task) IsSomeday() bool {
+	return t.GTDState() == GTDSomeday
+}
+
+// IsWaiting returns true if the task is waiting for someone
+func (t *Task) IsWaiting() bool {
+	return t.GTDState() == GTDWaiting
+}
+
+// HasContext returns true if the task has a context (case-insensitive, "@" optional)
+func (t *Task) HasContext(context string) bool {
+	want := NormalizeContext(context)
+	for _, c := range t.Contexts {
+		if NormalizeContext(c) == want {
+			return true
+		}
+	}
+	return false
+}
+
+// SetState moves the task to a GTD state and marks it reviewed. Leaving the
+// waiting state clears who it waited for and the follow-up date.
+func (t *Task) SetState(state string, now time.Time) {
+	if state == GTDInbox {
+		state = ""
+	}
+	t.State = state
+	if state != GTDWaiting {
+		t.WaitingFor = ""
+		t.FollowUpAt = nil
+	}
+	t.MarkReviewed(now)
+}
+
+// SetWaiting moves the task to waiting-for, following up at followUp (or in
+// DefaultFollowUpDays days when nil)
+func (t *Task) SetWaiting(person string, followUp *time.Time, now time.Time) {
+	t.SetState(GTDWaiting, now)
+	t.WaitingFor = strings.TrimSpace(person)
+	if followUp == nil {
+		at := now.AddDate(0, 0, DefaultFollowUpDays)
+		followUp = &at
+	}
+	t.FollowUpAt = followUp
+}
+
+// MarkReviewed records that the task was looked at in a review
+func (t *Task) MarkReviewed(now time.Time) {
+	t.ReviewedAt = &now
+}
+
+// LastReviewed returns when the task was last reviewed, or created if never
+func (t *Task) LastReviewed() time.Time {
+	if t.ReviewedAt != nil && t.ReviewedAt.After(t.CreatedAt) {
+		return *t.ReviewedAt
+	}
+	return t.CreatedAt
+}
+
+// IsStale returns true if the open task hasn't been reviewed within StaleAfter
+func (t *Task) IsStale(now time.Time) bool {
+	return !t.Completed && now.Sub(t.LastReviewed()) >= StaleAfter
+}
+
+// IsFollowUpDue returns true if an open waiting-for task's follow-up date has passed
+func (t *Task) IsFollowUpDue(now time.Time) bool {
+	return !t.Completed && t.IsWaiting() && t.FollowUpAt != nil && !t.FollowUpAt.After(now)
+}
+
+// FollowUpDisplay returns the follow-up date for display, e.g. "Jan 2"
+func (t *Task) FollowUpDisplay() string {
+	if t.FollowUpAt == nil {
+		return ""
+	}
+	followUp := t.FollowUpAt.In(time.Now().Location())
+	if followUp.Year() == time.Now().Year() {
+		return followUp.Format("Jan 2")
+	}
+	return followUp.Format("Jan 2, 2006")
+}
+
+// ReviewStep is one step of the weekly review: a group of tasks to go through
+type ReviewStep struct {
+	ID    string
+	Title string
+	Help  string
+	Tasks []*Task
+}
+
+// Weekly review step IDs, in order
+const (
+	ReviewStepInbox   = "inbox"
+	ReviewStepWaiting = "waiting"
+	ReviewStepNext    = "next"
+	ReviewStepSomeday = "someday"
+)
+
+// ReviewStepIDs lists the weekly review steps in order
+var ReviewStepIDs = []string{ReviewStepInbox, ReviewStepWaiting, ReviewStepNext, ReviewStepSomeday}
+
+// WeeklyReview walks through the open tasks that need attention
+type WeeklyReview struct {
+	Steps []*ReviewStep
+}
+
+// BuildWeeklyReview picks the tasks for each review step: the whole inbox,
+// waiting-for tasks due a follow-up or not reviewed lately, and stale next
+// actions and someday/maybe tasks
+func BuildWeeklyReview(tasks []*Task, now time.Time) *WeeklyReview {
+	steps := map[string]*ReviewStep{
+		ReviewStepInbox: {
+			ID:    ReviewStepInbox,
+			Title: "Process your inbox",
+			Help:  "Decide what each captured task is: a next action, something you're waiting for, someday/maybe, or already done.",
+		},
+		ReviewStepWaiting: {
+			ID:    ReviewStepWaiting,
+			Title: "Follow up on waiting-for",
+			Help:  "Chase up anything past its follow-up date, or move it on if it arrived.",
+		},
+		ReviewStepNext: {
+			ID:    ReviewStepNext,
+			Title: "Review next actions",
+			Help:  "These next actions haven't moved in a week. Keep them, park them as someday/maybe, or finish them.",
+		},
+		ReviewStepSomeday: {
+			ID:    ReviewStepSomeday,
+			Title: "Review someday/maybe",
+			Help:  "Activate anything you're ready to start; keep the rest for later.",
+		},
+	}
+
+	for _, task := range tasks {
+		if task.Completed {
+			continue
+		}
+		switch task.GTDState() {
+		case GTDInbox:
+			steps[ReviewStepInbox].Tasks = append(steps[ReviewStepInbox].Tasks, task)
+		case GTDWaiting:
+			if task.IsFollowUpDue(now) || task.IsStale(now) {
+				steps[ReviewStepWaiting].Tasks = append(steps[ReviewStepWaiting].Tasks, task)
+			}
+		case GTDNext:
+			if task.IsStale(now) {
+				steps[ReviewStepNext].Tasks = append(steps[ReviewStepNext].Tasks, task)
+			}
+		case GTDSomeday:
+			if task.IsStale(now) {
+				steps[ReviewStepSomeday].Tasks = append(steps[ReviewStepSomeday].Tasks, task)
+			}
+		}
+	}
+
+	review := &WeeklyReview{Steps: make([]*ReviewStep, 0, len(ReviewStepIDs))}
+	for _, id := range ReviewStepIDs {
+		review.Steps = append(review.Steps, steps[id])
+	}
+	return review
+}
+
+// Step returns the review step with an ID, or nil
+func (r *WeeklyReview) Step(id string) *ReviewStep {
+	for _, step := range r.Steps {
+		if step.ID == id {
+			return step
+		}
+	}
+	return nil
+}
+
+// NextStep returns the step after the one with an ID, or nil after the last
+func (r *WeeklyReview) NextStep(id string) *ReviewStep {
+	for i, step := range r.Steps {
+		if step.ID == id && i+1 < len(r.Steps) {
+			return r.Steps[i+1]
+		}
+	}
+	return nil
+}
+
+// Remaining returns how many tasks are left to review across all steps
+func (r *WeeklyReview) Remaining() int {
+	count := 0
+	for _, step := range r.Steps {
+		count += len(step.Tasks)
+	}
+	return count
+}
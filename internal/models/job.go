@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// What started a background job run
+const (
+	JobTriggerSchedule = "schedule"
+	JobTriggerManual   = "manual"
+)
+
+// Background job run statuses
+const (
+	JobRunRunning     = "running"
+	JobRunSucceeded   = "succeeded"
+	JobRunFailed      = "failed"
+	JobRunInterrupted = "interrupted" // Cut short by a restart
+)
+
+// JobRun is one run of a background job
+type JobRun struct {
+	ID           int64      `db:"id" json:"id"`
+	JobName      string     `db:"job_name" json:"jobName"`
+	Instance     string     `db:"instance" json:"instance,omitempty"` // Server instance the run ran on
+	TriggeredBy  string     `db:"triggered_by" json:"triggeredBy"`
+	Status       string     `db:"status" json:"status"`
+	StartedAt    time.Time  `db:"started_at" json:"startedAt"`
+	FinishedAt   *time.Time `db:"finished_at" json:"finishedAt,omitempty"`
+	ErrorMessage string     `db:"error_message" json:"errorMessage,omitempty"`
+}
+
+// Duration returns how long a finished run took
+func (r *JobRun) Duration() time.Duration {
+	if r.FinishedAt == nil {
+		return 0
+	}
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+// Lease is a time-limited claim by one server instance, e.g. to run a job
+type Lease struct {
+	Name       string    `db:"name" json:"name"`
+	Holder     string    `db:"holder" json:"holder"`
+	AcquiredAt time.Time `db:"acquired_at" json:"acquiredAt"`
+	ExpiresAt  time.Time `db:"expires_at" json:"expiresAt"`
+}
@@ -0,0 +1,208 @@
+package models
+
+import "errors"
+
+// MaxListDepth limits how many levels deep lists can be nested
+const MaxListDepth = 5
+
+var (
+	ErrListCycle   = errors.New("a list can't be nested under itself or one of its sublists")
+	ErrListTooDeep = errors.New("lists can't be nested more than 5 levels deep")
+)
+
+// BuildListTree links lists to their parents and returns the top-level lists,
+// keeping the input order at every level. Lists whose parent is missing
+// (e.g. deleted) or that are part of a parent cycle are treated as top-level.
+func BuildListTree(lists []*TaskList) []*TaskList {
+	byURI := make(map[string]*TaskList, len(lists))
+	for _, list := range lists {
+		list.Children = nil
+		list.Depth = 0
+		list.parentList = nil
+		byURI[list.URI] = list
+	}
+
+	roots := make([]*TaskList, 0)
+	for _, list := range lists {
+		parent, ok := byURI[list.Parent]
+		if !ok || list.Parent == "" || hasAncestor(byURI, list.Parent, list.URI) {
+			roots = append(roots, list)
+			continue
+		}
+		parent.Children = append(parent.Children, list)
+		list.parentList = parent
+	}
+
+	for _, root := range roots {
+		setDepth(root, 0)
+	}
+
+	return roots
+}
+
+// FlattenListTree returns the lists of a tree in display order (each list
+// followed by its children), with Depth set for indentation
+func FlattenListTree(roots []*TaskList) []*TaskList {
+	flat := make([]*TaskList, 0, len(roots))
+	var walk func(lists []*TaskList)
+	walk = func(lists []*TaskList) {
+		for _, list := range lists {
+			flat = append(flat, list)
+			walk(list.Children)
+		}
+	}
+	walk(roots)
+	return flat
+}
+
+// ValidateParent checks that the list at childURI can be moved under
+// parentURI without creating a cycle or exceeding MaxListDepth.
+// It builds the tree, so Children and Depth are (re)populated on lists.
+func ValidateParent(lists []*TaskList, childURI, parentURI string) error {
+	if parentURI == "" {
+		return nil
+	}
+	if parentURI == childURI {
+		return ErrListCycle
+	}
+
+	byURI := make(map[string]*TaskList, len(lists))
+	for _, list := range lists {
+		byURI[list.URI] = list
+	}
+	if hasAncestor(byURI, parentURI, childURI) {
+		return ErrListCycle
+	}
+
+	BuildListTree(lists)
+
+	// Levels down to the new parent plus the levels the moved list brings along
+	height := 1
+	if child, ok := byURI[childURI]; ok {
+		height = subtreeHeight(child)
+	}
+	parentDepth := 0
+	if parent, ok := byURI[parentURI]; ok {
+		parentDepth = parent.Depth + 1
+	}
+
+	if parentDepth+height > MaxListDepth {
+		return ErrListTooDeep
+	}
+	return nil
+}
+
+// ReparentChildren moves the direct sublists of the list at uri up to that
+// list's own parent, for when it's deleted, and returns the lists it moved
+func ReparentChildren(lists []*TaskList, uri string) []*TaskList {
+	newParent := ""
+	for _, list := range lists {
+		if list.URI == uri {
+			newParent = list.Parent
+			break
+		}
+	}
+
+	moved := make([]*TaskList, 0)
+	for _, list := range lists {
+		if list.Parent == uri && list.URI != uri {
+			list.Parent = newParent
+			moved = append(moved, list)
+		}
+	}
+	return moved
+}
+
+// Ancestors returns the parents of a list from the top-level list down
+// (requires BuildListTree)
+func (l *TaskList) Ancestors() []*TaskList {
+	ancestors := make([]*TaskList, 0, l.Depth)
+	for parent := l.parentList; parent != nil; parent = parent.parentList {
+		ancestors = append([]*TaskList{parent}, ancestors...)
+	}
+	return ancestors
+}
+
+// RollupCompleted returns the number of completed tasks in this list and all
+// nested lists, counting tasks that appear in several lists once
+func (l *TaskList) RollupCompleted() int {
+	completed := 0
+	for _, task := range l.rollupTasks() {
+		if task.Completed {
+			completed++
+		}
+	}
+	return completed
+}
+
+// RollupTotal returns the number of tasks in this list and all nested lists
+func (l *TaskList) RollupTotal() int {
+	return len(l.rollupTasks())
+}
+
+// RollupPercent returns the rolled up completion percentage (0-100)
+func (l *TaskList) RollupPercent() int {
+	total := l.RollupTotal()
+	if total == 0 {
+		return 0
+	}
+	return l.RollupCompleted() * 100 / total
+}
+
+// rollupTasks collects the unique resolved tasks of a list and its descendants
+func (l *TaskList) rollupTasks() []*Task {
+	seen := make(map[string]bool)
+	tasks := make([]*Task, 0)
+
+	var walk func(list *TaskList)
+	walk = func(list *TaskList) {
+		for _, task := range list.Tasks {
+			if seen[task.URI] {
+				continue
+			}
+			seen[task.URI] = true
+			tasks = append(tasks, task)
+		}
+		for _, child := range list.Children {
+			walk(child)
+		}
+	}
+	walk(l)
+
+	return tasks
+}
+
+// hasAncestor walks up the parent chain from uri looking for target
+func hasAncestor(byURI map[string]*TaskList, uri, target string) bool {
+	seen := make(map[string]bool)
+	for uri != "" && !seen[uri] {
+		if uri == target {
+			return true
+		}
+		seen[uri] = true
+		list, ok := byURI[uri]
+		if !ok {
+			return false
+		}
+		uri = list.Parent
+	}
+	return false
+}
+
+// subtreeHeight returns the number of levels in a list's subtree, including itself
+func subtreeHeight(list *TaskList) int {
+	height := 0
+	for _, child := range list.Children {
+		if h := subtreeHeight(child); h > height {
+			height = h
+		}
+	}
+	return height + 1
+}
+
+func setDepth(list *TaskList, depth int) {
+	list.Depth = depth
+	for _, child := range list.Children {
+		setDepth(child, depth+1)
+	}
+}
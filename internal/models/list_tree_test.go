@@ -0,0 +1,158 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// testLists builds lists named by URI with the given parents ("" for top-level)
+func testLists(parents [][2]string) []*TaskList {
+	lists := make([]*TaskList, len(parents))
+	for i, p := range parents {
+		lists[i] = &TaskList{URI: p[0], Parent: p[1]}
+	}
+	return lists
+}
+
+func uris(lists []*TaskList) []string {
+	out := make([]string, 0, len(lists))
+	for _, list := range lists {
+		out = append(out, list.URI)
+	}
+	return out
+}
+
+// chain returns lists nested n deep: l1 > l2 > ... > ln
+func chain(n int) [][2]string {
+	parents := make([][2]string, n)
+	for i := range parents {
+		parents[i][0] = fmt.Sprintf("l%d", i+1)
+		if i > 0 {
+			parents[i][1] = fmt.Sprintf("l%d", i)
+		}
+	}
+	return parents
+}
+
+func TestBuildListTree(t *testing.T) {
+	tests := []struct {
+		name      string
+		lists     [][2]string
+		wantRoots []string
+		wantFlat  []string
+		wantDepth map[string]int
+	}{
+		{
+			name:      "nested lists keep input order",
+			lists:     [][2]string{{"work", ""}, {"home", ""}, {"q1", "work"}, {"q2", "work"}, {"jan", "q1"}},
+			wantRoots: []string{"work", "home"},
+			wantFlat:  []string{"work", "q1", "jan", "q2", "home"},
+			wantDepth: map[string]int{"work": 0, "q1": 1, "jan": 2, "q2": 1, "home": 0},
+		},
+		{
+			name:      "missing parent becomes top-level",
+			lists:     [][2]string{{"a", ""}, {"orphan", "deleted"}, {"child", "orphan"}},
+			wantRoots: []string{"a", "orphan"},
+			wantFlat:  []string{"a", "orphan", "child"},
+			wantDepth: map[string]int{"a": 0, "orphan": 0, "child": 1},
+		},
+		{
+			name:      "cycle members become top-level",
+			lists:     [][2]string{{"x", "z"}, {"y", "x"}, {"z", "y"}, {"w", "x"}},
+			wantRoots: []string{"x", "y", "z"},
+			wantFlat:  []string{"x", "w", "y", "z"},
+			wantDepth: map[string]int{"x": 0, "y": 0, "z": 0, "w": 1},
+		},
+		{
+			name:      "self parent",
+			lists:     [][2]string{{"self", "self"}},
+			wantRoots: []string{"self"},
+			wantFlat:  []string{"self"},
+			wantDepth: map[string]int{"self": 0},
+		},
+		{
+			name:      "deeper than the limit is still shown",
+			lists:     chain(MaxListDepth + 2),
+			wantRoots: []string{"l1"},
+			wantFlat:  []string{"l1", "l2", "l3", "l4", "l5", "l6", "l7"},
+			wantDepth: map[string]int{"l1": 0, "l7": MaxListDepth + 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roots := BuildListTree(testLists(tt.lists))
+			if got := uris(roots); !reflect.DeepEqual(got, tt.wantRoots) {
+				t.Errorf("roots = %v, want %v", got, tt.wantRoots)
+			}
+			flat := FlattenListTree(roots)
+			if got := uris(flat); !reflect.DeepEqual(got, tt.wantFlat) {
+				t.Errorf("flattened = %v, want %v", got, tt.wantFlat)
+			}
+			for _, list := range flat {
+				if want, ok := tt.wantDepth[list.URI]; ok && list.Depth != want {
+					t.Errorf("%s depth = %d, want %d", list.URI, list.Depth, want)
+				}
+				if len(list.Ancestors()) != list.Depth {
+					t.Errorf("%s has %d ancestors at depth %d", list.URI, len(list.Ancestors()), list.Depth)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateParent(t *testing.T) {
+	tests := []struct {
+		name   string
+		lists  [][2]string
+		child  string
+		parent string
+		want   error
+	}{
+		{name: "top-level", lists: chain(3), child: "l3", parent: "", want: nil},
+		{name: "under a sibling", lists: [][2]string{{"a", ""}, {"b", ""}}, child: "b", parent: "a", want: nil},
+		{name: "under itself", lists: chain(2), child: "l2", parent: "l2", want: ErrListCycle},
+		{name: "under its own sublist", lists: chain(3), child: "l1", parent: "l3", want: ErrListCycle},
+		{name: "at the depth limit", lists: chain(MaxListDepth - 1), child: "new", parent: fmt.Sprintf("l%d", MaxListDepth-1), want: nil},
+		{name: "past the depth limit", lists: chain(MaxListDepth), child: "new", parent: fmt.Sprintf("l%d", MaxListDepth), want: ErrListTooDeep},
+		{
+			name:   "subtree pushed past the limit",
+			lists:  append(chain(3), [2]string{"m1", ""}, [2]string{"m2", "m1"}, [2]string{"m3", "m2"}),
+			child:  "m1",
+			parent: "l3",
+			want:   ErrListTooDeep,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateParent(testLists(tt.lists), tt.child, tt.parent)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("ValidateParent(%s under %s) = %v, want %v", tt.child, tt.parent, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReparentChildren(t *testing.T) {
+	lists := testLists([][2]string{{"root", ""}, {"mid", "root"}, {"a", "mid"}, {"b", "mid"}, {"grand", "a"}, {"other", "root"}})
+
+	moved := ReparentChildren(lists, "mid")
+	if got := uris(moved); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("moved = %v, want [a b]", got)
+	}
+	for _, list := range lists {
+		want := map[string]string{"root": "", "mid": "root", "a": "root", "b": "root", "grand": "a", "other": "root"}[list.URI]
+		if list.Parent != want {
+			t.Errorf("%s parent = %q, want %q", list.URI, list.Parent, want)
+		}
+	}
+
+	// Sublists of a deleted top-level list become top-level
+	moved = ReparentChildren(lists, "root")
+	if len(moved) != 4 || lists[2].Parent != "" {
+		t.Errorf("Expected root's 4 sublists moved to the top, got %v", uris(moved))
+	}
+}
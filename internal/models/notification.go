@@ -2,10 +2,26 @@ package models
 
 import "time"
 
+// Notification types recorded in notification_history
+const (
+	NotificationTypeOverdue       = "overdue"
+	NotificationTypeDueToday      = "due_today"
+	NotificationTypeDueSoon       = "due_soon"
+	NotificationTypeCalendarEvent = "calendar_event"
+	NotificationTypeListActivity  = "list_activity"
+	NotificationTypePlanNudge     = "plan_nudge"
+	NotificationTypePlanSummary   = "plan_summary"
+	NotificationTypeFollowUp      = "follow_up"
+	NotificationTypeDigestDaily   = "digest_daily"
+	NotificationTypeDigestWeekly  = "digest_weekly"
+	NotificationTypeReminder      = "reminder"
+)
+
 // NotificationUser represents a user who has enabled notifications
 type NotificationUser struct {
 	DID                  string     `db:"did" json:"did"`
 	NotificationsEnabled bool       `db:"notifications_enabled" json:"notificationsEnabled"`
+	BlueskyDMEnabled     bool       `db:"bluesky_dm_enabled" json:"blueskyDmEnabled"`
 	LastCheckedAt        *time.Time `db:"last_checked_at" json:"lastCheckedAt,omitempty"`
 	CreatedAt            time.Time  `db:"created_at" json:"createdAt"`
 	UpdatedAt            time.Time  `db:"updated_at" json:"updatedAt"`
@@ -23,13 +39,77 @@ type PushSubscription struct {
 	LastUsedAt time.Time `db:"last_used_at" json:"lastUsedAt"`
 }
 
+// PushDelivery is a push notification waiting to be retried after the push
+// service rate limited it, failed or couldn't be reached
+type PushDelivery struct {
+	ID               int64     `db:"id" json:"id"`
+	DID              string    `db:"did" json:"did"`
+	Endpoint         string    `db:"endpoint" json:"endpoint"`
+	Payload          string    `db:"payload" json:"payload"` // Notification JSON, as sent to the push service
+	NotificationType string    `db:"notification_type" json:"notificationType"`
+	TaskURI          string    `db:"task_uri" json:"taskUri"` // What the notification is about
+	Attempts         int       `db:"attempts" json:"attempts"`
+	NextAttemptAt    time.Time `db:"next_attempt_at" json:"nextAttemptAt"`
+	LastError        string    `db:"last_error" json:"lastError,omitempty"`
+	CreatedAt        time.Time `db:"created_at" json:"createdAt"`
+}
+
+// EmailAddress is a user's address for email notifications. It only gets
+// notifications once verified (double opt-in) and until the user unsubscribes.
+type EmailAddress struct {
+	DID              string     `db:"did" json:"-"`
+	Email            string     `db:"email" json:"email"`
+	VerifyToken      string     `db:"verify_token" json:"-"` // Empty once verified
+	VerifySentAt     *time.Time `db:"verify_sent_at" json:"-"`
+	VerifiedAt       *time.Time `db:"verified_at" json:"verifiedAt,omitempty"`
+	UnsubscribeToken string     `db:"unsubscribe_token" json:"-"`
+	UnsubscribedAt   *time.Time `db:"unsubscribed_at" json:"unsubscribedAt,omitempty"`
+	CreatedAt        time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt        time.Time  `db:"updated_at" json:"updatedAt"`
+}
+
+// Active reports whether the address gets notifications
+func (e *EmailAddress) Active() bool {
+	return e.VerifiedAt != nil && e.UnsubscribedAt == nil
+}
+
+// Webhook target kinds
+const (
+	WebhookKindWebhook = "webhook" // HMAC-signed JSON POST to any URL
+	WebhookKindNtfy    = "ntfy"    // ntfy topic URL
+	WebhookKindGotify  = "gotify"  // Gotify server, with an application token
+)
+
+// Webhook is an outgoing notification target. It's disabled after too many
+// deliveries in a row fail.
+type Webhook struct {
+	ID              int64      `db:"id" json:"id"`
+	DID             string     `db:"did" json:"-"`
+	Kind            string     `db:"kind" json:"kind"` // See WebhookKind* constants
+	Name            string     `db:"name" json:"name"`
+	URL             string     `db:"url" json:"url"`
+	Secret          string     `db:"secret" json:"-"` // HMAC secret for webhooks, access token for ntfy/Gotify
+	FailureCount    int        `db:"failure_count" json:"failureCount"`
+	LastError       string     `db:"last_error" json:"lastError,omitempty"`
+	LastDeliveredAt *time.Time `db:"last_delivered_at" json:"lastDeliveredAt,omitempty"`
+	DisabledAt      *time.Time `db:"disabled_at" json:"disabledAt,omitempty"`
+	CreatedAt       time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt       time.Time  `db:"updated_at" json:"updatedAt"`
+}
+
+// Active reports whether the target gets notifications
+func (w *Webhook) Active() bool {
+	return w.DisabledAt == nil
+}
+
 // NotificationHistory tracks sent notifications to prevent spam
 type NotificationHistory struct {
 	ID               int64     `db:"id" json:"id"`
 	DID              string    `db:"did" json:"did"`
 	TaskURI          string    `db:"task_uri" json:"taskUri"`
-	NotificationType string    `db:"notification_type" json:"notificationType"` // 'overdue', 'due_today', 'due_soon'
+	NotificationType string    `db:"notification_type" json:"notificationType"` // See NotificationType* constants
 	SentAt           time.Time `db:"sent_at" json:"sentAt"`
-	Status           string    `db:"status" json:"status"` // 'sent', 'failed', 'expired'
+	Status           string    `db:"status" json:"status"` // 'sent', 'failed', 'expired', 'retrying'
 	ErrorMessage     string    `db:"error_message" json:"errorMessage,omitempty"`
+	Endpoint         string    `db:"endpoint" json:"endpoint,omitempty"` // Set for a push delivery to one endpoint
 }
@@ -0,0 +1,263 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// PlanDateLayout is the format of plan dates, which are also the plan record keys
+const PlanDateLayout = "2006-01-02"
+
+// MaxPlanSuggestions limits the suggested tasks offered while planning
+const MaxPlanSuggestions = 5
+
+// DayPlan represents an app.attodo.plan record: the tasks picked for one day
+type DayPlan struct {
+	Date        string    `json:"date"`                  // Local date, YYYY-MM-DD
+	TaskURIs    []string  `json:"taskUris"`              // Planned tasks in order
+	CarriedOver bool      `json:"carriedOver,omitempty"` // The carry-over prompt for this day was answered
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+
+	// Metadata from AT Protocol (populated after creation)
+	RKey string `json:"rkey,omitempty"` // Record key (the date)
+	URI  string `json:"uri,omitempty"`  // Full AT URI
+
+	// Transient field - resolved from TaskURIs, missing tasks are skipped
+	Tasks []*Task `json:"-"`
+}
+
+// NewDayPlan returns an empty plan for a date
+func NewDayPlan(date string, now time.Time) *DayPlan {
+	return &DayPlan{
+		Date:      date,
+		TaskURIs:  make([]string, 0),
+		CreatedAt: now,
+		UpdatedAt: now,
+		RKey:      date,
+	}
+}
+
+// ParsePlanDate validates a YYYY-MM-DD plan date
+func ParsePlanDate(date string) (time.Time, error) {
+	t, err := time.Parse(PlanDateLayout, date)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid plan date %q", date)
+	}
+	return t, nil
+}
+
+// PlanDate returns the plan date of t in loc
+func PlanDate(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format(PlanDateLayout)
+}
+
+// UserLocation loads a user's IANA timezone, falling back to the server's
+func UserLocation(name string) *time.Location {
+	if name != "" {
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc
+		}
+	}
+	return time.Local
+}
+
+// AtClock returns the time on t's day at an "HH:MM" clock time in loc
+func AtClock(t time.Time, clock string, loc *time.Location) (time.Time, bool) {
+	parsed, err := time.Parse("15:04", clock)
+	if err != nil {
+		return time.Time{}, false
+	}
+	local := t.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), parsed.Hour(), parsed.Minute(), 0, 0, loc), true
+}
+
+// Contains reports whether a task is planned
+func (p *DayPlan) Contains(uri string) bool {
+	for _, u := range p.TaskURIs {
+		if u == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// Add appends a task to the plan, returning false if it was already planned
+func (p *DayPlan) Add(uri string) bool {
+	if p.Contains(uri) {
+		return false
+	}
+	p.TaskURIs = append(p.TaskURIs, uri)
+	return true
+}
+
+// Remove takes a task out of the plan, returning false if it wasn't planned
+func (p *DayPlan) Remove(uri string) bool {
+	for i, u := range p.TaskURIs {
+		if u == uri {
+			p.TaskURIs = append(p.TaskURIs[:i], p.TaskURIs[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve fills Tasks from a set of tasks keyed by URI, in plan order
+func (p *DayPlan) Resolve(tasksByURI map[string]*Task) {
+	p.Tasks = make([]*Task, 0, len(p.TaskURIs))
+	for _, uri := range p.TaskURIs {
+		if task, ok := tasksByURI[uri]; ok {
+			p.Tasks = append(p.Tasks, task)
+		}
+	}
+}
+
+// CompletedCount returns how many of the resolved planned tasks are done
+func (p *DayPlan) CompletedCount() int {
+	count := 0
+	for _, task := range p.Tasks {
+		if task.Completed {
+			count++
+		}
+	}
+	return count
+}
+
+// Unfinished returns the resolved planned tasks that are still incomplete
+func (p *DayPlan) Unfinished() []*Task {
+	tasks := make([]*Task, 0)
+	for _, task := range p.Tasks {
+		if !task.Completed {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+// Done reports whether every planned task has been completed
+func (p *DayPlan) Done() bool {
+	return len(p.Tasks) > 0 && p.CompletedCount() == len(p.Tasks)
+}
+
+// DayPlanning is everything shown while planning a day: the plan itself, the
+// tasks to pick from, and the unfinished tasks of the previous plan
+type DayPlanning struct {
+	Date     string
+	Plan     *DayPlan
+	Overdue  []*Task // Incomplete, past due, not planned
+	DueToday []*Task // Incomplete, due later today, not planned
+	Suggest  []*Task // Other incomplete tasks worth doing today
+
+	// Previous plan with unfinished tasks, offered for carry-over until the
+	// prompt is answered
+	Previous  *DayPlan
+	CarryOver []*Task
+}
+
+// PlanGroup is a titled group of tasks to pick from
+type PlanGroup struct {
+	Title string
+	Tasks []*Task
+}
+
+// Groups returns the non-empty groups of tasks to pick from, most urgent first
+func (p *DayPlanning) Groups() []PlanGroup {
+	groups := make([]PlanGroup, 0, 3)
+	for _, group := range []PlanGroup{
+		{Title: "Overdue", Tasks: p.Overdue},
+		{Title: "Due Today", Tasks: p.DueToday},
+		{Title: "Suggestions", Tasks: p.Suggest},
+	} {
+		if len(group.Tasks) > 0 {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// BuildDayPlanning sorts tasks into the planning groups. plan must already be
+// resolved; previous (the latest earlier plan, may be nil) too.
+func BuildDayPlanning(tasks []*Task, plan, previous *DayPlan) *DayPlanning {
+	planning := &DayPlanning{
+		Date:     plan.Date,
+		Plan:     plan,
+		Overdue:  make([]*Task, 0),
+		DueToday: make([]*Task, 0),
+		Suggest:  make([]*Task, 0),
+	}
+
+	candidates := make([]*Task, 0)
+	for _, task := range tasks {
+		// Someday/maybe tasks aren't planned until they're activated
+		if task.Completed || task.IsSomeday() || plan.Contains(task.URI) {
+			continue
+		}
+		switch {
+		case task.IsOverdue():
+			planning.Overdue = append(planning.Overdue, task)
+		case task.IsDueToday():
+			planning.DueToday = append(planning.DueToday, task)
+		case !task.IsWaiting():
+			candidates = append(candidates, task)
+		}
+	}
+
+	sortByDue(planning.Overdue)
+	sortByDue(planning.DueToday)
+	planning.Suggest = suggestPlanTasks(candidates, MaxPlanSuggestions)
+
+	if previous != nil && !plan.CarriedOver {
+		for _, task := range previous.Unfinished() {
+			if !plan.Contains(task.URI) {
+				planning.CarryOver = append(planning.CarryOver, task)
+			}
+		}
+		if len(planning.CarryOver) > 0 {
+			planning.Previous = previous
+		}
+	}
+
+	return planning
+}
+
+// suggestPlanTasks picks tasks already in progress, then next actions, then
+// tasks due in the next few days (soonest first), then the oldest open tasks
+func suggestPlanTasks(tasks []*Task, limit int) []*Task {
+	rank := func(task *Task) int {
+		switch {
+		case task.Status == StatusInProgress || task.Status == StatusReview:
+			return 0
+		case task.GTDState() == GTDNext:
+			return 1
+		case task.IsDueSoon():
+			return 2
+		default:
+			return 3
+		}
+	}
+
+	sorted := make([]*Task, len(tasks))
+	copy(sorted, tasks)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ri, rj := rank(sorted[i]), rank(sorted[j])
+		if ri != rj {
+			return ri < rj
+		}
+		if ri == 2 {
+			return sorted[i].DueDate.Before(*sorted[j].DueDate)
+		}
+		return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+	})
+
+	if len(sorted) > limit {
+		sorted = sorted[:limit]
+	}
+	return sorted
+}
+
+func sortByDue(tasks []*Task) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].DueDate.Before(*tasks[j].DueDate)
+	})
+}
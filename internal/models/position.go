@@ -0,0 +1,254 @@
+package models
+
+import (
+	"errors"
+	"slices"
+	"sort"
+)
+
+// positionDigits are the base-62 digits of fractional position keys, in sort order
+const positionDigits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// ErrInvalidPosition is returned for position keys that can't be ordered between
+var ErrInvalidPosition = errors.New("invalid position key")
+
+// PositionBetween returns a fractional position key that sorts strictly between
+// a and b. An empty a means "before everything", an empty b "after everything".
+// Moving an item only rewrites its own key, so a move re-applied to a record
+// another device reordered in between keeps that device's positions.
+func PositionBetween(a, b string) (string, error) {
+	if !validPosition(a) || !validPosition(b) || (b != "" && a >= b) {
+		return "", ErrInvalidPosition
+	}
+	return midpoint(a, b), nil
+}
+
+// PositionsBetween returns n increasing position keys between a and b
+func PositionsBetween(a, b string, n int) ([]string, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	mid, err := PositionBetween(a, b)
+	if err != nil {
+		return nil, err
+	}
+	if n == 1 {
+		return []string{mid}, nil
+	}
+
+	// Split around the midpoint so key lengths grow logarithmically
+	left, err := PositionsBetween(a, mid, (n-1)/2)
+	if err != nil {
+		return nil, err
+	}
+	right, err := PositionsBetween(mid, b, n-1-len(left))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := append(left, mid)
+	return append(keys, right...), nil
+}
+
+// OrderByPosition returns uris in manual order: items without a position come
+// first in their given order (so new items show at the top), followed by the
+// positioned items by key, with ties broken by URI
+func OrderByPosition(uris []string, positions map[string]string) []string {
+	unpositioned := make([]string, 0)
+	positioned := make([]string, 0, len(positions))
+	for _, uri := range uris {
+		if _, ok := positions[uri]; ok {
+			positioned = append(positioned, uri)
+		} else {
+			unpositioned = append(unpositioned, uri)
+		}
+	}
+
+	sort.SliceStable(positioned, func(i, j int) bool {
+		pi, pj := positions[positioned[i]], positions[positioned[j]]
+		if pi != pj {
+			return pi < pj
+		}
+		return positioned[i] < positioned[j]
+	})
+
+	return append(unpositioned, positioned...)
+}
+
+// MovePosition places uri after prev (or before next if prev is unknown) within
+// the manual order of uris, updating positions in place. Items that have no
+// position yet are given one so the order shown to the user is kept.
+// Positions of URIs not in uris are dropped.
+func MovePosition(uris []string, positions map[string]string, uri, prev, next string) error {
+	// Forget items that are no longer part of the set, and keys written by
+	// other clients that can't be ordered between
+	present := make(map[string]bool, len(uris))
+	for _, u := range uris {
+		present[u] = true
+	}
+	for u, key := range positions {
+		if !present[u] || key == "" || !validPosition(key) {
+			delete(positions, u)
+		}
+	}
+
+	ordered := OrderByPosition(uris, positions)
+
+	// Give unpositioned items keys ahead of the first positioned one
+	unpositioned := 0
+	for unpositioned < len(ordered) {
+		if _, ok := positions[ordered[unpositioned]]; ok {
+			break
+		}
+		unpositioned++
+	}
+	if unpositioned > 0 {
+		upper := ""
+		if unpositioned < len(ordered) {
+			upper = positions[ordered[unpositioned]]
+		}
+		keys, err := PositionsBetween("", upper, unpositioned)
+		if err != nil {
+			return err
+		}
+		for i, key := range keys {
+			positions[ordered[i]] = key
+		}
+	}
+
+	// Take the moved item out and find its new neighbours
+	rest := make([]string, 0, len(ordered))
+	for _, u := range ordered {
+		if u != uri {
+			rest = append(rest, u)
+		}
+	}
+
+	index := 0 // Insert position within rest
+	if i := slices.Index(rest, prev); prev != "" && i >= 0 {
+		index = i + 1
+	} else if i := slices.Index(rest, next); next != "" && i >= 0 {
+		index = i
+	}
+
+	lower, upper := "", ""
+	if index > 0 {
+		lower = positions[rest[index-1]]
+	}
+	if index < len(rest) {
+		upper = positions[rest[index]]
+	}
+
+	// Concurrent moves can leave two items with the same key; re-space the
+	// neighbourhood rather than failing
+	if upper != "" && lower >= upper {
+		keys, err := PositionsBetween(lower, "", len(rest)-index)
+		if err != nil {
+			return err
+		}
+		for i, key := range keys {
+			positions[rest[index+i]] = key
+		}
+		upper = positions[rest[index]]
+	}
+
+	key, err := PositionBetween(lower, upper)
+	if err != nil {
+		return err
+	}
+	positions[uri] = key
+	return nil
+}
+
+// midpoint finds a key between a and b (b == "" is unbounded); see PositionBetween
+func midpoint(a, b string) string {
+	// Keep any common prefix, padding a with zeros
+	if b != "" {
+		n := 0
+		for n < len(b) && digitAt(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			rest := ""
+			if n < len(a) {
+				rest = a[n:]
+			}
+			return b[:n] + midpoint(rest, b[n:])
+		}
+	}
+
+	digitA := 0
+	if a != "" {
+		digitA = positionDigitValue(a[0])
+	}
+	digitB := len(positionDigits)
+	if b != "" {
+		digitB = positionDigitValue(b[0])
+	}
+
+	if digitB-digitA > 1 {
+		return string(positionDigits[(digitA+digitB+1)/2])
+	}
+
+	// Consecutive first digits: a longer b already sorts between them
+	if len(b) > 1 {
+		return b[:1]
+	}
+
+	rest := ""
+	if len(a) > 1 {
+		rest = a[1:]
+	}
+	return string(positionDigits[digitA]) + midpoint(rest, "")
+}
+
+// validPosition checks a key only uses position digits and doesn't end in the
+// smallest digit (nothing could be placed right before such a key)
+func validPosition(key string) bool {
+	for i := 0; i < len(key); i++ {
+		if positionDigitValue(key[i]) < 0 {
+			return false
+		}
+	}
+	return key == "" || key[len(key)-1] != positionDigits[0]
+}
+
+func digitAt(s string, i int) byte {
+	if i < len(s) {
+		return s[i]
+	}
+	return positionDigits[0]
+}
+
+func positionDigitValue(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'A' && c <= 'Z':
+		return int(c-'A') + 10
+	case c >= 'a' && c <= 'z':
+		return int(c-'a') + 36
+	}
+	return -1
+}
+
+// OrderTasks sorts the list's resolved tasks into its manual order; tasks
+// without a position keep their current order ahead of positioned ones
+func (l *TaskList) OrderTasks() {
+	if len(l.Positions) == 0 {
+		return
+	}
+
+	byURI := make(map[string]*Task, len(l.Tasks))
+	uris := make([]string, 0, len(l.Tasks))
+	for _, task := range l.Tasks {
+		byURI[task.URI] = task
+		uris = append(uris, task.URI)
+	}
+
+	ordered := make([]*Task, 0, len(l.Tasks))
+	for _, uri := range OrderByPosition(uris, l.Positions) {
+		ordered = append(ordered, byURI[uri])
+	}
+	l.Tasks = ordered
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPositionBetween(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+	}{
+		{name: "empty range", a: "", b: ""},
+		{name: "after key", a: "V", b: ""},
+		{name: "before key", a: "", b: "V"},
+		{name: "wide gap", a: "A", b: "z"},
+		{name: "consecutive digits", a: "A", b: "B"},
+		{name: "consecutive with longer upper", a: "A", b: "B5"},
+		{name: "common prefix", a: "Ab", b: "Ac"},
+		{name: "prefix of upper", a: "A", b: "A1"},
+		{name: "after last digit", a: "z", b: ""},
+		{name: "before smallest", a: "", b: "01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := PositionBetween(tt.a, tt.b)
+			if err != nil {
+				t.Fatalf("PositionBetween(%q, %q) returned error: %v", tt.a, tt.b, err)
+			}
+			if key <= tt.a || (tt.b != "" && key >= tt.b) {
+				t.Errorf("PositionBetween(%q, %q) = %q, not between", tt.a, tt.b, key)
+			}
+			if !validPosition(key) {
+				t.Errorf("PositionBetween(%q, %q) = %q, not a valid key", tt.a, tt.b, key)
+			}
+		})
+	}
+}
+
+func TestPositionBetweenInvalid(t *testing.T) {
+	for _, tt := range [][2]string{{"B", "A"}, {"A", "A"}, {"A0", ""}, {"", "a-"}} {
+		if _, err := PositionBetween(tt[0], tt[1]); err == nil {
+			t.Errorf("PositionBetween(%q, %q) expected an error", tt[0], tt[1])
+		}
+	}
+}
+
+func TestPositionsBetween(t *testing.T) {
+	keys, err := PositionsBetween("", "", 100)
+	if err != nil {
+		t.Fatalf("PositionsBetween returned error: %v", err)
+	}
+	if len(keys) != 100 {
+		t.Fatalf("expected 100 keys, got %d", len(keys))
+	}
+	for i := 1; i < len(keys); i++ {
+		if keys[i-1] >= keys[i] {
+			t.Fatalf("keys not increasing at %d: %q >= %q", i, keys[i-1], keys[i])
+		}
+	}
+}
+
+func TestMovePosition(t *testing.T) {
+	uris := []string{"a", "b", "c", "d"}
+	positions := map[string]string{}
+
+	// Move d between a and b with no positions yet
+	if err := MovePosition(uris, positions, "d", "a", "b"); err != nil {
+		t.Fatalf("MovePosition returned error: %v", err)
+	}
+	if got, want := OrderByPosition(uris, positions), []string{"a", "d", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+
+	// Move a to the end (only next is unknown)
+	if err := MovePosition(uris, positions, "a", "c", ""); err != nil {
+		t.Fatalf("MovePosition returned error: %v", err)
+	}
+	if got, want := OrderByPosition(uris, positions), []string{"d", "b", "c", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+
+	// New items show first; removed items lose their position
+	uris = []string{"e", "a", "b", "c", "d"}
+	if err := MovePosition(uris[:4], positions, "c", "", "d"); err != nil {
+		t.Fatalf("MovePosition returned error: %v", err)
+	}
+	if _, ok := positions["d"]; ok {
+		t.Errorf("expected position of removed item to be dropped")
+	}
+	if got, want := OrderByPosition(uris, positions), []string{"d", "c", "e", "b", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+}
+
+func TestMovePositionTies(t *testing.T) {
+	uris := []string{"a", "b", "c"}
+	positions := map[string]string{"a": "V", "b": "V", "c": "k"}
+
+	if err := MovePosition(uris, positions, "c", "a", "b"); err != nil {
+		t.Fatalf("MovePosition returned error: %v", err)
+	}
+	if got, want := OrderByPosition(uris, positions), []string{"a", "c", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ReminderInputLayout is how absolute reminders are written back into the
+// reminders field, in a form the date parser reads
+const ReminderInputLayout = "1/2/2006 at 3:04pm"
+
+// Reminder is one explicit reminder on a task: an absolute time, or an offset
+// in minutes before the due date
+type Reminder struct {
+	At            *time.Time `json:"at,omitempty"`
+	MinutesBefore int        `json:"minutesBefore,omitempty"`
+}
+
+// FireAt returns when the reminder goes off, or nil for an offset reminder on
+// a task without a due date
+func (r Reminder) FireAt(due *time.Time) *time.Time {
+	if r.At != nil {
+		return r.At
+	}
+	if due == nil || r.MinutesBefore <= 0 {
+		return nil
+	}
+	at := due.Add(-time.Duration(r.MinutesBefore) * time.Minute)
+	return &at
+}
+
+// String returns the reminder as reminder field text, e.g. "30m before"
+func (r Reminder) String() string {
+	if r.At != nil {
+		return r.At.In(time.Local).Format(ReminderInputLayout)
+	}
+	return FormatOffset(r.MinutesBefore) + " before"
+}
+
+// Label returns a short description of the reminder for display
+func (r Reminder) Label() string {
+	if r.At != nil {
+		return r.At.In(time.Local).Format("Jan 2, 3:04 PM")
+	}
+	return FormatOffset(r.MinutesBefore) + " before due"
+}
+
+// FormatOffset formats minutes compactly, e.g. "45m", "1h30m", "2d"
+func FormatOffset(minutes int) string {
+	days, minutes := minutes/(24*60), minutes%(24*60)
+	hours, minutes := minutes/60, minutes%60
+
+	var b strings.Builder
+	if days > 0 {
+		fmt.Fprintf(&b, "%dd", days)
+	}
+	if hours > 0 {
+		fmt.Fprintf(&b, "%dh", hours)
+	}
+	if minutes > 0 || b.Len() == 0 {
+		fmt.Fprintf(&b, "%dm", minutes)
+	}
+	return b.String()
+}
+
+// ReminderTimes returns when the task's reminders go off, soonest first
+func (t *Task) ReminderTimes() []time.Time {
+	times := make([]time.Time, 0, len(t.Reminders))
+	for _, reminder := range t.Reminders {
+		if at := reminder.FireAt(t.DueDate); at != nil {
+			times = append(times, *at)
+		}
+	}
+	sort.Slice(times, func(i, j int) bool {
+		return times[i].Before(times[j])
+	})
+	return times
+}
+
+// HasReminderAt reports whether one of the task's reminders goes off at t
+func (t *Task) HasReminderAt(at time.Time) bool {
+	for _, fire := range t.ReminderTimes() {
+		if fire.Equal(at) {
+			return true
+		}
+	}
+	return false
+}
+
+// RemindersInput returns the task's reminders as reminder field text
+func (t *Task) RemindersInput() string {
+	parts := make([]string, len(t.Reminders))
+	for i, reminder := range t.Reminders {
+		parts[i] = reminder.String()
+	}
+	return strings.Join(parts, ", ")
+}
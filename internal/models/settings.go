@@ -23,13 +23,32 @@ type NotificationSettings struct {
 	// UI preferences
 	TaskInputCollapsed bool `json:"taskInputCollapsed"` // Whether task input form is collapsed
 
+	// Public profile
+	HideProfile bool `json:"hideProfile"` // Opt out of the public /u/@handle profile page
+
 	// Calendar notification settings
 	CalendarNotificationsEnabled  bool              `json:"calendarNotificationsEnabled"`            // Enable calendar event notifications
 	CalendarNotificationLeadTime  string            `json:"calendarNotificationLeadTime,omitempty"`  // Lead time for notifications (e.g. "1h", "30m")
 	NotificationSentHistory       map[string]string `json:"notificationSentHistory,omitempty"`       // Event RKey -> last sent timestamp
 
-	// Usage pattern tracking (for smart notification scheduling in Phase 3)
-	AppUsageHours map[string]int `json:"appUsageHours,omitempty"` // Hour (0-23) -> count
+	// Daily planning nudges (local "HH:MM" times, empty for off)
+	PlanningTime string `json:"planningTime,omitempty"` // Remind to plan the day / show today's plan
+	ReviewTime   string `json:"reviewTime,omitempty"`   // End-of-day summary of the plan
+	Timezone     string `json:"timezone,omitempty"`     // IANA timezone the times are in, from the browser
+
+	// Digests (sent at DigestTime local; the weekly digest on Sundays)
+	DigestDaily  bool   `json:"digestDaily"`          // Morning digest of today's tasks and events
+	DigestWeekly bool   `json:"digestWeekly"`         // Weekly digest of the past and coming week
+	DigestTime   string `json:"digestTime,omitempty"` // Local "HH:MM" to send digests at
+	DigestView   string `json:"digestView,omitempty"` // Saved view rkey limiting the digest's tasks, empty for all
+
+	// Delivery channels notifications are sent over (see Channels); nil means
+	// Web Push only
+	NotificationChannels []string `json:"notificationChannels"`
+
+	// Usage pattern tracking for smart notification timing, recorded by the
+	// server when the app is opened (see RecordUsage)
+	AppUsageHours map[string]int `json:"appUsageHours,omitempty"` // Local hour (0-23) -> count
 
 	// Metadata
 	UpdatedAt time.Time `json:"updatedAt"` // Last update timestamp
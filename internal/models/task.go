@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/json"
+	"strings"
 	"time"
 )
 
@@ -14,6 +15,17 @@ type Task struct {
 	CompletedAt *time.Time `json:"completedAt,omitempty"` // Pointer so it can be nil/omitted
 	DueDate     *time.Time `json:"dueDate,omitempty"`     // Due date for the task
 	Tags        []string   `json:"tags,omitempty"`        // User-defined tags for categorization
+	Status      string     `json:"status,omitempty"`      // Workflow status (board column ID), e.g. todo, in-progress, done
+
+	// Getting Things Done fields - stored directly in AT Protocol
+	State      string     `json:"state,omitempty"`      // GTD state: inbox (default), next, waiting or someday
+	Contexts   []string   `json:"contexts,omitempty"`   // Where or with what it can be done, e.g. @home, @phone
+	WaitingFor string     `json:"waitingFor,omitempty"` // Who the task is waiting on (waiting state)
+	FollowUpAt *time.Time `json:"followUpAt,omitempty"` // When to follow up (waiting state)
+	ReviewedAt *time.Time `json:"reviewedAt,omitempty"` // Last time the task was clarified or reviewed
+
+	// Explicit reminders - stored directly in AT Protocol
+	Reminders []Reminder `json:"reminders,omitempty"` // Absolute times or offsets before the due date
 
 	// Recurring task fields - stored directly in AT Protocol
 	IsRecurring   bool   `json:"isRecurring,omitempty"`   // Whether this task recurs
@@ -72,19 +84,110 @@ func (r *RecurringTask) SetDaysOfWeekFromJSON(jsonStr string) error {
 
 // TaskList represents a collection of tasks stored in AT Protocol
 type TaskList struct {
-	Name        string    `json:"name"`                  // Name of the list (e.g., "Work", "Personal", "Shopping")
-	Description string    `json:"description,omitempty"` // Optional description of the list
-	TaskURIs    []string  `json:"taskUris"`              // Array of AT URIs referencing tasks
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	Name        string     `json:"name"`                  // Name of the list (e.g., "Work", "Personal", "Shopping")
+	Description string     `json:"description,omitempty"` // Optional description of the list
+	TaskURIs    []string   `json:"taskUris"`              // Array of AT URIs referencing tasks
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	Source      *StrongRef `json:"source,omitempty"` // List this one was copied from
+	Parent      string     `json:"parent,omitempty"` // AT URI of the parent list (for nesting)
+
+	// Smart lists collect tasks matching a saved filter instead of TaskURIs
+	Filter      *TaskFilter `json:"filter,omitempty"`      // Rules selecting the list's tasks
+	Materialize bool        `json:"materialize,omitempty"` // Keep TaskURIs in sync with the filter (for public views)
+
+	// Manual (drag and drop) order: task URI -> fractional position key
+	Positions map[string]string `json:"positions,omitempty"`
+
+	// Board columns; the last column is terminal (empty means DefaultBoardColumns)
+	Columns []BoardColumn `json:"columns,omitempty"`
 
 	// Metadata from AT Protocol (populated after creation)
 	RKey        string `json:"-"` // Record key (extracted from URI)
 	URI         string `json:"-"` // Full AT URI
+	CID         string `json:"-"` // Record CID (populated for public fetches)
 	OwnerHandle string `json:"-"` // Handle of the list owner (for public views)
+	OwnerDID    string `json:"-"` // DID of the list owner (for public views)
 
 	// Transient field - populated when fetching list with tasks
 	Tasks []*Task `json:"-"` // Resolved task objects (not stored in AT Protocol)
+
+	// Transient fields - populated by BuildListTree
+	Children   []*TaskList `json:"-"` // Lists nested directly under this one
+	Depth      int         `json:"-"` // Nesting depth (0 for top-level lists)
+	parentList *TaskList   // Resolved parent, nil for top-level lists
+}
+
+// IsSmart returns true if the list's tasks are selected by a filter
+func (l *TaskList) IsSmart() bool {
+	return l.Filter != nil
+}
+
+// CompletedCount returns how many of the resolved tasks are completed
+func (l *TaskList) CompletedCount() int {
+	count := 0
+	for _, task := range l.Tasks {
+		if task.Completed {
+			count++
+		}
+	}
+	return count
+}
+
+// PercentComplete returns the share of resolved tasks that are completed (0-100)
+func (l *TaskList) PercentComplete() int {
+	if len(l.Tasks) == 0 {
+		return 0
+	}
+	return l.CompletedCount() * 100 / len(l.Tasks)
+}
+
+// SourcePublicURL returns the public view URL of the list this one was copied from
+func (l *TaskList) SourcePublicURL() string {
+	if l.Source == nil {
+		return ""
+	}
+	// at://did/app.attodo.list/rkey
+	parts := strings.Split(strings.TrimPrefix(l.Source.URI, "at://"), "/")
+	if len(parts) != 3 {
+		return ""
+	}
+	return "/list/@" + parts[0] + "/" + parts[2]
+}
+
+// ShiftDueDates moves every due date by the same number of whole days so the
+// earliest one lands on the day of now, keeping the spacing between tasks and
+// each task's time of day. Returns the number of days shifted.
+func ShiftDueDates(tasks []*Task, now time.Time) int {
+	var earliest *time.Time
+	for _, task := range tasks {
+		if task.DueDate != nil && (earliest == nil || task.DueDate.Before(*earliest)) {
+			earliest = task.DueDate
+		}
+	}
+	if earliest == nil {
+		return 0
+	}
+
+	// Count calendar days on UTC dates, where every day is 24 hours long even
+	// if the range crosses a DST change in loc
+	loc := now.Location()
+	from := earliest.In(loc)
+	fromDay := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(today.Sub(fromDay).Hours() / 24)
+	if days == 0 {
+		return 0
+	}
+
+	for _, task := range tasks {
+		if task.DueDate != nil {
+			shifted := task.DueDate.In(loc).AddDate(0, 0, days).UTC()
+			task.DueDate = &shifted
+		}
+	}
+
+	return days
 }
 
 // IsOverdue returns true if task has a due date in the past and is not completed
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestShiftDueDates(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	at := func(y int, m time.Month, d, hour int) *time.Time {
+		due := time.Date(y, m, d, hour, 0, 0, 0, newYork).UTC()
+		return &due
+	}
+
+	tests := []struct {
+		name string
+		due  []*time.Time
+		now  time.Time
+		days int
+		want []*time.Time
+	}{
+		{
+			name: "across DST change",
+			due:  []*time.Time{at(2026, 3, 1, 9), at(2026, 3, 3, 17)},
+			now:  time.Date(2026, 3, 20, 12, 0, 0, 0, newYork),
+			days: 19,
+			want: []*time.Time{at(2026, 3, 20, 9), at(2026, 3, 22, 17)},
+		},
+		{
+			name: "earliest in the future",
+			due:  []*time.Time{at(2026, 6, 10, 8), at(2026, 6, 5, 8)},
+			now:  time.Date(2026, 6, 1, 23, 0, 0, 0, newYork),
+			days: -4,
+			want: []*time.Time{at(2026, 6, 6, 8), at(2026, 6, 1, 8)},
+		},
+		{
+			name: "tasks without due dates",
+			due:  []*time.Time{nil, at(2026, 1, 1, 10), nil},
+			now:  time.Date(2026, 1, 2, 0, 30, 0, 0, newYork),
+			days: 1,
+			want: []*time.Time{nil, at(2026, 1, 2, 10), nil},
+		},
+		{
+			name: "no due dates",
+			due:  []*time.Time{nil, nil},
+			now:  time.Date(2026, 1, 2, 0, 0, 0, 0, newYork),
+			days: 0,
+			want: []*time.Time{nil, nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks := make([]*Task, len(tt.due))
+			for i, due := range tt.due {
+				tasks[i] = &Task{DueDate: due}
+			}
+
+			if days := ShiftDueDates(tasks, tt.now); days != tt.days {
+				t.Errorf("ShiftDueDates() = %d days, want %d", days, tt.days)
+			}
+			for i, task := range tasks {
+				switch {
+				case tt.want[i] == nil && task.DueDate != nil:
+					t.Errorf("task %d: due %v, want none", i, task.DueDate)
+				case tt.want[i] != nil && (task.DueDate == nil || !task.DueDate.Equal(*tt.want[i])):
+					t.Errorf("task %d: due %v, want %v", i, task.DueDate, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	// MinUsageSamples is how many app opens are recorded before notifications
+	// are timed by usage
+	MinUsageSamples = 5
+
+	// ActiveHourCount is how many of the busiest hours can count as active
+	ActiveHourCount = 3
+
+	// maxUsageCount caps an hour's count; reaching it halves every hour so
+	// recent habits outweigh old ones
+	maxUsageCount = 100
+)
+
+// RecordUsage counts an app open in a local hour (0-23)
+func (s *NotificationSettings) RecordUsage(hour int) {
+	if s.AppUsageHours == nil {
+		s.AppUsageHours = make(map[string]int)
+	}
+	key := strconv.Itoa(hour)
+	s.AppUsageHours[key]++
+
+	if s.AppUsageHours[key] >= maxUsageCount {
+		for k, count := range s.AppUsageHours {
+			if count /= 2; count > 0 {
+				s.AppUsageHours[k] = count
+			} else {
+				delete(s.AppUsageHours, k)
+			}
+		}
+	}
+}
+
+// ActiveHours returns the local hours the user most often opens the app in,
+// earliest first, or nil until enough usage is recorded
+func (s *NotificationSettings) ActiveHours() []int {
+	type hourCount struct{ hour, count int }
+
+	counts := make([]hourCount, 0, len(s.AppUsageHours))
+	total := 0
+	for k, count := range s.AppUsageHours {
+		hour, err := strconv.Atoi(k)
+		if err != nil || hour < 0 || hour > 23 || count <= 0 {
+			continue
+		}
+		counts = append(counts, hourCount{hour, count})
+		total += count
+	}
+	if total < MinUsageSamples {
+		return nil
+	}
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].count != counts[j].count {
+			return counts[i].count > counts[j].count
+		}
+		return counts[i].hour < counts[j].hour
+	})
+	if len(counts) > ActiveHourCount {
+		counts = counts[:ActiveHourCount]
+	}
+	// Occasional opens aren't a habit: drop hours far quieter than the busiest
+	for len(counts) > 1 && counts[len(counts)-1].count*4 < counts[0].count {
+		counts = counts[:len(counts)-1]
+	}
+
+	hours := make([]int, len(counts))
+	for i, c := range counts {
+		hours[i] = c.hour
+	}
+	sort.Ints(hours)
+	return hours
+}
+
+// NextActiveTime returns now if it falls in an active hour, otherwise the
+// start of the next active hour in loc. ok is false without usage data.
+func (s *NotificationSettings) NextActiveTime(now time.Time, loc *time.Location) (time.Time, bool) {
+	hours := s.ActiveHours()
+	if len(hours) == 0 {
+		return time.Time{}, false
+	}
+
+	local := now.In(loc)
+	for _, hour := range hours {
+		if hour == local.Hour() {
+			return now, true
+		}
+	}
+	for day := 0; day < 2; day++ {
+		for _, hour := range hours {
+			at := time.Date(local.Year(), local.Month(), local.Day()+day, hour, 0, 0, 0, loc)
+			if at.After(now) {
+				return at, true
+			}
+		}
+	}
+	return now, true
+}
+
+// DeferUntilActive reports whether a non-urgent notification should wait for
+// the user's next active hour: it does when usage is known, now isn't an
+// active hour, and the next one comes before deadline
+func (s *NotificationSettings) DeferUntilActive(now, deadline time.Time, loc *time.Location) bool {
+	next, ok := s.NextActiveTime(now, loc)
+	return ok && next.After(now) && next.Before(deadline)
+}
+
+// SmartClock returns the start of the user's earliest active hour as "HH:MM",
+// or fallback without usage data
+func (s *NotificationSettings) SmartClock(fallback string) string {
+	hours := s.ActiveHours()
+	if len(hours) == 0 {
+		return fallback
+	}
+	return time.Date(0, 1, 1, hours[0], 0, 0, 0, time.UTC).Format("15:04")
+}
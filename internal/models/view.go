@@ -0,0 +1,124 @@
+package models
+
+import (
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Saved view sort values (same vocabulary as the /app/tasks?sort= parameter)
+const (
+	ViewSortDue     = "due"
+	ViewSortTitle   = "title"
+	ViewSortCreated = "created"
+	ViewSortManual  = "manual"
+)
+
+// SavedView represents an app.attodo.view record: a named set of task list
+// parameters that can be reopened on any device, shared, and used as the
+// source of iCal feeds and digests (NotificationSettings.DigestView)
+type SavedView struct {
+	Name      string    `json:"name"`
+	Filter    string    `json:"filter,omitempty"`  // "incomplete", "completed" or "" for any
+	Tag       string    `json:"tag,omitempty"`     // Single tag, without the #
+	Due       string    `json:"due,omitempty"`     // "overdue", "today", "upcoming", "none", "has" or ""
+	State     string    `json:"state,omitempty"`   // GTD state, "all", or "" for any but someday/maybe
+	Context   string    `json:"context,omitempty"` // GTD context, e.g. "@home"
+	Sort      string    `json:"sort,omitempty"`    // "due", "title", "created", "manual" or "" for default order
+	CreatedAt time.Time `json:"createdAt"`
+
+	// Metadata from AT Protocol (populated after creation)
+	RKey string `json:"rkey,omitempty"` // Record key (extracted from URI)
+	URI  string `json:"uri,omitempty"`  // Full AT URI
+}
+
+// TaskFilter returns the view's rules as a task filter
+func (v *SavedView) TaskFilter() *TaskFilter {
+	filter := &TaskFilter{Status: v.Filter, Due: v.Due, State: v.State, Context: v.Context}
+	if v.Tag != "" {
+		filter.Tags = []string{v.Tag}
+	}
+	return filter
+}
+
+// Apply returns the tasks matching the view, keeping their order
+func (v *SavedView) Apply(tasks []*Task) []*Task {
+	return v.TaskFilter().Apply(tasks)
+}
+
+// Query returns the view as /app/tasks query parameters
+func (v *SavedView) Query() url.Values {
+	query := url.Values{}
+	if v.Filter != "" {
+		query.Set("filter", v.Filter)
+	}
+	if v.Tag != "" {
+		query.Set("tag", v.Tag)
+	}
+	if v.Due != "" {
+		query.Set("due", v.Due)
+	}
+	if v.State != "" {
+		query.Set("state", v.State)
+	}
+	if v.Context != "" {
+		query.Set("context", v.Context)
+	}
+	if v.Sort != "" {
+		query.Set("sort", v.Sort)
+	}
+	return query
+}
+
+// Summary describes the view for display, e.g. "incomplete • #work • due today • sorted by due date"
+func (v *SavedView) Summary() string {
+	summary := v.TaskFilter().Summary()
+
+	switch v.Sort {
+	case ViewSortDue:
+		summary += " • sorted by due date"
+	case ViewSortTitle:
+		summary += " • sorted by title"
+	case ViewSortCreated:
+		summary += " • newest first"
+	case ViewSortManual:
+		summary += " • manual order"
+	}
+
+	return summary
+}
+
+// OwnerDID returns the DID of the repository the view is stored in
+func (v *SavedView) OwnerDID() string {
+	parts := strings.SplitN(strings.TrimPrefix(v.URI, "at://"), "/", 2)
+	return parts[0]
+}
+
+// Normalize drops unknown parameter values and trims the name and tag
+func (v *SavedView) Normalize() {
+	v.Name = strings.TrimSpace(v.Name)
+	v.Tag = strings.TrimPrefix(strings.TrimSpace(v.Tag), "#")
+	v.Context = NormalizeContext(v.Context)
+
+	switch v.Filter {
+	case FilterStatusIncomplete, FilterStatusCompleted:
+	default:
+		v.Filter = ""
+	}
+
+	switch v.Due {
+	case FilterDueOverdue, FilterDueToday, FilterDueUpcoming, FilterDueNone, FilterDueHas:
+	default:
+		v.Due = ""
+	}
+
+	if !IsGTDState(v.State) && v.State != FilterStateAll {
+		v.State = ""
+	}
+
+	switch v.Sort {
+	case ViewSortDue, ViewSortTitle, ViewSortCreated, ViewSortManual:
+	default:
+		v.Sort = ""
+	}
+}
@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/shindakun/attodo/internal/chat"
+	"github.com/shindakun/attodo/internal/database"
+	"github.com/shindakun/attodo/internal/models"
+	"github.com/shindakun/attodo/internal/push"
+)
+
+// Bluesky delivers notifications as Bluesky chat DMs from the service account
+type Bluesky struct {
+	repo    *database.NotificationRepo
+	sender  *chat.Sender
+	baseURL string
+}
+
+// NewBluesky creates the Bluesky DM channel
+func NewBluesky(repo *database.NotificationRepo, sender *chat.Sender, baseURL string) *Bluesky {
+	return &Bluesky{
+		repo:    repo,
+		sender:  sender,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// Channel returns the channel name
+func (b *Bluesky) Channel() string {
+	return models.ChannelBluesky
+}
+
+// Reaches reports whether the user opted in to DMs and accepts them, as far
+// as is known without asking; users who recently refused one are skipped
+func (b *Bluesky) Reaches(ctx context.Context, recipient *Recipient) (bool, error) {
+	user, err := b.repo.GetNotificationUser(recipient.DID)
+	if err != nil {
+		return false, err
+	}
+	return user != nil && user.BlueskyDMEnabled && !b.sender.Unavailable(recipient.DID), nil
+}
+
+// Notify sends the notification as a DM linking back to the app
+func (b *Bluesky) Notify(ctx context.Context, recipient *Recipient, notification *push.Notification) error {
+	text := notification.Title
+	if notification.Body != "" {
+		text += "\n" + notification.Body
+	}
+
+	err := b.sender.Send(ctx, recipient.DID, &chat.Message{
+		Text: text,
+		Link: notificationLink(b.baseURL, notification),
+	})
+	if errors.Is(err, chat.ErrRecipientUnavailable) {
+		log.Printf("[Bluesky] %s doesn't accept DMs from the service account, skipping for now", recipient.DID)
+	}
+	return err
+}
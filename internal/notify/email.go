@@ -0,0 +1,101 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/shindakun/attodo/internal/database"
+	"github.com/shindakun/attodo/internal/handlers"
+	"github.com/shindakun/attodo/internal/mail"
+	"github.com/shindakun/attodo/internal/models"
+	"github.com/shindakun/attodo/internal/push"
+)
+
+// Email delivers notifications to the user's verified email address
+type Email struct {
+	repo    *database.NotificationRepo
+	sender  *mail.Sender
+	baseURL string
+}
+
+// NewEmail creates the email channel
+func NewEmail(repo *database.NotificationRepo, sender *mail.Sender, baseURL string) *Email {
+	return &Email{
+		repo:    repo,
+		sender:  sender,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// Channel returns the channel name
+func (e *Email) Channel() string {
+	return models.ChannelEmail
+}
+
+// Reaches reports whether the user has a verified, subscribed address
+func (e *Email) Reaches(ctx context.Context, recipient *Recipient) (bool, error) {
+	addr, err := e.repo.GetEmailAddress(recipient.DID)
+	if err != nil {
+		return false, err
+	}
+	return addr != nil && addr.Active(), nil
+}
+
+// emailData is what the email-notification templates render
+type emailData struct {
+	Notification   *push.Notification
+	Digest         *models.Digest // Set for digests, which are sent in full
+	BodyLines      []string
+	URL            string
+	UnsubscribeURL string
+}
+
+// Notify emails the notification, with the full digest for digests
+func (e *Email) Notify(ctx context.Context, recipient *Recipient, notification *push.Notification) error {
+	addr, err := e.repo.GetEmailAddress(recipient.DID)
+	if err != nil {
+		return err
+	}
+	if addr == nil || !addr.Active() {
+		return fmt.Errorf("no verified email address")
+	}
+
+	unsubscribeURL := handlers.EmailUnsubscribeURL(e.baseURL, addr.UnsubscribeToken)
+	data := emailData{
+		Notification:   notification,
+		Digest:         notification.Digest,
+		BodyLines:      strings.Split(notification.Body, "\n"),
+		URL:            notificationLink(e.baseURL, notification),
+		UnsubscribeURL: unsubscribeURL,
+	}
+
+	html, text, err := handlers.RenderEmail("email-notification", data)
+	if err != nil {
+		return err
+	}
+
+	return e.sender.Send(&mail.Message{
+		To:      addr.Email,
+		Subject: notification.Title,
+		HTML:    html,
+		Text:    text,
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<" + unsubscribeURL + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	})
+}
+
+// notificationLink returns the absolute URL a notification opens, defaulting
+// to the app
+func notificationLink(baseURL string, notification *push.Notification) string {
+	target, _ := notification.Data["url"].(string)
+	if target == "" {
+		target = "/app"
+	}
+	if strings.HasPrefix(target, "/") {
+		return baseURL + target
+	}
+	return target
+}
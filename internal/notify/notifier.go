@@ -0,0 +1,150 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/shindakun/attodo/internal/models"
+	"github.com/shindakun/attodo/internal/push"
+)
+
+// settingsCacheTTL is how long a user's settings are reused between sends, so
+// a job sending several notifications reads them once
+const settingsCacheTTL = time.Minute
+
+// Notifier delivers notifications over one channel. Each channel formats the
+// same push.Notification content its own way.
+type Notifier interface {
+	// Channel returns the channel name stored in settings, e.g. "push"
+	Channel() string
+
+	// Reaches reports whether the channel can deliver to the user, e.g. they
+	// have a push subscription or an address configured
+	Reaches(ctx context.Context, recipient *Recipient) (bool, error)
+
+	// Notify delivers a notification to the user
+	Notify(ctx context.Context, recipient *Recipient, notification *push.Notification) error
+}
+
+// SettingsFetcher reads a user's notification settings without a session
+type SettingsFetcher interface {
+	FetchSettings(ctx context.Context, did string) (*models.NotificationSettings, error)
+}
+
+// Recipient is a user resolved for delivery: their settings and the channels
+// that can reach them
+type Recipient struct {
+	DID      string
+	Settings *models.NotificationSettings
+	Channels []Notifier
+}
+
+// Reachable reports whether any channel can reach the recipient
+func (r *Recipient) Reachable() bool {
+	return len(r.Channels) > 0
+}
+
+// Dispatcher routes notifications to each user's enabled channels
+type Dispatcher struct {
+	settings  SettingsFetcher
+	notifiers []Notifier
+
+	mu    sync.Mutex
+	cache map[string]cachedSettings
+}
+
+type cachedSettings struct {
+	settings  *models.NotificationSettings
+	fetchedAt time.Time
+}
+
+// NewDispatcher creates a dispatcher over the given channels
+func NewDispatcher(settings SettingsFetcher, notifiers ...Notifier) *Dispatcher {
+	return &Dispatcher{
+		settings:  settings,
+		notifiers: notifiers,
+		cache:     make(map[string]cachedSettings),
+	}
+}
+
+// Register adds a channel
+func (d *Dispatcher) Register(notifier Notifier) {
+	d.notifiers = append(d.notifiers, notifier)
+}
+
+// Recipient resolves a user's settings and reachable channels
+func (d *Dispatcher) Recipient(ctx context.Context, did string) (*Recipient, error) {
+	settings, err := d.fetchSettings(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	return d.RecipientWithSettings(ctx, did, settings)
+}
+
+// RecipientWithSettings resolves a user's reachable channels from settings
+// the caller already has
+func (d *Dispatcher) RecipientWithSettings(ctx context.Context, did string, settings *models.NotificationSettings) (*Recipient, error) {
+	recipient := &Recipient{DID: did, Settings: settings}
+	for _, notifier := range d.notifiers {
+		if !settings.ChannelEnabled(notifier.Channel()) {
+			continue
+		}
+		ok, err := notifier.Reaches(ctx, recipient)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check %s channel: %w", notifier.Channel(), err)
+		}
+		if ok {
+			recipient.Channels = append(recipient.Channels, notifier)
+		}
+	}
+	return recipient, nil
+}
+
+// Send delivers a notification over each of the recipient's channels and
+// returns how many channels succeeded, plus the errors of those that failed
+func (d *Dispatcher) Send(ctx context.Context, recipient *Recipient, notification *push.Notification) (int, []error) {
+	successCount := 0
+	var errors []error
+
+	for _, notifier := range recipient.Channels {
+		if err := notifier.Notify(ctx, recipient, notification); err != nil {
+			log.Printf("[Notify] %s delivery to %s failed: %v", notifier.Channel(), recipient.DID, err)
+			errors = append(errors, fmt.Errorf("%s: %w", notifier.Channel(), err))
+			continue
+		}
+		successCount++
+	}
+
+	return successCount, errors
+}
+
+// fetchSettings reads a user's settings, reusing a recent read. If settings
+// can't be read, the defaults are used (uncached) so Web Push keeps working.
+func (d *Dispatcher) fetchSettings(ctx context.Context, did string) (*models.NotificationSettings, error) {
+	d.mu.Lock()
+	cached, ok := d.cache[did]
+	d.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < settingsCacheTTL {
+		return cached.settings, nil
+	}
+
+	settings, err := d.settings.FetchSettings(ctx, did)
+	if err != nil {
+		log.Printf("[Notify] Failed to fetch settings for %s, using defaults: %v", did, err)
+		return models.DefaultNotificationSettings(), nil
+	}
+
+	d.mu.Lock()
+	d.cache[did] = cachedSettings{settings: settings, fetchedAt: time.Now()}
+	for key, entry := range d.cache {
+		if time.Since(entry.fetchedAt) >= settingsCacheTTL {
+			delete(d.cache, key)
+		}
+	}
+	d.mu.Unlock()
+
+	return settings, nil
+}
@@ -0,0 +1,89 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/shindakun/attodo/internal/database"
+	"github.com/shindakun/attodo/internal/models"
+	"github.com/shindakun/attodo/internal/push"
+	"github.com/shindakun/attodo/internal/webhook"
+)
+
+// Webhooks delivers notifications to the user's webhook, ntfy and Gotify
+// targets
+type Webhooks struct {
+	repo    *database.NotificationRepo
+	sender  *webhook.Sender
+	baseURL string
+}
+
+// NewWebhooks creates the webhooks channel
+func NewWebhooks(repo *database.NotificationRepo, sender *webhook.Sender, baseURL string) *Webhooks {
+	return &Webhooks{
+		repo:    repo,
+		sender:  sender,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// Channel returns the channel name
+func (w *Webhooks) Channel() string {
+	return models.ChannelWebhook
+}
+
+// Reaches reports whether the user has a target that isn't disabled
+func (w *Webhooks) Reaches(ctx context.Context, recipient *Recipient) (bool, error) {
+	hooks, err := w.repo.GetWebhooksByDID(recipient.DID)
+	if err != nil {
+		return false, err
+	}
+	for _, hook := range hooks {
+		if hook.Active() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Notify delivers the notification to every active target; it succeeds if
+// any target got it
+func (w *Webhooks) Notify(ctx context.Context, recipient *Recipient, notification *push.Notification) error {
+	hooks, err := w.repo.GetWebhooksByDID(recipient.DID)
+	if err != nil {
+		return err
+	}
+
+	payload := webhook.NewPayload(notification, w.baseURL)
+	delivered := 0
+	var failures []string
+	for _, hook := range hooks {
+		if !hook.Active() {
+			continue
+		}
+		err := w.sender.Deliver(ctx, hook, payload)
+		w.recordDelivery(hook, err)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", hook.Kind, err))
+			continue
+		}
+		delivered++
+	}
+
+	if delivered == 0 {
+		return fmt.Errorf("no webhook delivered: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// recordDelivery saves the outcome of a delivery to a target
+func (w *Webhooks) recordDelivery(hook *models.Webhook, deliveryErr error) {
+	if webhook.RecordResult(hook, deliveryErr) {
+		log.Printf("[Webhooks] Disabled %s target %d for %s after %d failed deliveries", hook.Kind, hook.ID, hook.DID, hook.FailureCount)
+	}
+	if err := w.repo.UpdateWebhookDelivery(hook); err != nil {
+		log.Printf("[Webhooks] Failed to record delivery for target %d: %v", hook.ID, err)
+	}
+}
@@ -0,0 +1,222 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/shindakun/attodo/internal/database"
+	"github.com/shindakun/attodo/internal/models"
+	"github.com/shindakun/attodo/internal/push"
+)
+
+// retryBatchSize caps how many queued deliveries one retry pass sends
+const retryBatchSize = 100
+
+// WebPush delivers notifications to the user's subscribed browsers. Deliveries
+// the push service rate limits or fails are queued and retried with backoff,
+// and subscriptions it reports gone are removed.
+type WebPush struct {
+	repo   *database.NotificationRepo
+	sender *push.Sender
+}
+
+// NewWebPush creates the Web Push channel
+func NewWebPush(repo *database.NotificationRepo, sender *push.Sender) *WebPush {
+	return &WebPush{
+		repo:   repo,
+		sender: sender,
+	}
+}
+
+// Channel returns the channel name
+func (p *WebPush) Channel() string {
+	return models.ChannelPush
+}
+
+// Reaches reports whether the user has any push subscriptions
+func (p *WebPush) Reaches(ctx context.Context, recipient *Recipient) (bool, error) {
+	subscriptions, err := p.repo.GetPushSubscriptionsByDID(recipient.DID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get push subscriptions: %w", err)
+	}
+	return len(subscriptions) > 0, nil
+}
+
+// Notify sends the notification to all of the user's subscriptions; it only
+// fails if no subscription got it or had it queued for retry
+func (p *WebPush) Notify(ctx context.Context, recipient *Recipient, notification *push.Notification) error {
+	subscriptions, err := p.repo.GetPushSubscriptionsByDID(recipient.DID)
+	if err != nil {
+		return fmt.Errorf("failed to get push subscriptions: %w", err)
+	}
+	if len(subscriptions) == 0 {
+		return fmt.Errorf("no push subscriptions")
+	}
+
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+	notificationType, _ := notification.Data["type"].(string)
+	taskURI, _ := notification.Data["taskUri"].(string)
+	if taskURI == "" {
+		taskURI = notification.Tag
+	}
+
+	accepted := 0
+	var firstErr error
+	for _, sub := range subscriptions {
+		err := p.sender.SendPayload(ctx, sub, payload)
+		delivery := &models.PushDelivery{
+			DID:              recipient.DID,
+			Endpoint:         sub.Endpoint,
+			Payload:          string(payload),
+			NotificationType: notificationType,
+			TaskURI:          taskURI,
+			Attempts:         1,
+		}
+
+		// A send cut short by shutdown or a lost lease was neither sent nor failed
+		if err != nil && ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		switch {
+		case err == nil:
+			p.delivered(delivery)
+			accepted++
+
+		case push.IsGone(err):
+			p.removeGone(delivery, err)
+
+		case push.IsRetryable(err):
+			delivery.NextAttemptAt = time.Now().Add(push.RetryDelay(delivery.Attempts, err))
+			delivery.LastError = err.Error()
+			if qErr := p.repo.EnqueuePushDelivery(delivery); qErr != nil {
+				log.Printf("[WebPush] Failed to queue retry for %s: %v", sub.Endpoint, qErr)
+				p.recordHistory(delivery, "failed", err)
+				break
+			}
+			p.recordHistory(delivery, "retrying", err)
+			accepted++
+
+		default:
+			p.recordHistory(delivery, "failed", err)
+		}
+
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	if accepted == 0 {
+		return fmt.Errorf("failed to send to all %d subscriptions: %w", len(subscriptions), firstErr)
+	}
+	return nil
+}
+
+// RetryDue retries queued deliveries whose next attempt is due. Deliveries
+// that keep failing are given up after push.MaxDeliveryAttempts tries or once
+// they're older than push.DeliveryTTL.
+func (p *WebPush) RetryDue(ctx context.Context) error {
+	deliveries, err := p.repo.GetDuePushDeliveries(time.Now(), retryBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		sub, err := p.repo.GetPushSubscription(delivery.Endpoint)
+		if err != nil {
+			log.Printf("[WebPush] Failed to get subscription for queued delivery %d: %v", delivery.ID, err)
+			continue
+		}
+		if sub == nil {
+			// Unsubscribed since; nothing left to deliver to
+			p.dropDelivery(delivery)
+			continue
+		}
+
+		err = p.sender.SendPayload(ctx, sub, []byte(delivery.Payload))
+		if err != nil && ctx.Err() != nil {
+			// Stopped, not failed: leave the delivery queued as it was
+			return ctx.Err()
+		}
+		delivery.Attempts++
+
+		switch {
+		case err == nil:
+			p.dropDelivery(delivery)
+			p.delivered(delivery)
+
+		case push.IsGone(err):
+			p.dropDelivery(delivery)
+			p.removeGone(delivery, err)
+
+		case push.IsRetryable(err) && delivery.Attempts < push.MaxDeliveryAttempts &&
+			time.Since(delivery.CreatedAt) < push.DeliveryTTL:
+			delivery.NextAttemptAt = time.Now().Add(push.RetryDelay(delivery.Attempts, err))
+			delivery.LastError = err.Error()
+			if err := p.repo.UpdatePushDelivery(delivery); err != nil {
+				log.Printf("[WebPush] Failed to reschedule delivery %d: %v", delivery.ID, err)
+			}
+
+		default:
+			log.Printf("[WebPush] Giving up on delivery %d to %s after %d attempts: %v", delivery.ID, delivery.Endpoint, delivery.Attempts, err)
+			p.dropDelivery(delivery)
+			p.recordHistory(delivery, "failed", err)
+		}
+	}
+
+	return nil
+}
+
+// delivered records a successful delivery to an endpoint
+func (p *WebPush) delivered(delivery *models.PushDelivery) {
+	if err := p.repo.UpdatePushSubscriptionLastUsed(delivery.Endpoint); err != nil {
+		log.Printf("[WebPush] Failed to update subscription last used: %v", err)
+	}
+	p.recordHistory(delivery, "sent", nil)
+}
+
+// removeGone deletes a subscription the push service says no longer exists
+func (p *WebPush) removeGone(delivery *models.PushDelivery, sendErr error) {
+	log.Printf("[WebPush] Removing expired subscription %s for %s", delivery.Endpoint, delivery.DID)
+	if err := p.repo.DeletePushSubscription(delivery.Endpoint); err != nil {
+		log.Printf("[WebPush] Failed to remove expired subscription: %v", err)
+	}
+	p.recordHistory(delivery, "expired", sendErr)
+}
+
+// dropDelivery removes a delivery from the retry queue
+func (p *WebPush) dropDelivery(delivery *models.PushDelivery) {
+	if delivery.ID == 0 {
+		return
+	}
+	if err := p.repo.DeletePushDelivery(delivery.ID); err != nil {
+		log.Printf("[WebPush] Failed to remove queued delivery %d: %v", delivery.ID, err)
+	}
+}
+
+// recordHistory records the outcome of a delivery to one endpoint
+func (p *WebPush) recordHistory(delivery *models.PushDelivery, status string, sendErr error) {
+	history := &models.NotificationHistory{
+		DID:              delivery.DID,
+		TaskURI:          delivery.TaskURI,
+		NotificationType: delivery.NotificationType,
+		Status:           status,
+		Endpoint:         delivery.Endpoint,
+	}
+	if sendErr != nil {
+		history.ErrorMessage = sendErr.Error()
+	}
+	if err := p.repo.CreateNotificationHistory(history); err != nil {
+		log.Printf("[WebPush] Failed to record delivery history: %v", err)
+	}
+}
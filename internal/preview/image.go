@@ -0,0 +1,184 @@
+package preview
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Preview image dimensions (recommended OpenGraph size)
+const (
+	Width  = 1200
+	Height = 630
+
+	margin = 72
+)
+
+var (
+	colorBackground = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	colorBrand      = color.RGBA{0x1e, 0x88, 0xe5, 0xff}
+	colorDone       = color.RGBA{0x4c, 0xaf, 0x50, 0xff}
+	colorText       = color.RGBA{0x1f, 0x29, 0x33, 0xff}
+	colorMuted      = color.RGBA{0x6b, 0x72, 0x80, 0xff}
+	colorTrack      = color.RGBA{0xe5, 0xe7, 0xeb, 0xff}
+)
+
+// RenderList draws a social preview card for a list showing its name,
+// owner handle and progress, and returns it PNG-encoded
+func RenderList(name, handle string, completed, total int) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(colorBackground), image.Point{}, draw.Src)
+
+	// Brand band across the top
+	draw.Draw(img, image.Rect(0, 0, Width, 16), image.NewUniform(colorBrand), image.Point{}, draw.Src)
+
+	// List name (up to two lines). Names the bitmap font can't draw any of,
+	// e.g. in Japanese or Cyrillic, are titled after the owner instead.
+	title := printable(name)
+	if !readable(title) {
+		title = "@" + handle + "'s list"
+	}
+	y := 120
+	for _, line := range wrap(title, 24, 2) {
+		drawText(img, line, margin, y, 6, colorText)
+		y += 13*6 + 12
+	}
+
+	// Owner handle
+	drawText(img, "by @"+handle, margin, y+8, 3, colorMuted)
+
+	// Progress bar
+	barTop := 430
+	barColor := colorBrand
+	if total > 0 && completed == total {
+		barColor = colorDone
+	}
+	draw.Draw(img, image.Rect(margin, barTop, Width-margin, barTop+28), image.NewUniform(colorTrack), image.Point{}, draw.Src)
+	if total > 0 {
+		filled := (Width - 2*margin) * completed / total
+		draw.Draw(img, image.Rect(margin, barTop, margin+filled, barTop+28), image.NewUniform(barColor), image.Point{}, draw.Src)
+	}
+
+	percent := 0
+	if total > 0 {
+		percent = completed * 100 / total
+	}
+	drawText(img, fmt.Sprintf("%d/%d done (%d%%)", completed, total, percent), margin, barTop+50, 4, colorText)
+
+	// Footer
+	drawText(img, "AT Todo", margin, Height-72, 3, colorBrand)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode preview: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// drawText renders text with the built-in bitmap font, scaled up by an integer
+// factor, with its top-left corner at (x, y)
+func drawText(dst draw.Image, text string, x, y, scale int, c color.Color) {
+	face := basicfont.Face7x13
+	width := font.MeasureString(face, text).Ceil()
+	if width == 0 {
+		return
+	}
+
+	// Render at native size, then scale with nearest neighbour to keep pixels crisp
+	src := image.NewRGBA(image.Rect(0, 0, width, face.Height))
+	d := &font.Drawer{
+		Dst:  src,
+		Src:  image.NewUniform(c),
+		Face: face,
+		Dot:  fixed.P(0, face.Ascent),
+	}
+	d.DrawString(text)
+
+	target := image.Rect(x, y, x+width*scale, y+face.Height*scale)
+	xdraw.NearestNeighbor.Scale(dst, target, src, src.Bounds(), xdraw.Over, nil)
+}
+
+// wrap splits text into at most maxLines lines of up to width characters,
+// truncating with "..."
+func wrap(text string, width, maxLines int) []string {
+	words := strings.Fields(text)
+	lines := make([]string, 0, maxLines)
+	current := ""
+
+	for i, word := range words {
+		if utf8.RuneCountInString(word) > width {
+			word = truncate(word, width-3) + "..."
+		}
+
+		if current == "" {
+			current = word
+		} else if utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) <= width {
+			current += " " + word
+		} else {
+			lines = append(lines, current)
+			current = word
+		}
+
+		if len(lines) == maxLines {
+			// Out of room: mark the last line as truncated
+			lines[maxLines-1] = strings.TrimRight(truncate(lines[maxLines-1], width-3), " ") + "..."
+			return lines
+		}
+
+		if i == len(words)-1 {
+			lines = append(lines, current)
+		}
+	}
+
+	return lines
+}
+
+// truncate shortens text to at most n characters
+func truncate(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return string(runes[:n])
+}
+
+// printable replaces each run of characters outside the bitmap font's ASCII
+// range (emoji, other scripts etc.) with a single replacement glyph
+func printable(text string) string {
+	var b strings.Builder
+	replaced := false
+	for _, r := range text {
+		if r == '\t' || r == '\n' {
+			r = ' '
+		}
+		if r < 0x20 || r > 0x7e {
+			if !replaced {
+				b.WriteRune(utf8.RuneError)
+				replaced = true
+			}
+			continue
+		}
+		b.WriteRune(r)
+		replaced = false
+	}
+	return b.String()
+}
+
+// readable reports whether text has any letter or digit the font can draw
+func readable(text string) bool {
+	return strings.IndexFunc(text, func(r rune) bool {
+		return r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r))
+	}) >= 0
+}
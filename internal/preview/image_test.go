@@ -0,0 +1,101 @@
+package preview
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"reflect"
+	"testing"
+)
+
+// renderImage renders a list card and decodes it back
+func renderImage(t *testing.T, name string, completed, total int) image.Image {
+	t.Helper()
+
+	data, err := RenderList(name, "alice.bsky.social", completed, total)
+	if err != nil {
+		t.Fatalf("RenderList failed: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Failed to decode preview: %v", err)
+	}
+	return img
+}
+
+func TestRenderList(t *testing.T) {
+	img := renderImage(t, "Groceries", 3, 5)
+	if got := img.Bounds(); got != image.Rect(0, 0, Width, Height) {
+		t.Errorf("Expected a %dx%d image, got %v", Width, Height, got)
+	}
+}
+
+func TestRenderListProgress(t *testing.T) {
+	const barY = 430 + 14
+	barWidth := Width - 2*margin
+	at := func(img image.Image, fraction float64) color.Color {
+		return color.RGBAModel.Convert(img.At(margin+int(float64(barWidth)*fraction), barY))
+	}
+
+	half := renderImage(t, "Groceries", 1, 2)
+	if got := at(half, 0.25); got != colorBrand {
+		t.Errorf("Expected the done half of the bar filled, got %v", got)
+	}
+	if got := at(half, 0.75); got != colorTrack {
+		t.Errorf("Expected the rest of the bar empty, got %v", got)
+	}
+
+	done := renderImage(t, "Groceries", 2, 2)
+	if got := at(done, 0.99); got != colorDone {
+		t.Errorf("Expected a finished list's bar filled in the done colour, got %v", got)
+	}
+
+	empty := renderImage(t, "Groceries", 0, 0)
+	if got := at(empty, 0.01); got != colorTrack {
+		t.Errorf("Expected an empty list's bar empty, got %v", got)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Groceries", []string{"Groceries"}},
+		{"Things to pack for the weekend trip", []string{"Things to pack for the", "weekend trip"}},
+		{"Things to pack for the long weekend trip to the coast", []string{"Things to pack for the", "long weekend trip to..."}},
+		{"Supercalifragilisticexpialidocious", []string{"Supercalifragilistice..."}},
+		{"Café crème brûlée recipes to try out", []string{"Café crème brûlée", "recipes to try out"}},
+		{"", []string{}},
+	}
+
+	for _, tt := range tests {
+		if got := wrap(tt.text, 24, 2); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("wrap(%q) = %q, expected %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestPrintable(t *testing.T) {
+	tests := map[string]string{
+		"Groceries":   "Groceries",
+		"Trip\tplans": "Trip plans",
+		"🚀 Launch":    "� Launch",
+		"買い物リスト":      "�",
+		"Список дел":  "� �",
+		"Café":        "Caf�",
+	}
+	for text, want := range tests {
+		if got := printable(text); got != want {
+			t.Errorf("printable(%q) = %q, expected %q", text, got, want)
+		}
+	}
+
+	if readable(printable("買い物リスト")) {
+		t.Error("Expected a name with no drawable letters to be unreadable")
+	}
+	if !readable(printable("🚀 Launch")) {
+		t.Error("Expected a name with some drawable letters to be readable")
+	}
+}
@@ -0,0 +1,103 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxDeliveryAttempts is how many times a push delivery is tried,
+	// counting the first, before it's given up
+	MaxDeliveryAttempts = 6
+
+	// DeliveryTTL is how long a queued delivery is kept; it matches the TTL
+	// the push service is asked to hold messages for
+	DeliveryTTL = 24 * time.Hour
+
+	// initialRetryDelay is the wait before the first retry; it doubles after
+	// each attempt, up to maxRetryDelay
+	initialRetryDelay = 30 * time.Second
+	maxRetryDelay     = time.Hour
+)
+
+// StatusError is a push service response other than success
+type StatusError struct {
+	StatusCode int
+	RetryAfter time.Duration // From the Retry-After header, zero if not sent
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("push service returned status %d", e.StatusCode)
+}
+
+// Gone reports whether the push service says the subscription no longer
+// exists, so it should be removed
+func (e *StatusError) Gone() bool {
+	return e.StatusCode == http.StatusNotFound || e.StatusCode == http.StatusGone
+}
+
+// IsGone reports whether a send failed because the subscription is gone
+func IsGone(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.Gone()
+}
+
+// IsRetryable reports whether a failed send is worth trying again later: rate
+// limits, server errors and network errors reaching the push service. Errors
+// building the message, such as a bad subscription key, won't go away on a
+// retry, and a cancelled send was stopped on purpose.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// RetryDelay returns how long to wait before retrying a delivery that has
+// failed the given number of times: exponential backoff, but never sooner
+// than the push service's Retry-After
+func RetryDelay(attempts int, err error) time.Duration {
+	delay := initialRetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > delay {
+		delay = statusErr.RetryAfter
+	}
+	return delay
+}
+
+// parseRetryAfter reads a Retry-After header, given either as seconds or as
+// an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
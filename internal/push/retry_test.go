@@ -0,0 +1,156 @@
+package push
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	webpush "github.com/SherClockHolmes/webpush-go"
+	"github.com/shindakun/attodo/internal/models"
+)
+
+// testSubscription returns a subscription with real browser keys pointing at
+// a push service that answers with the given status and headers
+func testSubscription(t *testing.T, status int, headers map[string]string) *models.PushSubscription {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for key, value := range headers {
+			w.Header().Set(key, value)
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := make([]byte, 16)
+	rand.Read(auth)
+
+	return &models.PushSubscription{
+		Endpoint:   server.URL + "/push/abc",
+		P256dhKey:  base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()),
+		AuthSecret: base64.RawURLEncoding.EncodeToString(auth),
+	}
+}
+
+func testSender(t *testing.T) *Sender {
+	t.Helper()
+	privateKey, publicKey, err := webpush.GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewSender(publicKey, privateKey, "mailto:test@example.com")
+}
+
+func TestSendPayloadStatuses(t *testing.T) {
+	sender := testSender(t)
+	payload := []byte(`{"title":"Buy milk"}`)
+
+	if err := sender.SendPayload(context.Background(), testSubscription(t, http.StatusCreated, nil), payload); err != nil {
+		t.Errorf("Expected 201 to succeed, got %v", err)
+	}
+
+	for _, status := range []int{http.StatusNotFound, http.StatusGone} {
+		err := sender.SendPayload(context.Background(), testSubscription(t, status, nil), payload)
+		if !IsGone(err) || IsRetryable(err) {
+			t.Errorf("Expected %d to mean gone, got %v", status, err)
+		}
+	}
+
+	err := sender.SendPayload(context.Background(), testSubscription(t, http.StatusTooManyRequests, map[string]string{"Retry-After": "120"}), payload)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || !IsRetryable(err) || statusErr.RetryAfter != 2*time.Minute {
+		t.Errorf("Expected a retryable rate limit with Retry-After, got %#v", err)
+	}
+
+	err = sender.SendPayload(context.Background(), testSubscription(t, http.StatusBadRequest, nil), payload)
+	if err == nil || IsRetryable(err) || IsGone(err) {
+		t.Errorf("Expected 400 to fail permanently, got %v", err)
+	}
+}
+
+func TestSendPayloadNotRetryable(t *testing.T) {
+	sender := testSender(t)
+	payload := []byte(`{"title":"Buy milk"}`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := sender.SendPayload(ctx, testSubscription(t, http.StatusCreated, nil), payload); err == nil || IsRetryable(err) {
+		t.Errorf("Expected a cancelled send not to be retried, got %v", err)
+	}
+
+	sub := testSubscription(t, http.StatusCreated, nil)
+	sub.P256dhKey = "not-a-key"
+	if err := sender.SendPayload(context.Background(), sub, payload); err == nil || IsRetryable(err) {
+		t.Errorf("Expected an invalid key not to be retried, got %v", err)
+	}
+
+	sub = testSubscription(t, http.StatusCreated, nil)
+	sub.Endpoint = "http://127.0.0.1:1/push/abc"
+	if err := sender.SendPayload(context.Background(), sub, payload); err == nil || !IsRetryable(err) {
+		t.Errorf("Expected a connection error to be retried, got %v", err)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	for _, tt := range []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{&StatusError{StatusCode: http.StatusTooManyRequests}, true},
+		{&StatusError{StatusCode: http.StatusBadGateway}, true},
+		{&StatusError{StatusCode: http.StatusForbidden}, false},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, true},
+		{&url.Error{Op: "Post", URL: "https://push.example.com", Err: context.Canceled}, false},
+		{fmt.Errorf("failed to send push notification: %w", context.Canceled), false},
+		{errors.New("failed to encrypt payload"), false},
+	} {
+		if got := IsRetryable(tt.err); got != tt.want {
+			t.Errorf("IsRetryable(%v) = %v, expected %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	if got := RetryDelay(1, errors.New("connection refused")); got != initialRetryDelay {
+		t.Errorf("Expected first retry after %s, got %s", initialRetryDelay, got)
+	}
+	if got := RetryDelay(3, nil); got != 4*initialRetryDelay {
+		t.Errorf("Expected backoff to double, got %s", got)
+	}
+	if got := RetryDelay(50, nil); got != maxRetryDelay {
+		t.Errorf("Expected backoff capped at %s, got %s", maxRetryDelay, got)
+	}
+	if got := RetryDelay(1, &StatusError{StatusCode: 429, RetryAfter: 10 * time.Minute}); got != 10*time.Minute {
+		t.Errorf("Expected Retry-After to win, got %s", got)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 3, 14, 12, 0, 0, 0, time.UTC)
+	for value, want := range map[string]time.Duration{
+		"":                              0,
+		"30":                            30 * time.Second,
+		"-5":                            0,
+		"Sat, 14 Mar 2026 12:05:00 GMT": 5 * time.Minute,
+		"Sat, 14 Mar 2026 11:00:00 GMT": 0,
+		"soon":                          0,
+	} {
+		if got := parseRetryAfter(value, now); got != want {
+			t.Errorf("parseRetryAfter(%q) = %s, expected %s", value, got, want)
+		}
+	}
+}
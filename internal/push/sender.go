@@ -1,9 +1,13 @@
 package push
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
+	"time"
 
 	webpush "github.com/SherClockHolmes/webpush-go"
 	"github.com/shindakun/attodo/internal/models"
@@ -14,6 +18,7 @@ type Sender struct {
 	publicKey  string
 	privateKey string
 	subscriber string
+	client     *http.Client
 }
 
 // NewSender creates a new push notification sender
@@ -22,6 +27,7 @@ func NewSender(publicKey, privateKey, subscriber string) *Sender {
 		publicKey:  publicKey,
 		privateKey: privateKey,
 		subscriber: subscriber,
+		client:     &http.Client{Timeout: 30 * time.Second},
 	}
 }
 
@@ -38,16 +44,30 @@ type Notification struct {
 	Badge string                 `json:"badge,omitempty"`
 	Tag   string                 `json:"tag,omitempty"`
 	Data  map[string]interface{} `json:"data,omitempty"`
+
+	// Digest is the full digest behind a digest notification, for channels
+	// that can show more than a title and body (email)
+	Digest *models.Digest `json:"-"`
+
+	// Tasks are the tasks a notification is about, for channels that pass
+	// task metadata along (webhooks)
+	Tasks []*models.Task `json:"-"`
 }
 
 // Send sends a push notification to a subscription
-func (s *Sender) Send(sub *models.PushSubscription, notification *Notification) error {
+func (s *Sender) Send(ctx context.Context, sub *models.PushSubscription, notification *Notification) error {
 	// Marshal notification to JSON
 	payload, err := json.Marshal(notification)
 	if err != nil {
 		return fmt.Errorf("failed to marshal notification: %w", err)
 	}
 
+	return s.SendPayload(ctx, sub, payload)
+}
+
+// SendPayload sends an already encoded notification to a subscription. A
+// push service refusal is returned as a *StatusError.
+func (s *Sender) SendPayload(ctx context.Context, sub *models.PushSubscription, payload []byte) error {
 	// Create webpush subscription
 	subscription := &webpush.Subscription{
 		Endpoint: sub.Endpoint,
@@ -58,7 +78,8 @@ func (s *Sender) Send(sub *models.PushSubscription, notification *Notification)
 	}
 
 	// Send the notification
-	resp, err := webpush.SendNotification(payload, subscription, &webpush.Options{
+	resp, err := webpush.SendNotificationWithContext(ctx, payload, subscription, &webpush.Options{
+		HTTPClient:      s.client,
 		Subscriber:      s.subscriber,
 		VAPIDPublicKey:  s.publicKey,
 		VAPIDPrivateKey: s.privateKey,
@@ -69,30 +90,17 @@ func (s *Sender) Send(sub *models.PushSubscription, notification *Notification)
 		return fmt.Errorf("failed to send push notification: %w", err)
 	}
 	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
 
 	// Check response status
-	if resp.StatusCode != 201 {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		log.Printf("Push service returned status %d for %s", resp.StatusCode, sub.Endpoint)
-		return fmt.Errorf("push service returned status %d", resp.StatusCode)
+		return &StatusError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
 	}
 
 	log.Printf("Push notification sent successfully (HTTP %d) to %s", resp.StatusCode, sub.Endpoint)
 	return nil
 }
-
-// SendToAll sends a notification to all subscriptions for a DID
-// Returns the number of successful sends and any errors encountered
-func (s *Sender) SendToAll(subscriptions []*models.PushSubscription, notification *Notification) (int, []error) {
-	var errors []error
-	successCount := 0
-
-	for _, sub := range subscriptions {
-		if err := s.Send(sub, notification); err != nil {
-			errors = append(errors, fmt.Errorf("failed to send to %s: %w", sub.Endpoint, err))
-		} else {
-			successCount++
-		}
-	}
-
-	return successCount, errors
-}
@@ -0,0 +1,314 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/shindakun/attodo/internal/models"
+	"github.com/shindakun/attodo/internal/push"
+)
+
+const (
+	// MaxConsecutiveFailures is how many deliveries in a row can fail before
+	// a target is disabled
+	MaxConsecutiveFailures = 5
+
+	// deliveryTimeout bounds one delivery, so a dead target holds up the
+	// notification jobs' workers for at most this long
+	deliveryTimeout = 10 * time.Second
+)
+
+// Headers sent with signed webhook deliveries
+const (
+	HeaderEvent     = "X-Attodo-Event"
+	HeaderDelivery  = "X-Attodo-Delivery"
+	HeaderTimestamp = "X-Attodo-Timestamp"
+	HeaderSignature = "X-Attodo-Signature"
+)
+
+// errPrivateAddress means a target resolved to an address on the server's
+// own network
+var errPrivateAddress = errors.New("target resolves to a private address")
+
+// Payload is what webhook targets receive: the notification with the same
+// fields as a push notification, plus the event, a link and the tasks it's
+// about
+type Payload struct {
+	push.Notification
+	Event  string     `json:"event"` // Notification type, e.g. reminder or due_today
+	URL    string     `json:"url"`   // Absolute link into the app
+	SentAt time.Time  `json:"sentAt"`
+	Tasks  []TaskInfo `json:"tasks,omitempty"`
+}
+
+// TaskInfo is the task metadata sent with a notification
+type TaskInfo struct {
+	URI         string     `json:"uri"`
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	DueDate     *time.Time `json:"dueDate,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	State       string     `json:"state,omitempty"`
+	URL         string     `json:"url"`
+}
+
+// NewPayload builds the payload for a notification. Relative links are made
+// absolute against baseURL.
+func NewPayload(notification *push.Notification, baseURL string) *Payload {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	link, _ := notification.Data["url"].(string)
+	if link == "" {
+		link = "/app"
+	}
+	if strings.HasPrefix(link, "/") {
+		link = baseURL + link
+	}
+	event, _ := notification.Data["type"].(string)
+
+	payload := &Payload{
+		Notification: *notification,
+		Event:        event,
+		URL:          link,
+		SentAt:       time.Now().UTC(),
+	}
+	for _, task := range notification.Tasks {
+		payload.Tasks = append(payload.Tasks, TaskInfo{
+			URI:         task.URI,
+			Title:       task.Title,
+			Description: task.Description,
+			DueDate:     task.DueDate,
+			Tags:        task.Tags,
+			State:       task.State,
+			URL:         baseURL + "/app#task-" + task.RKey,
+		})
+	}
+	return payload
+}
+
+// Sign returns the signature of a webhook delivery: the hex HMAC-SHA256 of
+// the timestamp, a dot and the body, keyed with the webhook's secret
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret returns a random signing secret for a new webhook
+func NewSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// ValidateURL checks a target URL is an absolute http(s) URL
+func ValidateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return fmt.Errorf("must be an http:// or https:// URL")
+	}
+	if u.User != nil {
+		return fmt.Errorf("must not contain a username or password")
+	}
+	return nil
+}
+
+// RecordResult updates a target's delivery state after a delivery: success
+// resets the failure count, and too many failures in a row disable the
+// target. It reports whether the target was just disabled.
+func RecordResult(hook *models.Webhook, deliveryErr error) bool {
+	now := time.Now()
+	if deliveryErr == nil {
+		hook.FailureCount = 0
+		hook.LastError = ""
+		hook.LastDeliveredAt = &now
+		return false
+	}
+
+	hook.FailureCount++
+	hook.LastError = deliveryErr.Error()
+	if len(hook.LastError) > 500 {
+		hook.LastError = hook.LastError[:500]
+	}
+	if hook.FailureCount >= MaxConsecutiveFailures && hook.DisabledAt == nil {
+		hook.DisabledAt = &now
+		return true
+	}
+	return false
+}
+
+// Sender delivers notifications to webhook, ntfy and Gotify targets. Each
+// delivery is tried once; a target that keeps failing is disabled rather than
+// retried. Unless private addresses are allowed, targets on the server's own
+// network are refused.
+type Sender struct {
+	client *http.Client
+}
+
+// NewSender creates a new webhook sender. allowPrivate lets targets resolve
+// to loopback and private addresses (e.g. home automation on a self-hosted
+// server's LAN).
+func NewSender(allowPrivate bool) *Sender {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	transport := &http.Transport{DialContext: dialer.DialContext, Proxy: http.ProxyFromEnvironment}
+	if !allowPrivate {
+		// The guard checks the address dialed, which through a proxy would
+		// be the proxy's rather than the target's, so connect directly
+		dialer.Control = refusePrivate
+		transport.Proxy = nil
+	}
+
+	return &Sender{
+		client: &http.Client{
+			Timeout:   deliveryTimeout,
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse // Redirects count as failures
+			},
+		},
+	}
+}
+
+// Deliver sends a payload to a target, within the caller's ctx and at most
+// deliveryTimeout
+func (s *Sender) Deliver(ctx context.Context, hook *models.Webhook, payload *Payload) error {
+	deliveryID, err := randomID()
+	if err != nil {
+		return err
+	}
+
+	req, err := s.request(ctx, hook, payload, deliveryID)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("target returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// request builds the request for a target's kind
+func (s *Sender) request(ctx context.Context, hook *models.Webhook, payload *Payload, deliveryID string) (*http.Request, error) {
+	var target string
+	var body interface{}
+	headers := map[string]string{"Content-Type": "application/json"}
+
+	switch hook.Kind {
+	case models.WebhookKindWebhook:
+		target = hook.URL
+		body = payload
+
+	case models.WebhookKindNtfy:
+		// Publish as JSON to the server root, with the topic in the body
+		u, err := url.Parse(hook.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ntfy topic URL: %w", err)
+		}
+		topic := path.Base(u.Path)
+		u.Path = path.Dir(u.Path)
+		target = u.String()
+		body = map[string]interface{}{
+			"topic":   topic,
+			"title":   payload.Title,
+			"message": payload.Body,
+			"click":   payload.URL,
+			"tags":    []string{"memo"},
+		}
+		if hook.Secret != "" {
+			headers["Authorization"] = "Bearer " + hook.Secret
+		}
+
+	case models.WebhookKindGotify:
+		target = strings.TrimSuffix(hook.URL, "/") + "/message"
+		body = map[string]interface{}{
+			"title":    payload.Title,
+			"message":  payload.Body,
+			"priority": 5,
+			"extras": map[string]interface{}{
+				"client::notification": map[string]interface{}{
+					"click": map[string]string{"url": payload.URL},
+				},
+			},
+		}
+		headers["X-Gotify-Key"] = hook.Secret
+
+	default:
+		return nil, fmt.Errorf("unknown webhook kind %q", hook.Kind)
+	}
+
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(encoded))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "AT-Todo-Webhooks/1.0")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	if hook.Kind == models.WebhookKindWebhook {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(HeaderEvent, payload.Event)
+		req.Header.Set(HeaderDelivery, deliveryID)
+		req.Header.Set(HeaderTimestamp, timestamp)
+		req.Header.Set(HeaderSignature, Sign(hook.Secret, timestamp, encoded))
+	}
+
+	return req, nil
+}
+
+// refusePrivate is a dialer hook that refuses loopback, private, link-local
+// and unspecified addresses, after DNS resolution
+func refusePrivate(network, address string, conn syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return errPrivateAddress
+	}
+	return nil
+}
+
+// randomID returns a random delivery ID, the same across retries so targets
+// can drop duplicates
+func randomID() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
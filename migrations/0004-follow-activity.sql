@@ -0,0 +1,38 @@
+-- Follow activity tracking
+-- Follows themselves live in the follower's AT Protocol repository (app.attodo.follow),
+-- this table only remembers how far we've notified each follower
+
+CREATE TABLE IF NOT EXISTS follow_activity_state (
+    did TEXT NOT NULL,
+    subject TEXT NOT NULL, -- AT URI of the followed list or DID of the followed user
+    last_seen_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (did, subject),
+    FOREIGN KEY (did) REFERENCES notification_users(did) ON DELETE CASCADE
+);
+
+-- Relax the notification_type check so new notification kinds
+-- (calendar_event, list_activity, ...) don't require a table rebuild each time.
+-- Types are validated in the application layer (models.NotificationType*).
+CREATE TABLE notification_history_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    did TEXT NOT NULL,
+    task_uri TEXT NOT NULL,
+    notification_type TEXT NOT NULL,
+    sent_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    status TEXT NOT NULL CHECK(status IN ('sent', 'failed', 'expired')),
+    error_message TEXT
+);
+
+INSERT INTO notification_history_new (id, did, task_uri, notification_type, sent_at, status, error_message)
+SELECT id, did, task_uri, notification_type, sent_at, status, error_message FROM notification_history;
+
+DROP TABLE notification_history;
+
+ALTER TABLE notification_history_new RENAME TO notification_history;
+
+CREATE INDEX IF NOT EXISTS idx_notification_history_task
+ON notification_history(did, task_uri, sent_at);
+
+CREATE INDEX IF NOT EXISTS idx_notification_history_sent_at
+ON notification_history(sent_at);
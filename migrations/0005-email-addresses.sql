@@ -0,0 +1,20 @@
+-- Email notification addresses
+-- Kept server-side only: settings records are public, addresses must not be.
+-- An address gets notifications once verified (double opt-in) and until the
+-- user follows an unsubscribe link.
+
+CREATE TABLE IF NOT EXISTS email_addresses (
+    did TEXT PRIMARY KEY,
+    email TEXT NOT NULL,
+    verify_token TEXT NOT NULL DEFAULT '', -- Cleared once verified
+    verify_sent_at DATETIME,
+    verified_at DATETIME,
+    unsubscribe_token TEXT NOT NULL UNIQUE,
+    unsubscribed_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (did) REFERENCES notification_users(did) ON DELETE CASCADE
+);
+
+-- Index for verification link lookups
+CREATE INDEX IF NOT EXISTS idx_email_addresses_verify_token ON email_addresses(verify_token);
@@ -0,0 +1,5 @@
+-- Bluesky DM notifications
+-- Set when a user opts in to DMs from the service account; the DM itself goes
+-- to the user's DID, so nothing else needs storing
+
+ALTER TABLE notification_users ADD COLUMN bluesky_dm_enabled BOOLEAN NOT NULL DEFAULT 0;
@@ -0,0 +1,23 @@
+-- Webhook notification targets
+-- Outgoing webhooks (HMAC-signed JSON) and ntfy/Gotify topics. A target is
+-- disabled after too many deliveries in a row fail, until the user turns it
+-- back on.
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    did TEXT NOT NULL,
+    kind TEXT NOT NULL CHECK(kind IN ('webhook', 'ntfy', 'gotify')),
+    name TEXT NOT NULL DEFAULT '',
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL DEFAULT '', -- HMAC secret for webhooks, access token for ntfy/Gotify
+    failure_count INTEGER NOT NULL DEFAULT 0, -- Consecutive failed deliveries
+    last_error TEXT NOT NULL DEFAULT '',
+    last_delivered_at DATETIME,
+    disabled_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (did) REFERENCES notification_users(did) ON DELETE CASCADE
+);
+
+-- Index for a user's targets
+CREATE INDEX IF NOT EXISTS idx_webhooks_did ON webhooks(did);
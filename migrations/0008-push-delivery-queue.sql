@@ -0,0 +1,47 @@
+-- Push delivery queue
+-- Push deliveries that failed with a rate limit (429), a server error (5xx) or
+-- a network error are queued here and retried with exponential backoff.
+
+CREATE TABLE IF NOT EXISTS push_delivery_queue (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    did TEXT NOT NULL,
+    endpoint TEXT NOT NULL,
+    payload TEXT NOT NULL, -- Notification JSON, as sent to the push service
+    notification_type TEXT NOT NULL DEFAULT '',
+    task_uri TEXT NOT NULL DEFAULT '', -- What the notification is about, for notification_history
+    attempts INTEGER NOT NULL DEFAULT 1,
+    next_attempt_at DATETIME NOT NULL,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (endpoint) REFERENCES push_subscriptions(endpoint) ON DELETE CASCADE
+);
+
+-- Index for picking up due deliveries
+CREATE INDEX IF NOT EXISTS idx_push_delivery_queue_next_attempt
+ON push_delivery_queue(next_attempt_at);
+
+-- Record push delivery per endpoint: add the endpoint column (empty for
+-- per-notification rows) and a 'retrying' status for queued deliveries
+CREATE TABLE notification_history_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    did TEXT NOT NULL,
+    task_uri TEXT NOT NULL,
+    notification_type TEXT NOT NULL,
+    sent_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    status TEXT NOT NULL CHECK(status IN ('sent', 'failed', 'expired', 'retrying')),
+    error_message TEXT,
+    endpoint TEXT NOT NULL DEFAULT ''
+);
+
+INSERT INTO notification_history_new (id, did, task_uri, notification_type, sent_at, status, error_message)
+SELECT id, did, task_uri, notification_type, sent_at, status, error_message FROM notification_history;
+
+DROP TABLE notification_history;
+
+ALTER TABLE notification_history_new RENAME TO notification_history;
+
+CREATE INDEX IF NOT EXISTS idx_notification_history_task
+ON notification_history(did, task_uri, sent_at);
+
+CREATE INDEX IF NOT EXISTS idx_notification_history_sent_at
+ON notification_history(sent_at);
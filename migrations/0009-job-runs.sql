@@ -0,0 +1,19 @@
+-- Background job run history
+-- One row per run of a background job, scheduled or triggered by hand. A run
+-- still marked running when the server starts was cut short by a restart.
+
+CREATE TABLE IF NOT EXISTS job_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    job_name TEXT NOT NULL,
+    triggered_by TEXT NOT NULL CHECK(triggered_by IN ('schedule', 'manual')),
+    status TEXT NOT NULL CHECK(status IN ('running', 'succeeded', 'failed', 'interrupted')),
+    started_at DATETIME NOT NULL,
+    finished_at DATETIME,
+    error_message TEXT NOT NULL DEFAULT ''
+);
+
+-- Index for a job's recent runs
+CREATE INDEX IF NOT EXISTS idx_job_runs_job_started ON job_runs(job_name, started_at);
+
+-- Index for the most recent runs of all jobs
+CREATE INDEX IF NOT EXISTS idx_job_runs_started ON job_runs(started_at);
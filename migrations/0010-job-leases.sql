@@ -0,0 +1,18 @@
+-- Leases for background jobs across server instances
+-- An instance runs a job only while it holds the job's lease, renewing it
+-- well before it expires. If the instance dies, the lease expires and another
+-- instance takes over. Times are stored in UTC so instances in different
+-- time zones compare them correctly.
+
+CREATE TABLE IF NOT EXISTS job_leases (
+    name TEXT PRIMARY KEY, -- e.g. job:NotificationCheck
+    holder TEXT NOT NULL, -- Instance ID
+    acquired_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL
+);
+
+-- Index for clearing out expired leases
+CREATE INDEX IF NOT EXISTS idx_job_leases_expires ON job_leases(expires_at);
+
+-- Which instance a job run ran on
+ALTER TABLE job_runs ADD COLUMN instance TEXT NOT NULL DEFAULT '';
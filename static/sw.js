@@ -1,5 +1,5 @@
 // Service Worker for AT Todo
-const CACHE_NAME = 'attodo-v4'; // Added server ping for periodic checks
+const CACHE_NAME = 'attodo-v5'; // Notification clicks open data.url when provided
 const HEALTH_CHECK_INTERVAL = 60000; // 60 seconds
 
 // Install event - cache essential resources
@@ -234,6 +234,13 @@ async function pingServerForCheck() {
 self.addEventListener('notificationclick', (event) => {
   event.notification.close();
 
+  // Notifications may link somewhere specific (e.g. a followed public list)
+  const data = event.notification.data || {};
+  if (data.url) {
+    event.waitUntil(clients.openWindow(data.url));
+    return;
+  }
+
   // Open the app
   event.waitUntil(
     clients.matchAll({ type: 'window' }).then((clientList) => {